@@ -22,7 +22,9 @@ func NewAccountClient(httpClient *HTTPClient) *AccountClient {
 
 
 // Register creates a new account
-func (c *AccountClient) Register(ctx context.Context, req *AccountRegisterRequest) (*AccountData, error) {
+func (c *AccountClient) Register(ctx context.Context, req *AccountRegisterRequest, callOpts ...CallOption) (*AccountData, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	var registerResp AccountRegisterResponse
 	err := c.httpClient.DoPostRequest(ctx, "/open-api/v3/accounts/register", req, &registerResp)
 	if err != nil {
@@ -42,7 +44,9 @@ func (c *AccountClient) Register(ctx context.Context, req *AccountRegisterReques
 
 // RegisterWithDetails creates a new account with specific field ordering as expected by the API
 // This function matches the exact curl command structure you provided
-func (c *AccountClient) RegisterWithDetails(ctx context.Context, phoneCountryCode, phoneNumber, email, name string) (*AccountData, error) {
+func (c *AccountClient) RegisterWithDetails(ctx context.Context, phoneCountryCode, phoneNumber, email, name string, callOpts ...CallOption) (*AccountData, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	// Create request with exact field order from the curl command
 	req := &AccountRegisterRequest{
 		PhoneCountryCode: phoneCountryCode,
@@ -55,7 +59,9 @@ func (c *AccountClient) RegisterWithDetails(ctx context.Context, phoneCountryCod
 }
 
 // RegisterGolangTest creates a test account with the exact data from your curl command
-func (c *AccountClient) RegisterGolangTest(ctx context.Context) (*AccountData, error) {
+func (c *AccountClient) RegisterGolangTest(ctx context.Context, callOpts ...CallOption) (*AccountData, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	req := &AccountRegisterRequest{
 		PhoneCountryCode: "86",
 		PhoneNumber:      "15900000031",
@@ -76,7 +82,9 @@ type AccountListOptions struct {
 }
 
 // List retrieves a list of accounts with optional filtering
-func (c *AccountClient) List(ctx context.Context, opts *AccountListOptions) (*AccountListData, error) {
+func (c *AccountClient) List(ctx context.Context, opts *AccountListOptions, callOpts ...CallOption) (*AccountListData, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	// Build query parameters
 	params := url.Values{}
 	
@@ -129,7 +137,9 @@ func (c *AccountClient) List(ctx context.Context, opts *AccountListOptions) (*Ac
 }
 
 // Get retrieves a specific account by ID
-func (c *AccountClient) Get(ctx context.Context, accountID string) (*AccountData, error) {
+func (c *AccountClient) Get(ctx context.Context, accountID string, callOpts ...CallOption) (*AccountData, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	opts := &AccountListOptions{
 		AccountID: accountID,
 		Limit:     1,
@@ -152,7 +162,9 @@ func (c *AccountClient) Get(ctx context.Context, accountID string) (*AccountData
 }
 
 // ListAll retrieves all accounts without pagination (automatically handles pagination)
-func (c *AccountClient) ListAll(ctx context.Context) ([]AccountData, error) {
+func (c *AccountClient) ListAll(ctx context.Context, callOpts ...CallOption) ([]AccountData, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	var allAccounts []AccountData
 	page := 1
 	limit := 100 // Use maximum limit for efficiency
@@ -182,7 +194,9 @@ func (c *AccountClient) ListAll(ctx context.Context) ([]AccountData, error) {
 }
 
 // ListByStatus retrieves accounts filtered by status
-func (c *AccountClient) ListByStatus(ctx context.Context, status string) ([]AccountData, error) {
+func (c *AccountClient) ListByStatus(ctx context.Context, status string, callOpts ...CallOption) ([]AccountData, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	opts := &AccountListOptions{
 		Status: status,
 		Limit:  100,
@@ -198,17 +212,23 @@ func (c *AccountClient) ListByStatus(ctx context.Context, status string) ([]Acco
 }
 
 // ListActiveAccounts retrieves all active accounts
-func (c *AccountClient) ListActiveAccounts(ctx context.Context) ([]AccountData, error) {
+func (c *AccountClient) ListActiveAccounts(ctx context.Context, callOpts ...CallOption) ([]AccountData, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	return c.ListByStatus(ctx, "ACTIVE")
 }
 
 // ListInactiveAccounts retrieves all inactive accounts
-func (c *AccountClient) ListInactiveAccounts(ctx context.Context) ([]AccountData, error) {
+func (c *AccountClient) ListInactiveAccounts(ctx context.Context, callOpts ...CallOption) ([]AccountData, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	return c.ListByStatus(ctx, "INACTIVE")
 }
 
 // ListByType retrieves accounts filtered by type
-func (c *AccountClient) ListByType(ctx context.Context, accountType int) ([]AccountData, error) {
+func (c *AccountClient) ListByType(ctx context.Context, accountType int, callOpts ...CallOption) ([]AccountData, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	opts := &AccountListOptions{
 		Type:  accountType,
 		Limit: 100,
@@ -224,7 +244,9 @@ func (c *AccountClient) ListByType(ctx context.Context, accountType int) ([]Acco
 }
 
 // Count returns the total number of accounts
-func (c *AccountClient) Count(ctx context.Context) (int, error) {
+func (c *AccountClient) Count(ctx context.Context, callOpts ...CallOption) (int, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	opts := &AccountListOptions{
 		Limit: 1,
 		Page:  1,
@@ -245,7 +267,9 @@ func (c *AccountClient) Count(ctx context.Context) (int, error) {
 }
 
 // GetAccountsByPage retrieves accounts with specific pagination settings
-func (c *AccountClient) GetAccountsByPage(ctx context.Context, page, limit int) (*AccountListData, error) {
+func (c *AccountClient) GetAccountsByPage(ctx context.Context, page, limit int, callOpts ...CallOption) (*AccountListData, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	opts := &AccountListOptions{
 		Page:  page,
 		Limit: limit,
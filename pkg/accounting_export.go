@@ -0,0 +1,873 @@
+package interlace
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Accounting entry sources
+const (
+	AccountingSourceCard            = "card"
+	AccountingSourceInfinityAccount = "infinity_account"
+)
+
+// AccountingEntry is a card or Infinity Account transaction normalized for accounting. Amounts
+// are signed from the account's point of view: spend is negative, refunds and deposits positive.
+type AccountingEntry struct {
+	ID        string
+	Source    string // AccountingSourceCard or AccountingSourceInfinityAccount
+	AccountID string
+	CardID    string
+	Date      time.Time
+	Payee     string
+	Memo      string
+	MCC       string
+	Category  string
+	Status    string
+	Reference string
+
+	// Amount and Currency are what the account was charged: the billing amount of card
+	// transactions
+	Amount   float64
+	Currency string
+	// OriginalAmount and OriginalCurrency are the merchant's amount, set when it differs from the
+	// charged currency, and ExchangeRate converts it into Currency
+	OriginalAmount   float64
+	OriginalCurrency string
+	ExchangeRate     float64
+	// SettlementAmount and SettlementCurrency are the settled amount of card transactions
+	SettlementAmount   float64
+	SettlementCurrency string
+
+	// Balance is the account balance after the entry, when known
+	Balance *float64
+}
+
+// ExpenseCategoryMap maps merchant category codes and Infinity Account transaction categories to
+// expense categories
+type ExpenseCategoryMap struct {
+	// MCC maps merchant category codes, or inclusive ranges such as "5811-5814", to categories.
+	// Exact codes take precedence over ranges.
+	MCC map[string]string
+	// Types maps Infinity Account transaction categories, such as FEE, to categories
+	Types map[string]string
+	// Default is the category of unmapped transactions. Defaults to "Uncategorized".
+	Default string
+}
+
+// DefaultExpenseCategories returns a category map covering common business spend
+func DefaultExpenseCategories() *ExpenseCategoryMap {
+	return &ExpenseCategoryMap{
+		MCC: map[string]string{
+			"3000-3350": "Travel", // airlines
+			"3351-3500": "Travel", // car rental
+			"3501-3999": "Travel", // lodging
+			"4011-4131": "Travel",
+			"4411":      "Travel",
+			"4511":      "Travel",
+			"4722":      "Travel",
+			"7011":      "Travel",
+			"7512":      "Travel",
+			"4121":      "Travel",
+			"4214-4215": "Shipping & Postage",
+			"9402":      "Shipping & Postage",
+			"4812-4816": "Telephone & Internet",
+			"4899":      "Subscriptions",
+			"5734":      "Software",
+			"5817-5818": "Software",
+			"7372":      "Software",
+			"5045":      "Office Equipment",
+			"5732":      "Office Equipment",
+			"5111":      "Office Supplies",
+			"5943":      "Office Supplies",
+			"5811-5814": "Meals & Entertainment",
+			"7311":      "Advertising & Marketing",
+			"5541-5542": "Vehicle",
+			"7523":      "Vehicle",
+			"8111":      "Professional Services",
+			"8931":      "Professional Services",
+			"8999":      "Professional Services",
+			"9311":      "Taxes",
+			"6010-6012": "Bank Fees",
+		},
+		Types: map[string]string{
+			"FEE": "Bank Fees",
+		},
+		Default: "Uncategorized",
+	}
+}
+
+// Category returns the category of a merchant category code
+func (m *ExpenseCategoryMap) Category(mcc string) string {
+	if category, ok := m.MCC[mcc]; ok {
+		return category
+	}
+	if code, err := strconv.Atoi(mcc); err == nil {
+		// Ranges are checked in order so that overlapping ranges resolve the same way every time
+		ranges := make([]string, 0, len(m.MCC))
+		for key := range m.MCC {
+			if strings.Contains(key, "-") {
+				ranges = append(ranges, key)
+			}
+		}
+		sort.Strings(ranges)
+		for _, key := range ranges {
+			low, high, _ := strings.Cut(key, "-")
+			lo, errLow := strconv.Atoi(strings.TrimSpace(low))
+			hi, errHigh := strconv.Atoi(strings.TrimSpace(high))
+			if errLow == nil && errHigh == nil && code >= lo && code <= hi {
+				return m.MCC[key]
+			}
+		}
+	}
+	return m.fallback()
+}
+
+func (m *ExpenseCategoryMap) fallback() string {
+	if m.Default == "" {
+		return "Uncategorized"
+	}
+	return m.Default
+}
+
+// ExportCheckpoint records what an incremental export has already exported
+type ExportCheckpoint struct {
+	// Until is the end of the period of the last export
+	Until time.Time `json:"until"`
+	// Exported holds the dates of the entries exported within the lookback window before Until,
+	// by ID
+	Exported map[string]time.Time `json:"exported"`
+}
+
+// ExportCheckpointStore keeps the checkpoints of incremental exports by name. Implementations must
+// be safe for concurrent use.
+type ExportCheckpointStore interface {
+	// Load returns the checkpoint of an export, or nil when it never ran
+	Load(ctx context.Context, name string) (*ExportCheckpoint, error)
+	Save(ctx context.Context, name string, checkpoint *ExportCheckpoint) error
+}
+
+// MemoryExportCheckpoints is an in-memory ExportCheckpointStore, useful for tests
+type MemoryExportCheckpoints struct {
+	mu          sync.Mutex
+	checkpoints map[string]ExportCheckpoint
+}
+
+// NewMemoryExportCheckpoints creates an empty in-memory checkpoint store
+func NewMemoryExportCheckpoints() *MemoryExportCheckpoints {
+	return &MemoryExportCheckpoints{checkpoints: make(map[string]ExportCheckpoint)}
+}
+
+// Load implements ExportCheckpointStore
+func (s *MemoryExportCheckpoints) Load(ctx context.Context, name string) (*ExportCheckpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	checkpoint, ok := s.checkpoints[name]
+	if !ok {
+		return nil, nil
+	}
+	checkpoint.Exported = copyExported(checkpoint.Exported)
+	return &checkpoint, nil
+}
+
+// Save implements ExportCheckpointStore
+func (s *MemoryExportCheckpoints) Save(ctx context.Context, name string, checkpoint *ExportCheckpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	saved := *checkpoint
+	saved.Exported = copyExported(checkpoint.Exported)
+	s.checkpoints[name] = saved
+	return nil
+}
+
+func copyExported(exported map[string]time.Time) map[string]time.Time {
+	copied := make(map[string]time.Time, len(exported))
+	for id, date := range exported {
+		copied[id] = date
+	}
+	return copied
+}
+
+// FileExportCheckpoints keeps the checkpoints in a JSON file, rewritten on every save
+type FileExportCheckpoints struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileExportCheckpoints creates a checkpoint store backed by the file at path, which is created
+// on the first save
+func NewFileExportCheckpoints(path string) *FileExportCheckpoints {
+	return &FileExportCheckpoints{path: path}
+}
+
+// Load implements ExportCheckpointStore
+func (s *FileExportCheckpoints) Load(ctx context.Context, name string) (*ExportCheckpoint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	checkpoints, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	return checkpoints[name], nil
+}
+
+// Save implements ExportCheckpointStore
+func (s *FileExportCheckpoints) Save(ctx context.Context, name string, checkpoint *ExportCheckpoint) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	checkpoints, err := s.load()
+	if err != nil {
+		return err
+	}
+	checkpoints[name] = checkpoint
+
+	data, err := json.MarshalIndent(checkpoints, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode export checkpoints: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write export checkpoints: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write export checkpoints: %w", err)
+	}
+	return nil
+}
+
+func (s *FileExportCheckpoints) load() (map[string]*ExportCheckpoint, error) {
+	checkpoints := make(map[string]*ExportCheckpoint)
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return checkpoints, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export checkpoints: %w", err)
+	}
+	if err := json.Unmarshal(data, &checkpoints); err != nil {
+		return nil, fmt.Errorf("failed to decode export checkpoints: %w", err)
+	}
+	return checkpoints, nil
+}
+
+// AccountingExportRequest selects the transactions to export
+type AccountingExportRequest struct {
+	AccountID string
+	// CardID restricts the card transactions to one card
+	CardID string
+	// Sources selects AccountingSourceCard and/or AccountingSourceInfinityAccount. Defaults to both.
+	Sources []string
+	// From and To bound the transaction dates, From included and To excluded. To defaults to now.
+	// From is ignored by incremental exports that already ran.
+	From time.Time
+	To   time.Time
+	// IncludePending exports transactions that are not final yet. By default they are left for a
+	// later export, once settled.
+	IncludePending bool
+
+	// Checkpoint makes the export incremental: only transactions not exported by a committed
+	// export of the same Name are returned
+	Checkpoint ExportCheckpointStore
+	// Name identifies the incremental export. Defaults to the account ID.
+	Name string
+	// Lookback is how far before the last export transactions are fetched again, to catch those
+	// that were still pending. Defaults to 7 days.
+	Lookback time.Duration
+}
+
+// AccountingExport holds the entries of an export. Once they are written, Commit records them
+// as exported for incremental exports.
+type AccountingExport struct {
+	AccountID string
+	From      time.Time
+	To        time.Time
+	Entries   []AccountingEntry
+
+	store      ExportCheckpointStore
+	name       string
+	lookback   time.Duration
+	checkpoint *ExportCheckpoint
+}
+
+// AccountingExporter exports card and Infinity Account transactions for accounting tools
+//
+//	exporter := interlace.NewAccountingExporter(client)
+//	export, err := exporter.Export(ctx, &interlace.AccountingExportRequest{
+//		AccountID:  accountID,
+//		Checkpoint: interlace.NewFileExportCheckpoints("exports.json"),
+//	})
+//	err = export.WriteCSV(f, interlace.CSVLayoutXero)
+//	err = export.Commit(ctx)
+type AccountingExporter struct {
+	client *Client
+	// Categories maps MCCs to expense categories. Defaults to DefaultExpenseCategories().
+	Categories *ExpenseCategoryMap
+}
+
+// NewAccountingExporter creates an exporter using the default expense categories
+func NewAccountingExporter(client *Client) *AccountingExporter {
+	return &AccountingExporter{client: client, Categories: DefaultExpenseCategories()}
+}
+
+// Export collects the transactions of the request, oldest first. Failed and declined
+// transactions are skipped.
+func (e *AccountingExporter) Export(ctx context.Context, req *AccountingExportRequest, callOpts ...CallOption) (*AccountingExport, error) {
+	if req == nil || req.AccountID == "" {
+		return nil, fmt.Errorf("account ID is required")
+	}
+	export := &AccountingExport{
+		AccountID: req.AccountID,
+		From:      req.From,
+		To:        req.To,
+		store:     req.Checkpoint,
+		name:      req.Name,
+		lookback:  req.Lookback,
+	}
+	if export.To.IsZero() {
+		export.To = time.Now()
+	}
+	if export.name == "" {
+		export.name = req.AccountID
+	}
+	if export.lookback <= 0 {
+		export.lookback = 7 * 24 * time.Hour
+	}
+
+	if export.store != nil {
+		checkpoint, err := export.store.Load(ctx, export.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load export checkpoint: %w", err)
+		}
+		if checkpoint != nil {
+			export.From = checkpoint.Until.Add(-export.lookback)
+		} else {
+			checkpoint = &ExportCheckpoint{}
+		}
+		if checkpoint.Exported == nil {
+			checkpoint.Exported = make(map[string]time.Time)
+		}
+		export.checkpoint = checkpoint
+	}
+	if !export.To.After(export.From) {
+		return nil, errors.New("export period is invalid: To must be after From")
+	}
+
+	sources := req.Sources
+	if len(sources) == 0 {
+		sources = []string{AccountingSourceCard, AccountingSourceInfinityAccount}
+	}
+	categories := e.Categories
+	if categories == nil {
+		categories = DefaultExpenseCategories()
+	}
+
+	var entries []AccountingEntry
+	for _, source := range sources {
+		var (
+			sourceEntries []AccountingEntry
+			err           error
+		)
+		switch source {
+		case AccountingSourceCard:
+			sourceEntries, err = e.cardEntries(ctx, req, export, categories, callOpts)
+		case AccountingSourceInfinityAccount:
+			sourceEntries, err = e.infinityEntries(ctx, req, export, categories, callOpts)
+		default:
+			return nil, fmt.Errorf("unknown accounting source %q", source)
+		}
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, sourceEntries...)
+	}
+
+	for _, entry := range entries {
+		if entry.Date.Before(export.From) || !entry.Date.Before(export.To) {
+			continue
+		}
+		if export.checkpoint != nil {
+			if _, ok := export.checkpoint.Exported[entry.Source+":"+entry.ID]; ok {
+				continue
+			}
+		}
+		export.Entries = append(export.Entries, entry)
+	}
+	sort.SliceStable(export.Entries, func(i, j int) bool {
+		return export.Entries[i].Date.Before(export.Entries[j].Date)
+	})
+
+	return export, nil
+}
+
+// exportable reports whether a transaction with the given status is exported
+func exportable(status string, includePending bool) bool {
+	if isFailedStatus(status) {
+		return false
+	}
+	return includePending || status == "" || statusState(status) == OperationSucceeded || strings.EqualFold(status, "SETTLED")
+}
+
+func (e *AccountingExporter) cardEntries(ctx context.Context, req *AccountingExportRequest, export *AccountingExport, categories *ExpenseCategoryMap, callOpts []CallOption) ([]AccountingEntry, error) {
+	options := &ListCardTransactionsOptions{
+		AccountID: req.AccountID,
+		CardID:    req.CardID,
+		StartTime: export.From.Format(time.RFC3339),
+		EndTime:   export.To.Format(time.RFC3339),
+		Limit:     selectPageSize,
+	}
+
+	var entries []AccountingEntry
+	err := walkPages(func(page int) (int, int, error) {
+		options.Page = page
+		list, err := e.client.CardTransaction.ListCardTransactions(ctx, options, callOpts...)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to list card transactions (page %d): %w", page, err)
+		}
+		for i := range list.List {
+			tx := &list.List[i]
+			if !exportable(tx.Status, req.IncludePending) {
+				continue
+			}
+			entries = append(entries, cardAccountingEntry(tx, req.AccountID, categories))
+		}
+		return len(list.List), list.Total, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// cardAccountingEntry books a card transaction in its billing currency, keeping the merchant
+// amount as the original amount when the currencies differ
+func cardAccountingEntry(tx *CardTransaction, accountID string, categories *ExpenseCategoryMap) AccountingEntry {
+	entry := AccountingEntry{
+		ID:                 tx.ID,
+		Source:             AccountingSourceCard,
+		AccountID:          accountID,
+		CardID:             tx.CardID,
+		Payee:              tx.MerchantName,
+		Memo:               tx.Description,
+		MCC:                tx.MerchantCategoryCode,
+		Category:           categories.Category(tx.MerchantCategoryCode),
+		Status:             tx.Status,
+		Reference:          tx.AuthorizationCode,
+		Amount:             math.Abs(tx.Amount),
+		Currency:           strings.ToUpper(tx.Currency),
+		SettlementAmount:   math.Abs(tx.SettlementAmount),
+		SettlementCurrency: strings.ToUpper(tx.SettlementCurrency),
+	}
+	if date, ok := parseAPITime(tx.TransactionTime); ok {
+		entry.Date = date
+	} else if date, ok := parseAPITime(tx.CreatedAt); ok {
+		entry.Date = date
+	}
+	if entry.Memo == "" {
+		entry.Memo = tx.MerchantName
+	}
+
+	if tx.BillingAmount != 0 && tx.BillingCurrency != "" && !strings.EqualFold(tx.BillingCurrency, tx.Currency) {
+		entry.OriginalAmount = entry.Amount
+		entry.OriginalCurrency = entry.Currency
+		entry.Amount = math.Abs(tx.BillingAmount)
+		entry.Currency = strings.ToUpper(tx.BillingCurrency)
+		entry.ExchangeRate = tx.ExchangeRate
+		if entry.ExchangeRate == 0 && entry.OriginalAmount != 0 {
+			entry.ExchangeRate = math.Round(entry.Amount/entry.OriginalAmount*1e6) / 1e6
+		}
+	}
+
+	if isCardDebit(tx) {
+		entry.Amount = -entry.Amount
+		entry.OriginalAmount = -entry.OriginalAmount
+		entry.SettlementAmount = -entry.SettlementAmount
+	}
+	return entry
+}
+
+// isCardDebit reports whether a card transaction takes funds from the card. Unlike isCardSpend,
+// which only counts purchases, transfers out and withdrawals are debits too.
+func isCardDebit(tx *CardTransaction) bool {
+	switch strings.ToUpper(tx.Type) {
+	case "REFUND", "REVERSAL", "CREDIT", "TRANSFER_IN", "DEPOSIT":
+		return false
+	case "":
+		return tx.Amount <= 0
+	}
+	return true
+}
+
+func (e *AccountingExporter) infinityEntries(ctx context.Context, req *AccountingExportRequest, export *AccountingExport, categories *ExpenseCategoryMap, callOpts []CallOption) ([]AccountingEntry, error) {
+	options := &ListInfinityAccountTransactionsOptions{
+		AccountID: req.AccountID,
+		StartTime: export.From.Format(time.RFC3339),
+		EndTime:   export.To.Format(time.RFC3339),
+		Limit:     selectPageSize,
+	}
+
+	var entries []AccountingEntry
+	err := walkPages(func(page int) (int, int, error) {
+		options.Page = page
+		list, err := e.client.InfinityAccount.ListInfinityAccountTransactions(ctx, options, callOpts...)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to list Infinity Account transactions (page %d): %w", page, err)
+		}
+		for i := range list.Transactions {
+			tx := &list.Transactions[i]
+			if !exportable(tx.Status, req.IncludePending) {
+				continue
+			}
+			category, ok := categories.Types[strings.ToUpper(tx.Category)]
+			if !ok {
+				category = categories.fallback()
+			}
+			balance := tx.BalanceAfter
+			entry := AccountingEntry{
+				ID:        tx.TransactionID,
+				Source:    AccountingSourceInfinityAccount,
+				AccountID: req.AccountID,
+				Payee:     tx.Category,
+				Memo:      tx.Description,
+				Category:  category,
+				Status:    tx.Status,
+				Reference: tx.MerchantTradeNo,
+				Amount:    math.Abs(tx.Amount),
+				Currency:  strings.ToUpper(tx.Currency),
+				Balance:   &balance,
+			}
+			if date, ok := parseAPITime(tx.CreatedAt); ok {
+				entry.Date = date
+			}
+			if strings.EqualFold(tx.Type, "DEBIT") || (tx.Type == "" && tx.Amount < 0) {
+				entry.Amount = -entry.Amount
+			}
+			entries = append(entries, entry)
+		}
+		return len(list.Transactions), list.TotalCount, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Commit records the entries of an incremental export as exported, so the next export of the
+// same name skips them. Call it once the export was written successfully. It does nothing for
+// exports without a checkpoint store.
+func (x *AccountingExport) Commit(ctx context.Context) error {
+	if x.store == nil {
+		return nil
+	}
+	checkpoint := &ExportCheckpoint{Until: x.To, Exported: copyExported(x.checkpoint.Exported)}
+	if x.checkpoint.Until.After(checkpoint.Until) {
+		checkpoint.Until = x.checkpoint.Until
+	}
+	for _, entry := range x.Entries {
+		checkpoint.Exported[entry.Source+":"+entry.ID] = entry.Date
+	}
+
+	// IDs older than what the next export fetches again are no longer needed
+	horizon := checkpoint.Until.Add(-x.lookback)
+	for id, date := range checkpoint.Exported {
+		if date.Before(horizon) {
+			delete(checkpoint.Exported, id)
+		}
+	}
+
+	if err := x.store.Save(ctx, x.name, checkpoint); err != nil {
+		return fmt.Errorf("failed to save export checkpoint: %w", err)
+	}
+	x.checkpoint = checkpoint
+	return nil
+}
+
+// CSVLayout is the column layout of a CSV export
+type CSVLayout string
+
+// CSV layouts
+const (
+	// CSVLayoutDetailed has every field of the entries
+	CSVLayoutDetailed CSVLayout = "detailed"
+	// CSVLayoutQuickBooks is the three-column Date, Description, Amount bank upload of QuickBooks
+	// Online, with MM/DD/YYYY dates
+	CSVLayoutQuickBooks CSVLayout = "quickbooks"
+	// CSVLayoutXero is the Xero bank statement import template, with DD/MM/YYYY dates
+	CSVLayoutXero CSVLayout = "xero"
+)
+
+func csvAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+// Currencies returns the currencies of the entries, sorted
+func (x *AccountingExport) Currencies() []string {
+	seen := make(map[string]bool)
+	var currencies []string
+	for _, entry := range x.Entries {
+		if !seen[entry.Currency] {
+			seen[entry.Currency] = true
+			currencies = append(currencies, entry.Currency)
+		}
+	}
+	sort.Strings(currencies)
+	return currencies
+}
+
+// SplitByCurrency returns one export per currency, for the layouts that have no currency column.
+// Commit the original export once all of them are written.
+func (x *AccountingExport) SplitByCurrency() map[string]*AccountingExport {
+	exports := make(map[string]*AccountingExport)
+	for _, entry := range x.Entries {
+		split, ok := exports[entry.Currency]
+		if !ok {
+			split = &AccountingExport{AccountID: x.AccountID, From: x.From, To: x.To}
+			exports[entry.Currency] = split
+		}
+		split.Entries = append(split.Entries, entry)
+	}
+	return exports
+}
+
+// WriteCSV writes the entries in the given layout. The QuickBooks and Xero layouts have no
+// currency column, so they fail for exports in several currencies: write each part of
+// SplitByCurrency instead.
+func (x *AccountingExport) WriteCSV(w io.Writer, layout CSVLayout) error {
+	if layout == CSVLayoutQuickBooks || layout == CSVLayoutXero {
+		if currencies := x.Currencies(); len(currencies) > 1 {
+			return fmt.Errorf("the %s layout has no currency column and the export has entries in %s: split it by currency", layout, strings.Join(currencies, ", "))
+		}
+	}
+
+	writer := csv.NewWriter(w)
+	switch layout {
+	case CSVLayoutQuickBooks:
+		writer.Write([]string{"Date", "Description", "Amount"})
+		for _, entry := range x.Entries {
+			writer.Write([]string{entry.Date.Format("01/02/2006"), entry.description(), csvAmount(entry.Amount)})
+		}
+	case CSVLayoutXero:
+		writer.Write([]string{"*Date", "*Amount", "Payee", "Description", "Reference", "Cheque Number"})
+		for _, entry := range x.Entries {
+			writer.Write([]string{entry.Date.Format("02/01/2006"), csvAmount(entry.Amount), entry.Payee, entry.description(), entry.ID, ""})
+		}
+	case CSVLayoutDetailed, "":
+		writer.Write([]string{"date", "id", "source", "account_id", "card_id", "payee", "memo", "mcc", "category", "amount", "currency", "original_amount", "original_currency", "exchange_rate", "settlement_amount", "settlement_currency", "status", "reference"})
+		for _, entry := range x.Entries {
+			row := []string{
+				entry.Date.Format(time.RFC3339), entry.ID, entry.Source, entry.AccountID, entry.CardID,
+				entry.Payee, entry.Memo, entry.MCC, entry.Category, csvAmount(entry.Amount), entry.Currency,
+				"", entry.OriginalCurrency, "", "", entry.SettlementCurrency, entry.Status, entry.Reference,
+			}
+			if entry.OriginalCurrency != "" {
+				row[11] = csvAmount(entry.OriginalAmount)
+				row[13] = strconv.FormatFloat(entry.ExchangeRate, 'f', -1, 64)
+			}
+			if entry.SettlementCurrency != "" {
+				row[14] = csvAmount(entry.SettlementAmount)
+			}
+			writer.Write(row)
+		}
+	default:
+		return fmt.Errorf("unknown CSV layout %q", layout)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// description returns the payee, memo and category of an entry as one line
+func (e *AccountingEntry) description() string {
+	parts := nonEmpty(e.Payee)
+	if e.Memo != "" && e.Memo != e.Payee {
+		parts = append(parts, e.Memo)
+	}
+	description := strings.Join(parts, " - ")
+	if e.Category != "" {
+		description += " [" + e.Category + "]"
+	}
+	if e.OriginalCurrency != "" {
+		description += fmt.Sprintf(" (%s %s @ %s)", csvAmount(math.Abs(e.OriginalAmount)), e.OriginalCurrency, strconv.FormatFloat(e.ExchangeRate, 'f', -1, 64))
+	}
+	return strings.TrimSpace(description)
+}
+
+// ofxDateFormat is the OFX date-time format
+const ofxDateFormat = "20060102150405"
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxTransaction struct {
+	Type         string           `xml:"TRNTYPE"`
+	Posted       string           `xml:"DTPOSTED"`
+	Amount       string           `xml:"TRNAMT"`
+	FITID        string           `xml:"FITID"`
+	SIC          string           `xml:"SIC,omitempty"`
+	Name         string           `xml:"NAME,omitempty"`
+	Memo         string           `xml:"MEMO,omitempty"`
+	OrigCurrency *ofxOrigCurrency `xml:"ORIGCURRENCY,omitempty"`
+}
+
+type ofxOrigCurrency struct {
+	Rate   string `xml:"CURRATE"`
+	Symbol string `xml:"CURSYM"`
+}
+
+type ofxTransactionList struct {
+	Start        string           `xml:"DTSTART"`
+	End          string           `xml:"DTEND"`
+	Transactions []ofxTransaction `xml:"STMTTRN"`
+}
+
+type ofxLedgerBalance struct {
+	Amount string `xml:"BALAMT"`
+	AsOf   string `xml:"DTASOF"`
+}
+
+type ofxCardStatement struct {
+	TRNUID    int                `xml:"TRNUID"`
+	Status    ofxStatus          `xml:"STATUS"`
+	Currency  string             `xml:"CCSTMTRS>CURDEF"`
+	AccountID string             `xml:"CCSTMTRS>CCACCTFROM>ACCTID"`
+	List      ofxTransactionList `xml:"CCSTMTRS>BANKTRANLIST"`
+	Balance   *ofxLedgerBalance  `xml:"CCSTMTRS>LEDGERBAL,omitempty"`
+}
+
+type ofxBankStatement struct {
+	TRNUID      int                `xml:"TRNUID"`
+	Status      ofxStatus          `xml:"STATUS"`
+	Currency    string             `xml:"STMTRS>CURDEF"`
+	BankID      string             `xml:"STMTRS>BANKACCTFROM>BANKID"`
+	AccountID   string             `xml:"STMTRS>BANKACCTFROM>ACCTID"`
+	AccountType string             `xml:"STMTRS>BANKACCTFROM>ACCTTYPE"`
+	List        ofxTransactionList `xml:"STMTRS>BANKTRANLIST"`
+	Balance     *ofxLedgerBalance  `xml:"STMTRS>LEDGERBAL,omitempty"`
+}
+
+type ofxDocument struct {
+	XMLName        xml.Name           `xml:"OFX"`
+	SignOnStatus   ofxStatus          `xml:"SIGNONMSGSRSV1>SONRS>STATUS"`
+	ServerDate     string             `xml:"SIGNONMSGSRSV1>SONRS>DTSERVER"`
+	Language       string             `xml:"SIGNONMSGSRSV1>SONRS>LANGUAGE"`
+	BankStatements []ofxBankStatement `xml:"BANKMSGSRSV1>STMTTRNRS,omitempty"`
+	CardStatements []ofxCardStatement `xml:"CREDITCARDMSGSRSV1>CCSTMTTRNRS,omitempty"`
+}
+
+// ofxTransactionType returns the OFX type of an entry
+func ofxTransactionType(entry *AccountingEntry) string {
+	if entry.Source == AccountingSourceInfinityAccount && strings.EqualFold(entry.Payee, "FEE") {
+		return "FEE"
+	}
+	if entry.Amount < 0 {
+		if entry.Source == AccountingSourceCard {
+			return "POS"
+		}
+		return "DEBIT"
+	}
+	return "CREDIT"
+}
+
+// WriteOFX writes the entries as an OFX 2.2 document. Card entries form credit card statements
+// and Infinity Account entries bank statements, one per currency. Entries charged in a currency
+// other than the merchant's carry it as ORIGCURRENCY with the exchange rate. LEDGERBAL is the
+// balance after the last entry that reports one. Card transactions carry no running balance, so
+// card statements have none rather than a made-up zero balance.
+func (x *AccountingExport) WriteOFX(w io.Writer) error {
+	doc := ofxDocument{
+		SignOnStatus: ofxStatus{Code: 0, Severity: "INFO"},
+		ServerDate:   time.Now().UTC().Format(ofxDateFormat),
+		Language:     "ENG",
+	}
+
+	type group struct {
+		source, currency string
+		entries          []*AccountingEntry
+	}
+	var groups []*group
+	index := make(map[string]*group)
+	for i := range x.Entries {
+		entry := &x.Entries[i]
+		key := entry.Source + ":" + entry.Currency
+		g, ok := index[key]
+		if !ok {
+			g = &group{source: entry.Source, currency: entry.Currency}
+			index[key] = g
+			groups = append(groups, g)
+		}
+		g.entries = append(g.entries, entry)
+	}
+
+	for i, g := range groups {
+		list := ofxTransactionList{
+			Start: x.From.UTC().Format(ofxDateFormat),
+			End:   x.To.UTC().Format(ofxDateFormat),
+		}
+		var balance *ofxLedgerBalance
+		for _, entry := range g.entries {
+			tx := ofxTransaction{
+				Type:   ofxTransactionType(entry),
+				Posted: entry.Date.UTC().Format(ofxDateFormat),
+				Amount: csvAmount(entry.Amount),
+				FITID:  entry.ID,
+				SIC:    entry.MCC,
+				Name:   truncateRunes(entry.Payee, 32),
+				Memo:   truncateRunes(entry.description(), 255),
+			}
+			if entry.OriginalCurrency != "" && entry.ExchangeRate != 0 {
+				// CURRATE converts the original amount into the statement currency
+				tx.OrigCurrency = &ofxOrigCurrency{
+					Rate:   strconv.FormatFloat(entry.ExchangeRate, 'f', -1, 64),
+					Symbol: entry.OriginalCurrency,
+				}
+			}
+			list.Transactions = append(list.Transactions, tx)
+			if entry.Balance != nil {
+				balance = &ofxLedgerBalance{Amount: csvAmount(*entry.Balance), AsOf: tx.Posted}
+			}
+		}
+
+		status := ofxStatus{Code: 0, Severity: "INFO"}
+		if g.source == AccountingSourceCard {
+			doc.CardStatements = append(doc.CardStatements, ofxCardStatement{
+				TRNUID: i + 1, Status: status, Currency: g.currency, AccountID: x.AccountID, List: list, Balance: balance,
+			})
+		} else {
+			doc.BankStatements = append(doc.BankStatements, ofxBankStatement{
+				TRNUID: i + 1, Status: status, Currency: g.currency, BankID: "INTERLACE", AccountID: x.AccountID,
+				AccountType: "CHECKING", List: list, Balance: balance,
+			})
+		}
+	}
+
+	header := xml.Header + `<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n"
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write OFX: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func truncateRunes(value string, max int) string {
+	if runes := []rune(value); len(runes) > max {
+		return string(runes[:max])
+	}
+	return value
+}
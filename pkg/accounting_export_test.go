@@ -0,0 +1,234 @@
+package interlace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testExportStart = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+// exportDay returns the date of the given day of the test export period
+func exportDay(day int) time.Time {
+	return testExportStart.AddDate(0, 0, day)
+}
+
+// cardTransactionServer lists its card transactions in one page, whatever the period asked
+type cardTransactionServer struct {
+	mu           sync.Mutex
+	transactions []CardTransaction
+}
+
+func (s *cardTransactionServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	json.NewEncoder(w).Encode(CardTransactionListResponse{List: s.transactions, Total: len(s.transactions)})
+}
+
+func (s *cardTransactionServer) set(transactions ...CardTransaction) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.transactions = transactions
+}
+
+func testCardTransaction(id string, day int, status string) CardTransaction {
+	return CardTransaction{ID: id, CardID: "card-1", Type: "PURCHASE", Amount: 10, Currency: "USD", Status: status,
+		MerchantName: "Shop", MerchantCategoryCode: "5943", TransactionTime: exportDay(day).Format(time.RFC3339)}
+}
+
+func newTestAccountingExporter(t *testing.T, handler http.Handler) *AccountingExporter {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	cfg := DefaultConfig()
+	cfg.BaseURL = srv.URL
+	cfg.MaxRetries = 0
+	return NewAccountingExporter(NewClientWithToken(cfg, "token"))
+}
+
+func entryIDs(export *AccountingExport) []string {
+	var ids []string
+	for _, entry := range export.Entries {
+		ids = append(ids, entry.ID)
+	}
+	return ids
+}
+
+func TestExpenseCategoryMap(t *testing.T) {
+	defaults := DefaultExpenseCategories()
+	custom := &ExpenseCategoryMap{
+		MCC:     map[string]string{"5500-5599": "Narrow", "5000-5999": "Wide", "5555": "Exact"},
+		Default: "Other",
+	}
+
+	tests := []struct {
+		name       string
+		categories *ExpenseCategoryMap
+		mcc        string
+		want       string
+	}{
+		{name: "exact code", categories: defaults, mcc: "4411", want: "Travel"},
+		{name: "within a range", categories: defaults, mcc: "5812", want: "Meals & Entertainment"},
+		{name: "range lower bound", categories: defaults, mcc: "3000", want: "Travel"},
+		{name: "range upper bound", categories: defaults, mcc: "4215", want: "Shipping & Postage"},
+		{name: "unmapped", categories: defaults, mcc: "5999", want: "Uncategorized"},
+		{name: "not a number", categories: defaults, mcc: "abcd", want: "Uncategorized"},
+		{name: "exact code wins over ranges", categories: custom, mcc: "5555", want: "Exact"},
+		{name: "overlapping ranges resolve in order", categories: custom, mcc: "5510", want: "Wide"},
+		{name: "custom default", categories: custom, mcc: "6000", want: "Other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.categories.Category(tt.mcc))
+		})
+	}
+}
+
+func TestCardAccountingEntry(t *testing.T) {
+	categories := DefaultExpenseCategories()
+
+	tests := []struct {
+		name string
+		tx   CardTransaction
+		want AccountingEntry
+	}{
+		{
+			name: "same currency",
+			tx: CardTransaction{ID: "tx-1", Type: "PURCHASE", Amount: 12.5, Currency: "usd", MerchantName: "Cafe",
+				MerchantCategoryCode: "5812", BillingAmount: 12.5, BillingCurrency: "USD"},
+			want: AccountingEntry{Amount: -12.5, Currency: "USD"},
+		},
+		{
+			name: "charged in another currency",
+			tx: CardTransaction{ID: "tx-1", Type: "PURCHASE", Amount: 100, Currency: "EUR", MerchantName: "Cafe",
+				MerchantCategoryCode: "5812", BillingAmount: 110, BillingCurrency: "usd"},
+			want: AccountingEntry{Amount: -110, Currency: "USD", OriginalAmount: -100, OriginalCurrency: "EUR", ExchangeRate: 1.1},
+		},
+		{
+			name: "reported exchange rate",
+			tx: CardTransaction{ID: "tx-1", Type: "PURCHASE", Amount: 100, Currency: "EUR", MerchantName: "Cafe",
+				MerchantCategoryCode: "5812", BillingAmount: 110, BillingCurrency: "USD", ExchangeRate: 1.0987},
+			want: AccountingEntry{Amount: -110, Currency: "USD", OriginalAmount: -100, OriginalCurrency: "EUR", ExchangeRate: 1.0987},
+		},
+		{
+			name: "refund in another currency",
+			tx: CardTransaction{ID: "tx-1", Type: "REFUND", Amount: 100, Currency: "EUR", MerchantName: "Cafe",
+				MerchantCategoryCode: "5812", BillingAmount: 110, BillingCurrency: "USD"},
+			want: AccountingEntry{Amount: 110, Currency: "USD", OriginalAmount: 100, OriginalCurrency: "EUR", ExchangeRate: 1.1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := cardAccountingEntry(&tt.tx, "acc-1", categories)
+			assert.Equal(t, "Meals & Entertainment", entry.Category)
+			assert.Equal(t, tt.want.Amount, entry.Amount)
+			assert.Equal(t, tt.want.Currency, entry.Currency)
+			assert.Equal(t, tt.want.OriginalAmount, entry.OriginalAmount)
+			assert.Equal(t, tt.want.OriginalCurrency, entry.OriginalCurrency)
+			assert.Equal(t, tt.want.ExchangeRate, entry.ExchangeRate)
+		})
+	}
+}
+
+func TestAccountingExportSplitByCurrency(t *testing.T) {
+	export := &AccountingExport{AccountID: "acc-1", Entries: []AccountingEntry{
+		{ID: "tx-1", Date: exportDay(1), Payee: "Cafe", Amount: -110, Currency: "USD", OriginalAmount: -100,
+			OriginalCurrency: "EUR", ExchangeRate: 1.1},
+		{ID: "tx-2", Date: exportDay(2), Payee: "Shop", Amount: -20, Currency: "EUR"},
+		{ID: "tx-3", Date: exportDay(3), Payee: "Store", Amount: -5, Currency: "USD"},
+	}}
+	assert.Equal(t, []string{"EUR", "USD"}, export.Currencies())
+
+	// The entry charged in USD is booked in USD, not in the merchant's EUR
+	var buf bytes.Buffer
+	assert.Error(t, export.WriteCSV(&buf, CSVLayoutXero), "the layout has no currency column")
+
+	splits := export.SplitByCurrency()
+	require.Len(t, splits, 2)
+	assert.Equal(t, []string{"tx-1", "tx-3"}, entryIDs(splits["USD"]))
+	assert.Equal(t, []string{"tx-2"}, entryIDs(splits["EUR"]))
+
+	buf.Reset()
+	require.NoError(t, splits["USD"].WriteCSV(&buf, CSVLayoutXero))
+	assert.Equal(t, "*Date,*Amount,Payee,Description,Reference,Cheque Number\n"+
+		"02/03/2024,-110.00,Cafe,Cafe (100.00 EUR @ 1.1),tx-1,\n"+
+		"04/03/2024,-5.00,Store,Store,tx-3,\n", buf.String())
+}
+
+func TestAccountingExportCheckpoint(t *testing.T) {
+	server := &cardTransactionServer{}
+	exporter := newTestAccountingExporter(t, server)
+	store := NewMemoryExportCheckpoints()
+	export := func(to int) *AccountingExport {
+		result, err := exporter.Export(context.Background(), &AccountingExportRequest{
+			AccountID:  "acc-1",
+			Sources:    []string{AccountingSourceCard},
+			From:       testExportStart,
+			To:         exportDay(to),
+			Checkpoint: store,
+		})
+		require.NoError(t, err)
+		return result
+	}
+
+	// Pending transactions are left for a later export
+	server.set(testCardTransaction("tx-1", 2, "SETTLED"), testCardTransaction("tx-2", 8, "PENDING"))
+	first := export(10)
+	assert.Equal(t, []string{"tx-1"}, entryIDs(first))
+	assert.Equal(t, []string{"tx-1"}, entryIDs(export(10)), "an export that is not committed is returned again")
+	require.NoError(t, first.Commit(context.Background()))
+
+	// The next export looks back 7 days before the last one, catching tx-2 once settled
+	server.set(testCardTransaction("tx-1", 2, "SETTLED"), testCardTransaction("tx-2", 8, "SETTLED"),
+		testCardTransaction("tx-3", 15, "SETTLED"))
+	second := export(20)
+	assert.Equal(t, exportDay(3), second.From)
+	assert.Equal(t, []string{"tx-2", "tx-3"}, entryIDs(second))
+	require.NoError(t, second.Commit(context.Background()))
+
+	// Only the IDs within the lookback window of the last export are kept
+	checkpoint, err := store.Load(context.Background(), "acc-1")
+	require.NoError(t, err)
+	assert.Equal(t, exportDay(20), checkpoint.Until)
+	assert.Equal(t, map[string]time.Time{"card:tx-3": exportDay(15)}, checkpoint.Exported)
+
+	assert.Empty(t, export(25).Entries)
+}
+
+func TestAccountingExportWriteOFX(t *testing.T) {
+	afterDeposit, afterFee := 250.0, 240.0
+	export := &AccountingExport{AccountID: "acc-1", From: testExportStart, To: exportDay(10), Entries: []AccountingEntry{
+		{ID: "tx-1", Source: AccountingSourceCard, Date: exportDay(1), Payee: "Cafe", MCC: "5812", Amount: -110,
+			Currency: "USD", OriginalAmount: -100, OriginalCurrency: "EUR", ExchangeRate: 1.1},
+		{ID: "ia-1", Source: AccountingSourceInfinityAccount, Date: exportDay(2), Payee: "TRANSFER_IN", Amount: 250,
+			Currency: "USD", Balance: &afterDeposit},
+		{ID: "ia-2", Source: AccountingSourceInfinityAccount, Date: exportDay(3), Payee: "FEE", Amount: -10,
+			Currency: "USD", Balance: &afterFee},
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, export.WriteOFX(&buf))
+	ofx := buf.String()
+
+	card := ofx[strings.Index(ofx, "<CREDITCARDMSGSRSV1>"):]
+	assert.Contains(t, card, "<TRNTYPE>POS</TRNTYPE>")
+	assert.Contains(t, card, "<CURRATE>1.1</CURRATE>")
+	assert.Contains(t, card, "<CURSYM>EUR</CURSYM>")
+	assert.NotContains(t, card, "LEDGERBAL", "card statements have no balance")
+
+	bank := ofx[strings.Index(ofx, "<BANKMSGSRSV1>"):strings.Index(ofx, "<CREDITCARDMSGSRSV1>")]
+	assert.Contains(t, bank, "<TRNTYPE>FEE</TRNTYPE>")
+	assert.Contains(t, bank, "<LEDGERBAL>\n          <BALAMT>240.00</BALAMT>\n          <DTASOF>20240304000000</DTASOF>",
+		"the balance after the last entry")
+}
@@ -0,0 +1,738 @@
+package interlace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrApprovalRequired is returned, wrapped in an *ApprovalRequiredError, when a request was
+// captured as a pending approval instead of being sent. Check for it with errors.Is.
+var ErrApprovalRequired = errors.New("interlace: approval required")
+
+// ErrApprovalNotFound is returned for unknown approval IDs
+var ErrApprovalNotFound = errors.New("interlace: approval not found")
+
+// ErrApprovalExpired is returned when acting on an approval past its expiry
+var ErrApprovalExpired = errors.New("interlace: approval expired")
+
+// ApprovalRequiredError reports the pending approval a request was captured as
+type ApprovalRequiredError struct {
+	Approval *PendingApproval
+}
+
+func (e *ApprovalRequiredError) Error() string {
+	if e.Approval.Currency == "" {
+		return fmt.Sprintf("%v: %s is pending approval %s (%d approvals needed)",
+			ErrApprovalRequired, e.Approval.Operation, e.Approval.ID, e.Approval.RequiredApprovals)
+	}
+	return fmt.Sprintf("%v: %s of %s %s is pending approval %s (%d approvals needed)",
+		ErrApprovalRequired, e.Approval.Operation, formatAmount(e.Approval.Amount), e.Approval.Currency,
+		e.Approval.ID, e.Approval.RequiredApprovals)
+}
+
+// Is makes errors.Is(err, ErrApprovalRequired) match
+func (e *ApprovalRequiredError) Is(target error) bool {
+	return target == ErrApprovalRequired
+}
+
+// ApprovalConfig configures the optional maker-checker approval layer of the HTTP client. Payouts
+// (including accepted quotations), business transfers, blockchain transfers, card top-ups and
+// budget increases above the threshold of their currency are not sent: they are stored as pending
+// approvals and the call fails with ErrApprovalRequired. Requests are recognized by endpoint, and
+// those whose amount is not known, such as accepted quotations, always need approval. The
+// requester must be identified with WithRequester. Once RequiredApprovals distinct approvers
+// other than the requester have approved, ApprovalClient.Execute sends the request exactly as it
+// was captured. Sending a request again with the same idempotency key returns its approval
+// instead of capturing it twice.
+type ApprovalConfig struct {
+	// Thresholds maps a currency to the amount above which requests need approval. The "*" entry
+	// applies to the other currencies; without it, requests in unlisted currencies always need
+	// approval.
+	Thresholds map[string]float64
+	// RequiredApprovals is the number of distinct approvers needed. Defaults to 2.
+	RequiredApprovals int
+	// TTL is how long an approval stays valid, from the request until execution. Defaults to 24h.
+	TTL time.Duration
+	// Store keeps the pending approvals. Defaults to an in-memory store, which loses them when the
+	// process exits or the client configuration changes.
+	Store ApprovalStore
+
+	// OnEvent is called after every audit event is recorded. It must not block.
+	OnEvent func(approval *PendingApproval, event ApprovalEvent)
+}
+
+// ApprovalStatus is the status of a pending approval
+type ApprovalStatus string
+
+// Approval statuses
+const (
+	ApprovalStatusPending  ApprovalStatus = "pending"
+	ApprovalStatusApproved ApprovalStatus = "approved"
+	ApprovalStatusRejected ApprovalStatus = "rejected"
+	ApprovalStatusExpired  ApprovalStatus = "expired"
+	ApprovalStatusExecuted ApprovalStatus = "executed"
+	// ApprovalStatusFailed is set when the API rejected the approved request
+	ApprovalStatusFailed ApprovalStatus = "failed"
+)
+
+// ApprovalAction is the action of an audit event
+type ApprovalAction string
+
+// Approval audit actions
+const (
+	ApprovalActionRequested       ApprovalAction = "requested"
+	ApprovalActionApproved        ApprovalAction = "approved"
+	ApprovalActionRejected        ApprovalAction = "rejected"
+	ApprovalActionExpired         ApprovalAction = "expired"
+	ApprovalActionExecuted        ApprovalAction = "executed"
+	ApprovalActionExecutionFailed ApprovalAction = "execution_failed"
+)
+
+// ApprovalEvent is an entry of the audit trail of an approval
+type ApprovalEvent struct {
+	Time    time.Time      `json:"time"`
+	Actor   string         `json:"actor,omitempty"`
+	Action  ApprovalAction `json:"action"`
+	Comment string         `json:"comment,omitempty"`
+}
+
+// PendingApproval is a captured money-movement request and its approval state
+type PendingApproval struct {
+	ID string `json:"id"`
+	// Operation is "payout", "business_transfer", "blockchain_transfer", "card_transfer" or
+	// "budget_increase"
+	Operation string `json:"operation"`
+	// Amount and Currency are empty when the request does not state them
+	Amount   float64        `json:"amount"`
+	Currency string         `json:"currency"`
+	Status   ApprovalStatus `json:"status"`
+
+	RequestedBy       string    `json:"requestedBy,omitempty"`
+	RequestedAt       time.Time `json:"requestedAt"`
+	ExpiresAt         time.Time `json:"expiresAt"`
+	RequiredApprovals int       `json:"requiredApprovals"`
+	// Approvers lists the distinct users who approved, in order
+	Approvers []string `json:"approvers,omitempty"`
+
+	// Method, Endpoint, Body, IdempotencyKey and OnBehalfOf are the captured request
+	Method         string          `json:"method"`
+	Endpoint       string          `json:"endpoint"`
+	Body           json.RawMessage `json:"body"`
+	IdempotencyKey string          `json:"idempotencyKey,omitempty"`
+	OnBehalfOf     string          `json:"onBehalfOf,omitempty"`
+
+	// Result is the API response of the executed request
+	Result json.RawMessage `json:"result,omitempty"`
+	Audit  []ApprovalEvent `json:"audit"`
+}
+
+func (a *PendingApproval) clone() *PendingApproval {
+	cloned := *a
+	cloned.Approvers = append([]string(nil), a.Approvers...)
+	cloned.Audit = append([]ApprovalEvent(nil), a.Audit...)
+	cloned.Body = append(json.RawMessage(nil), a.Body...)
+	cloned.Result = append(json.RawMessage(nil), a.Result...)
+	return &cloned
+}
+
+// ApprovalStore keeps pending approvals. Implementations must be safe for concurrent use.
+type ApprovalStore interface {
+	// Save creates or replaces an approval
+	Save(ctx context.Context, approval *PendingApproval) error
+	// Get returns an approval, or an error matching ErrApprovalNotFound
+	Get(ctx context.Context, id string) (*PendingApproval, error)
+	// List returns every approval, oldest first
+	List(ctx context.Context) ([]*PendingApproval, error)
+}
+
+// MemoryApprovalStore is an in-memory ApprovalStore. Approvals are lost when the process exits.
+type MemoryApprovalStore struct {
+	mu        sync.Mutex
+	approvals map[string]*PendingApproval
+}
+
+// NewMemoryApprovalStore creates an empty in-memory approval store
+func NewMemoryApprovalStore() *MemoryApprovalStore {
+	return &MemoryApprovalStore{approvals: make(map[string]*PendingApproval)}
+}
+
+// Save implements ApprovalStore
+func (s *MemoryApprovalStore) Save(ctx context.Context, approval *PendingApproval) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.approvals[approval.ID] = approval.clone()
+	return nil
+}
+
+// Get implements ApprovalStore
+func (s *MemoryApprovalStore) Get(ctx context.Context, id string) (*PendingApproval, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	approval, ok := s.approvals[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrApprovalNotFound, id)
+	}
+	return approval.clone(), nil
+}
+
+// List implements ApprovalStore
+func (s *MemoryApprovalStore) List(ctx context.Context) ([]*PendingApproval, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	approvals := make([]*PendingApproval, 0, len(s.approvals))
+	for _, approval := range s.approvals {
+		approvals = append(approvals, approval.clone())
+	}
+	sortApprovals(approvals)
+	return approvals, nil
+}
+
+// FileApprovalStore keeps the approvals in a JSON file, rewritten atomically on every change. It
+// is safe for concurrent use within one process only.
+type FileApprovalStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileApprovalStore creates an approval store backed by the file at path, which is created on
+// the first change
+func NewFileApprovalStore(path string) *FileApprovalStore {
+	return &FileApprovalStore{path: path}
+}
+
+// Save implements ApprovalStore
+func (s *FileApprovalStore) Save(ctx context.Context, approval *PendingApproval) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	approvals, err := s.load()
+	if err != nil {
+		return err
+	}
+	approvals[approval.ID] = approval
+
+	list := make([]*PendingApproval, 0, len(approvals))
+	for _, a := range approvals {
+		list = append(list, a)
+	}
+	sortApprovals(list)
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode approvals: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write approvals: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write approvals: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write approvals: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write approvals: %w", err)
+	}
+	return nil
+}
+
+// Get implements ApprovalStore
+func (s *FileApprovalStore) Get(ctx context.Context, id string) (*PendingApproval, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	approvals, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	approval, ok := approvals[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrApprovalNotFound, id)
+	}
+	return approval, nil
+}
+
+// List implements ApprovalStore
+func (s *FileApprovalStore) List(ctx context.Context) ([]*PendingApproval, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	approvals, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*PendingApproval, 0, len(approvals))
+	for _, approval := range approvals {
+		list = append(list, approval)
+	}
+	sortApprovals(list)
+	return list, nil
+}
+
+// load reads the approvals of the file, which may not exist yet
+func (s *FileApprovalStore) load() (map[string]*PendingApproval, error) {
+	approvals := make(map[string]*PendingApproval)
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return approvals, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read approvals: %w", err)
+	}
+
+	var list []*PendingApproval
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to decode approvals: %w", err)
+	}
+	for _, approval := range list {
+		approvals[approval.ID] = approval
+	}
+	return approvals, nil
+}
+
+func sortApprovals(approvals []*PendingApproval) {
+	sort.Slice(approvals, func(i, j int) bool {
+		return approvals[i].RequestedAt.Before(approvals[j].RequestedAt)
+	})
+}
+
+// approvalRoute is a money-movement endpoint subject to approval. Requests are matched by
+// method and endpoint, so that typed and pre-encoded bodies are gated alike.
+type approvalRoute struct {
+	operation string
+	method    string
+	// endpoint is the endpoint path, where * matches one path segment
+	endpoint string
+	// amountField and currencyField are the JSON fields of the amount moved. Requests without
+	// them always need approval.
+	amountField   string
+	currencyField string
+}
+
+// approvalRoutes are the endpoints subject to approval
+var approvalRoutes = []approvalRoute{
+	{"payout", "POST", "/open-api/v3/payment", "sourceAmount", "sourceCurrency"},
+	{"payout", "POST", "/open-api/v3/payment/quotation/*/accept", "", ""},
+	{"business_transfer", "POST", "/open-api/v3/business/transfer/external", "amount", "currency"},
+	{"business_transfer", "POST", "/open-api/v3/business/transfer/internal", "amount", "currency"},
+	{"blockchain_transfer", "POST", "/open-api/v3/cryptoconnect/transfers", "amount", "currency"},
+	{"card_transfer", "POST", "/open-api/v3/cards/transfer-in", "amount", "currency"},
+	{"budget_increase", "POST", "/open-api/v3/budgets/*/increase", "amount", "currency"},
+}
+
+// matchApprovalRoute returns the route of a request, or nil when it does not need approval
+func matchApprovalRoute(method, endpoint string) *approvalRoute {
+	if i := strings.IndexByte(endpoint, '?'); i >= 0 {
+		endpoint = endpoint[:i]
+	}
+	segments := strings.Split(strings.TrimSuffix(endpoint, "/"), "/")
+	for i := range approvalRoutes {
+		route := &approvalRoutes[i]
+		if !strings.EqualFold(route.method, method) {
+			continue
+		}
+		pattern := strings.Split(route.endpoint, "/")
+		if len(pattern) != len(segments) {
+			continue
+		}
+		matched := true
+		for j := range pattern {
+			if pattern[j] != "*" && pattern[j] != segments[j] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return route
+		}
+	}
+	return nil
+}
+
+// amount returns the amount and currency moved by an encoded request body. known is false when
+// the body does not state them.
+func (r *approvalRoute) amount(body []byte) (amount float64, currency string, known bool) {
+	if r.amountField == "" {
+		return 0, "", false
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return 0, "", false
+	}
+	json.Unmarshal(fields[r.currencyField], &currency)
+
+	// Amounts are numbers or decimal strings depending on the endpoint
+	raw := strings.Trim(string(fields[r.amountField]), `"`)
+	amount, err := strconv.ParseFloat(raw, 64)
+	if err != nil || currency == "" {
+		return 0, currency, false
+	}
+	return amount, currency, true
+}
+
+// approvalGate captures the requests that need approval and tracks their approval
+type approvalGate struct {
+	config ApprovalConfig
+	// mu serializes the read-modify-write cycles on the store
+	mu sync.Mutex
+}
+
+func newApprovalGate(config ApprovalConfig) *approvalGate {
+	if config.RequiredApprovals <= 0 {
+		config.RequiredApprovals = 2
+	}
+	if config.TTL <= 0 {
+		config.TTL = 24 * time.Hour
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryApprovalStore()
+	}
+	return &approvalGate{config: config}
+}
+
+// requiresApproval reports whether an amount in the currency is above its threshold
+func (g *approvalGate) requiresApproval(amount float64, currency string) bool {
+	threshold, ok := g.config.Thresholds[strings.ToUpper(currency)]
+	if !ok {
+		threshold, ok = g.config.Thresholds["*"]
+	}
+	return !ok || amount > threshold
+}
+
+// capture stores the request as a pending approval when it needs one and returns the
+// *ApprovalRequiredError to fail the call with. A request sent again with the idempotency key of
+// a pending or approved request fails with the existing approval.
+func (g *approvalGate) capture(ctx context.Context, opts *RequestOptions, callOpts *callOptions, body []byte, idempotencyKey string) error {
+	if callOpts.approvalID != "" {
+		return nil
+	}
+	route := matchApprovalRoute(opts.Method, opts.Endpoint)
+	if route == nil {
+		return nil
+	}
+	amount, currency, known := route.amount(body)
+	if known && !g.requiresApproval(amount, currency) {
+		return nil
+	}
+	if callOpts.requester == "" {
+		return fmt.Errorf("%s requires approval: identify the requester with WithRequester", route.operation)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if idempotencyKey != "" {
+		existing, err := g.findByIdempotencyKey(ctx, idempotencyKey)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			if existing.Method != opts.Method || existing.Endpoint != opts.Endpoint || string(existing.Body) != string(body) {
+				return fmt.Errorf("idempotency key %s is already used by approval %s for a different request", idempotencyKey, existing.ID)
+			}
+			return &ApprovalRequiredError{Approval: existing}
+		}
+	}
+
+	now := time.Now()
+	approval := &PendingApproval{
+		ID:                "apr-" + NewIdempotencyKey(),
+		Operation:         route.operation,
+		Amount:            amount,
+		Currency:          strings.ToUpper(currency),
+		Status:            ApprovalStatusPending,
+		RequestedBy:       callOpts.requester,
+		RequestedAt:       now,
+		ExpiresAt:         now.Add(g.config.TTL),
+		RequiredApprovals: g.config.RequiredApprovals,
+		Method:            opts.Method,
+		Endpoint:          opts.Endpoint,
+		Body:              body,
+		IdempotencyKey:    idempotencyKey,
+		OnBehalfOf:        callOpts.onBehalfOf,
+	}
+	g.record(approval, callOpts.requester, ApprovalActionRequested, "")
+	if err := g.config.Store.Save(ctx, approval); err != nil {
+		return fmt.Errorf("failed to save pending approval: %w", err)
+	}
+	g.notify(approval)
+
+	return &ApprovalRequiredError{Approval: approval}
+}
+
+// findByIdempotencyKey returns the pending or approved approval with the idempotency key, or nil.
+// g.mu must be held.
+func (g *approvalGate) findByIdempotencyKey(ctx context.Context, key string) (*PendingApproval, error) {
+	approvals, err := g.config.Store.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list approvals: %w", err)
+	}
+	for _, approval := range approvals {
+		if approval.IdempotencyKey != key {
+			continue
+		}
+		if err := g.expire(ctx, approval); err != nil {
+			return nil, err
+		}
+		if approval.Status == ApprovalStatusPending || approval.Status == ApprovalStatusApproved {
+			return approval, nil
+		}
+	}
+	return nil, nil
+}
+
+// record appends an audit event to the approval
+func (g *approvalGate) record(approval *PendingApproval, actor string, action ApprovalAction, comment string) {
+	approval.Audit = append(approval.Audit, ApprovalEvent{
+		Time:    time.Now(),
+		Actor:   actor,
+		Action:  action,
+		Comment: comment,
+	})
+}
+
+// notify calls OnEvent with the last audit event of the approval
+func (g *approvalGate) notify(approval *PendingApproval) {
+	if g.config.OnEvent != nil && len(approval.Audit) > 0 {
+		g.config.OnEvent(approval, approval.Audit[len(approval.Audit)-1])
+	}
+}
+
+// load returns an approval, marking it expired when its time has passed. g.mu must be held.
+func (g *approvalGate) load(ctx context.Context, id string) (*PendingApproval, error) {
+	approval, err := g.config.Store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.expire(ctx, approval); err != nil {
+		return nil, err
+	}
+	return approval, nil
+}
+
+// expire marks a pending or approved approval past its expiry as expired. g.mu must be held.
+func (g *approvalGate) expire(ctx context.Context, approval *PendingApproval) error {
+	if approval.Status != ApprovalStatusPending && approval.Status != ApprovalStatusApproved {
+		return nil
+	}
+	if time.Now().Before(approval.ExpiresAt) {
+		return nil
+	}
+	approval.Status = ApprovalStatusExpired
+	g.record(approval, "", ApprovalActionExpired, "")
+	if err := g.config.Store.Save(ctx, approval); err != nil {
+		return fmt.Errorf("failed to save approval %s: %w", approval.ID, err)
+	}
+	g.notify(approval)
+	return nil
+}
+
+// ApprovalClient reviews and executes the requests captured by the approval layer configured
+// with Config.Approval
+type ApprovalClient struct {
+	httpClient *HTTPClient
+}
+
+// NewApprovalClient creates a new approval client
+func NewApprovalClient(httpClient *HTTPClient) *ApprovalClient {
+	return &ApprovalClient{
+		httpClient: httpClient,
+	}
+}
+
+// gate returns the approval gate of the HTTP client
+func (c *ApprovalClient) gate() (*approvalGate, error) {
+	if c.httpClient.approvals == nil {
+		return nil, errors.New("approvals are not enabled: set Config.Approval")
+	}
+	return c.httpClient.approvals, nil
+}
+
+// Get returns an approval
+func (c *ApprovalClient) Get(ctx context.Context, id string) (*PendingApproval, error) {
+	g, err := c.gate()
+	if err != nil {
+		return nil, err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.load(ctx, id)
+}
+
+// List returns the approvals with the given statuses, or all approvals when none is given,
+// oldest first
+func (c *ApprovalClient) List(ctx context.Context, statuses ...ApprovalStatus) ([]*PendingApproval, error) {
+	g, err := c.gate()
+	if err != nil {
+		return nil, err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	approvals, err := g.config.Store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var list []*PendingApproval
+	for _, approval := range approvals {
+		if err := g.expire(ctx, approval); err != nil {
+			return nil, err
+		}
+		if len(statuses) == 0 || containsApprovalStatus(statuses, approval.Status) {
+			list = append(list, approval)
+		}
+	}
+	return list, nil
+}
+
+func containsApprovalStatus(statuses []ApprovalStatus, status ApprovalStatus) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Approve records the approval of a pending request by approver. The requester cannot approve
+// their own request and each approver counts once. The approval becomes ApprovalStatusApproved
+// with the last required approval.
+func (c *ApprovalClient) Approve(ctx context.Context, id, approver, comment string) (*PendingApproval, error) {
+	return c.decide(ctx, id, approver, comment, true)
+}
+
+// Reject rejects a pending request. Rejected requests cannot be executed.
+func (c *ApprovalClient) Reject(ctx context.Context, id, approver, reason string) (*PendingApproval, error) {
+	return c.decide(ctx, id, approver, reason, false)
+}
+
+func (c *ApprovalClient) decide(ctx context.Context, id, approver, comment string, approve bool) (*PendingApproval, error) {
+	g, err := c.gate()
+	if err != nil {
+		return nil, err
+	}
+	if approver == "" {
+		return nil, fmt.Errorf("approver is required")
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	approval, err := g.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case approval.Status == ApprovalStatusExpired:
+		return approval, fmt.Errorf("%w: %s", ErrApprovalExpired, id)
+	case approval.Status != ApprovalStatusPending:
+		return approval, fmt.Errorf("approval %s is %s", id, approval.Status)
+	case approval.RequestedBy == "":
+		return approval, fmt.Errorf("approval %s has no requester and cannot be approved", id)
+	case approver == approval.RequestedBy:
+		return approval, fmt.Errorf("approval %s: %s cannot approve their own request", id, approver)
+	case containsString(approval.Approvers, approver):
+		return approval, fmt.Errorf("approval %s: %s has already approved", id, approver)
+	}
+
+	if approve {
+		approval.Approvers = append(approval.Approvers, approver)
+		if len(approval.Approvers) >= approval.RequiredApprovals {
+			approval.Status = ApprovalStatusApproved
+		}
+		g.record(approval, approver, ApprovalActionApproved, comment)
+	} else {
+		approval.Status = ApprovalStatusRejected
+		g.record(approval, approver, ApprovalActionRejected, comment)
+	}
+	if err := g.config.Store.Save(ctx, approval); err != nil {
+		return nil, fmt.Errorf("failed to save approval %s: %w", id, err)
+	}
+	g.notify(approval)
+
+	return approval, nil
+}
+
+// Execute sends an approved request as it was captured, with its original idempotency key, and
+// decodes the response into result, e.g. a *Payout for a payout. When the outcome is unknown (a
+// *RequestError) the approval stays approved so Execute can be called again safely; when the API
+// rejects the request the approval is marked ApprovalStatusFailed.
+func (c *ApprovalClient) Execute(ctx context.Context, id string, result interface{}, callOpts ...CallOption) (*PendingApproval, error) {
+	g, err := c.gate()
+	if err != nil {
+		return nil, err
+	}
+
+	// The lock is held during the request so that an approval is never executed twice at once
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	approval, err := g.load(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	switch approval.Status {
+	case ApprovalStatusApproved:
+	case ApprovalStatusExpired:
+		return approval, fmt.Errorf("%w: %s", ErrApprovalExpired, id)
+	case ApprovalStatusPending:
+		return approval, fmt.Errorf("approval %s has %d of %d approvals", id, len(approval.Approvers), approval.RequiredApprovals)
+	default:
+		return approval, fmt.Errorf("approval %s is %s", id, approval.Status)
+	}
+
+	callOpts = append(callOpts, withApprovalID(approval.ID))
+	if approval.OnBehalfOf != "" {
+		callOpts = append(callOpts, WithOnBehalfOf(approval.OnBehalfOf))
+	}
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
+	var response json.RawMessage
+	sendErr := c.httpClient.DoRequest(ctx, &RequestOptions{
+		Method:         approval.Method,
+		Endpoint:       approval.Endpoint,
+		Body:           []byte(approval.Body),
+		RequireAuth:    true,
+		IdempotencyKey: approval.IdempotencyKey,
+	}, &response)
+
+	var apiErr *Error
+	switch {
+	case sendErr == nil:
+		approval.Status = ApprovalStatusExecuted
+		approval.Result = response
+		g.record(approval, "", ApprovalActionExecuted, "")
+	case errors.As(sendErr, &apiErr):
+		approval.Status = ApprovalStatusFailed
+		g.record(approval, "", ApprovalActionExecutionFailed, sendErr.Error())
+	default:
+		g.record(approval, "", ApprovalActionExecutionFailed, sendErr.Error())
+	}
+	// The outcome must be recorded even when ctx was cancelled during the request
+	if err := g.config.Store.Save(context.WithoutCancel(ctx), approval); err != nil {
+		return approval, fmt.Errorf("failed to save approval %s: %w", id, errors.Join(sendErr, err))
+	}
+	g.notify(approval)
+
+	if sendErr != nil {
+		return approval, fmt.Errorf("failed to execute approval %s: %w", id, sendErr)
+	}
+	if result != nil {
+		if err := json.Unmarshal(response, result); err != nil {
+			return approval, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return approval, nil
+}
@@ -0,0 +1,233 @@
+package interlace
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCardTransferEndpoint = "/open-api/v3/cards/transfer-in"
+
+func newTestApprovals(t *testing.T, server *recordingServer, config ApprovalConfig) (*HTTPClient, *ApprovalClient) {
+	client := newTestHTTPClient(t, server, func(cfg *Config) { cfg.Approval = &config })
+	return client, NewApprovalClient(client)
+}
+
+// sendMoney posts a money movement with the given idempotency key and call options
+func sendMoney(client *HTTPClient, endpoint string, body interface{}, key string, callOpts ...CallOption) error {
+	return client.DoRequest(ContextWithCallOptions(context.Background(), callOpts...),
+		&RequestOptions{Method: http.MethodPost, Endpoint: endpoint, Body: body, IdempotencyKey: key}, nil)
+}
+
+func cardTransfer(amount interface{}, currency string) map[string]interface{} {
+	return map[string]interface{}{"cardId": "card-1", "amount": amount, "currency": currency}
+}
+
+// capturedApproval returns the approval an error reports, failing the test when there is none
+func capturedApproval(t *testing.T, err error) *PendingApproval {
+	var approvalErr *ApprovalRequiredError
+	require.ErrorAs(t, err, &approvalErr)
+	assert.True(t, errors.Is(err, ErrApprovalRequired))
+	return approvalErr.Approval
+}
+
+func TestApprovalThresholds(t *testing.T) {
+	thresholds := map[string]float64{"USD": 1000, "*": 5000}
+
+	tests := []struct {
+		name       string
+		thresholds map[string]float64
+		endpoint   string
+		body       interface{}
+		captured   bool
+	}{
+		{name: "below the threshold", endpoint: testCardTransferEndpoint, body: cardTransfer(500, "usd")},
+		{name: "at the threshold", endpoint: testCardTransferEndpoint, body: cardTransfer(1000, "USD")},
+		{name: "above the threshold", endpoint: testCardTransferEndpoint, body: cardTransfer(1000.01, "USD"), captured: true},
+		{name: "default threshold", endpoint: testCardTransferEndpoint, body: cardTransfer(4000, "EUR")},
+		{name: "above the default threshold", endpoint: testCardTransferEndpoint, body: cardTransfer(6000, "EUR"), captured: true},
+		{
+			name:       "unlisted currency without a default",
+			thresholds: map[string]float64{"USD": 1000},
+			endpoint:   testCardTransferEndpoint,
+			body:       cardTransfer(1, "EUR"),
+			captured:   true,
+		},
+		{
+			name:     "decimal string amount",
+			endpoint: "/open-api/v3/payment",
+			body:     map[string]interface{}{"sourceAmount": "2000.00", "sourceCurrency": "USD"},
+			captured: true,
+		},
+		{name: "unknown amount", endpoint: "/open-api/v3/payment/quotation/q-1/accept", body: map[string]interface{}{}, captured: true},
+		{name: "missing currency", endpoint: testCardTransferEndpoint, body: map[string]interface{}{"amount": 1}, captured: true},
+		{name: "budget increase", endpoint: "/open-api/v3/budgets/b-1/increase", body: cardTransfer(1500, "USD"), captured: true},
+		{name: "not a money movement", endpoint: "/open-api/v3/cards", body: cardTransfer(1500, "USD")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := ApprovalConfig{Thresholds: thresholds}
+			if tt.thresholds != nil {
+				config.Thresholds = tt.thresholds
+			}
+			server := &recordingServer{}
+			client, approvals := newTestApprovals(t, server, config)
+
+			err := sendMoney(client, tt.endpoint, tt.body, "", WithRequester("alice"))
+			if !tt.captured {
+				require.NoError(t, err)
+				assert.Len(t, server.keys, 1)
+				return
+			}
+			approval := capturedApproval(t, err)
+			assert.Equal(t, ApprovalStatusPending, approval.Status)
+			assert.Empty(t, server.keys, "captured requests are not sent")
+
+			pending, err := approvals.List(context.Background(), ApprovalStatusPending)
+			require.NoError(t, err)
+			require.Len(t, pending, 1)
+			assert.Equal(t, approval.ID, pending[0].ID)
+		})
+	}
+}
+
+func TestApprovalCapture(t *testing.T) {
+	server := &recordingServer{}
+	var events []ApprovalAction
+	client, approvals := newTestApprovals(t, server, ApprovalConfig{
+		Thresholds: map[string]float64{"USD": 100},
+		OnEvent:    func(approval *PendingApproval, event ApprovalEvent) { events = append(events, event.Action) },
+	})
+
+	err := sendMoney(client, testCardTransferEndpoint, cardTransfer(250, "USD"), "")
+	assert.Error(t, err, "the requester is required")
+	assert.False(t, errors.Is(err, ErrApprovalRequired))
+
+	approval := capturedApproval(t, sendMoney(client, testCardTransferEndpoint, cardTransfer(250, "usd"), "key-1",
+		WithRequester("alice"), WithOnBehalfOf("sub-1")))
+	assert.Equal(t, "card_transfer", approval.Operation)
+	assert.Equal(t, 250.0, approval.Amount)
+	assert.Equal(t, "USD", approval.Currency)
+	assert.Equal(t, "alice", approval.RequestedBy)
+	assert.Equal(t, 2, approval.RequiredApprovals)
+	assert.Equal(t, "key-1", approval.IdempotencyKey)
+	assert.Equal(t, "sub-1", approval.OnBehalfOf)
+	assert.JSONEq(t, `{"cardId":"card-1","amount":250,"currency":"usd"}`, string(approval.Body))
+
+	// Sending the request again returns its approval
+	again := capturedApproval(t, sendMoney(client, testCardTransferEndpoint, cardTransfer(250, "usd"), "key-1",
+		WithRequester("alice"), WithOnBehalfOf("sub-1")))
+	assert.Equal(t, approval.ID, again.ID)
+	err = sendMoney(client, testCardTransferEndpoint, cardTransfer(300, "usd"), "key-1", WithRequester("alice"))
+	assert.ErrorContains(t, err, "already used by approval "+approval.ID)
+
+	_, err = approvals.Execute(context.Background(), approval.ID, nil)
+	assert.ErrorContains(t, err, "has 0 of 2 approvals")
+
+	_, err = approvals.Approve(context.Background(), approval.ID, "bob", "")
+	require.NoError(t, err)
+	approved, err := approvals.Approve(context.Background(), approval.ID, "carol", "ok")
+	require.NoError(t, err)
+	assert.Equal(t, ApprovalStatusApproved, approved.Status)
+	assert.Equal(t, []string{"bob", "carol"}, approved.Approvers)
+
+	// The approved request is sent as captured
+	executed, err := approvals.Execute(context.Background(), approval.ID, nil)
+	require.NoError(t, err)
+	assert.Equal(t, ApprovalStatusExecuted, executed.Status)
+	assert.Equal(t, []string{"key-1"}, server.keys)
+	assert.Equal(t, map[string]interface{}{"cardId": "card-1", "amount": 250.0, "currency": "usd"}, server.bodies[0])
+
+	_, err = approvals.Execute(context.Background(), approval.ID, nil)
+	assert.ErrorContains(t, err, "is executed")
+	assert.Len(t, server.keys, 1, "an approval is executed once")
+	assert.Equal(t, []ApprovalAction{ApprovalActionRequested, ApprovalActionApproved, ApprovalActionApproved,
+		ApprovalActionExecuted}, events)
+}
+
+func TestApprovalExecuteFailure(t *testing.T) {
+	server := &recordingServer{statuses: []int{400}}
+	client, approvals := newTestApprovals(t, server, ApprovalConfig{Thresholds: map[string]float64{"*": 0}, RequiredApprovals: 1})
+
+	approval := capturedApproval(t, sendMoney(client, testCardTransferEndpoint, cardTransfer(10, "USD"), "", WithRequester("alice")))
+	_, err := approvals.Approve(context.Background(), approval.ID, "bob", "")
+	require.NoError(t, err)
+
+	failed, err := approvals.Execute(context.Background(), approval.ID, nil)
+	assert.Error(t, err)
+	assert.Equal(t, ApprovalStatusFailed, failed.Status, "a request rejected by the API cannot be executed again")
+	assert.Equal(t, ApprovalActionExecutionFailed, failed.Audit[len(failed.Audit)-1].Action)
+}
+
+func TestApprovalSelfApproval(t *testing.T) {
+	server := &recordingServer{}
+	client, approvals := newTestApprovals(t, server, ApprovalConfig{Thresholds: map[string]float64{"*": 0}})
+	approval := capturedApproval(t, sendMoney(client, testCardTransferEndpoint, cardTransfer(10, "USD"), "", WithRequester("alice")))
+	ctx := context.Background()
+
+	_, err := approvals.Approve(ctx, approval.ID, "alice", "")
+	assert.ErrorContains(t, err, "alice cannot approve their own request")
+	_, err = approvals.Approve(ctx, approval.ID, "", "")
+	assert.ErrorContains(t, err, "approver is required")
+
+	_, err = approvals.Approve(ctx, approval.ID, "bob", "")
+	require.NoError(t, err)
+	_, err = approvals.Approve(ctx, approval.ID, "bob", "")
+	assert.ErrorContains(t, err, "bob has already approved")
+
+	current, err := approvals.Get(ctx, approval.ID)
+	require.NoError(t, err)
+	assert.Equal(t, ApprovalStatusPending, current.Status)
+	assert.Equal(t, []string{"bob"}, current.Approvers)
+
+	rejected, err := approvals.Reject(ctx, approval.ID, "carol", "unknown card")
+	require.NoError(t, err)
+	assert.Equal(t, ApprovalStatusRejected, rejected.Status)
+	_, err = approvals.Approve(ctx, approval.ID, "dave", "")
+	assert.ErrorContains(t, err, "is rejected")
+	_, err = approvals.Execute(ctx, approval.ID, nil)
+	assert.ErrorContains(t, err, "is rejected")
+	assert.Empty(t, server.keys)
+
+	_, err = approvals.Get(ctx, "apr-unknown")
+	assert.True(t, errors.Is(err, ErrApprovalNotFound))
+}
+
+func TestApprovalExpiry(t *testing.T) {
+	server := &recordingServer{}
+	store := NewMemoryApprovalStore()
+	client, approvals := newTestApprovals(t, server, ApprovalConfig{Thresholds: map[string]float64{"*": 0}, RequiredApprovals: 1,
+		Store: store})
+	ctx := context.Background()
+
+	approval := capturedApproval(t, sendMoney(client, testCardTransferEndpoint, cardTransfer(10, "USD"), "key-1", WithRequester("alice")))
+	assert.Equal(t, 24*time.Hour, approval.ExpiresAt.Sub(approval.RequestedAt), "approvals are valid for a day by default")
+	_, err := approvals.Approve(ctx, approval.ID, "bob", "")
+	require.NoError(t, err)
+
+	// An approved request that was not executed in time expires
+	approval.Status = ApprovalStatusApproved
+	approval.ExpiresAt = time.Now().Add(-time.Second)
+	require.NoError(t, store.Save(ctx, approval))
+
+	_, err = approvals.Execute(ctx, approval.ID, nil)
+	assert.True(t, errors.Is(err, ErrApprovalExpired))
+	_, err = approvals.Approve(ctx, approval.ID, "carol", "")
+	assert.True(t, errors.Is(err, ErrApprovalExpired))
+	assert.Empty(t, server.keys)
+
+	expired, err := approvals.List(ctx, ApprovalStatusExpired)
+	require.NoError(t, err)
+	require.Len(t, expired, 1)
+	assert.Equal(t, ApprovalActionExpired, expired[0].Audit[len(expired[0].Audit)-1].Action)
+
+	// The request can be captured again once its approval expired
+	again := capturedApproval(t, sendMoney(client, testCardTransferEndpoint, cardTransfer(10, "USD"), "key-1", WithRequester("alice")))
+	assert.NotEqual(t, approval.ID, again.ID)
+}
@@ -0,0 +1,309 @@
+package interlace
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidBankDetails matches, with errors.Is, the errors returned by the bank detail validators
+var ErrInvalidBankDetails = errors.New("interlace: invalid bank details")
+
+// FieldError is a validation error of one request field
+type FieldError struct {
+	// Field is the JSON name of the field, e.g. "iban"
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + " " + e.Message
+}
+
+// Is makes errors.Is(err, ErrInvalidBankDetails) match
+func (e *FieldError) Is(target error) bool {
+	return target == ErrInvalidBankDetails
+}
+
+// ValidationErrors holds every field error of a request
+type ValidationErrors []*FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Is makes errors.Is(err, ErrInvalidBankDetails) match
+func (e ValidationErrors) Is(target error) bool {
+	return target == ErrInvalidBankDetails
+}
+
+// Field returns the error of the given field, or nil
+func (e ValidationErrors) Field(field string) *FieldError {
+	for _, err := range e {
+		if err.Field == field {
+			return err
+		}
+	}
+	return nil
+}
+
+// ibanLengths is the IBAN length of every country using IBANs
+var ibanLengths = map[string]int{
+	"AD": 24, "AE": 23, "AL": 28, "AT": 20, "AZ": 28, "BA": 20, "BE": 16, "BG": 22, "BH": 22,
+	"BR": 29, "BY": 28, "CH": 21, "CR": 22, "CY": 28, "CZ": 24, "DE": 22, "DK": 18, "DO": 28,
+	"EE": 20, "EG": 29, "ES": 24, "FI": 18, "FO": 18, "FR": 27, "GB": 22, "GE": 22, "GI": 23,
+	"GL": 18, "GR": 27, "GT": 28, "HR": 21, "HU": 28, "IE": 22, "IL": 23, "IQ": 23, "IS": 26,
+	"IT": 27, "JO": 30, "KW": 30, "KZ": 20, "LB": 28, "LC": 32, "LI": 21, "LT": 20, "LU": 20,
+	"LV": 21, "MC": 27, "MD": 24, "ME": 22, "MK": 19, "MR": 27, "MT": 31, "MU": 30, "NL": 18,
+	"NO": 15, "PK": 24, "PL": 28, "PS": 29, "PT": 25, "QA": 29, "RO": 24, "RS": 22, "SA": 24,
+	"SC": 31, "SE": 24, "SI": 19, "SK": 24, "SM": 27, "ST": 25, "SV": 28, "TL": 23, "TN": 24,
+	"TR": 26, "UA": 29, "VA": 22, "VG": 24, "XK": 20,
+}
+
+// countryCurrencies is the local currency of the countries payouts are commonly sent to
+var countryCurrencies = map[string]string{
+	"AE": "AED", "AT": "EUR", "AU": "AUD", "BE": "EUR", "BG": "BGN", "BR": "BRL", "CA": "CAD",
+	"CH": "CHF", "CN": "CNY", "CY": "EUR", "CZ": "CZK", "DE": "EUR", "DK": "DKK", "EE": "EUR",
+	"ES": "EUR", "FI": "EUR", "FR": "EUR", "GB": "GBP", "GR": "EUR", "HK": "HKD", "HR": "EUR",
+	"HU": "HUF", "ID": "IDR", "IE": "EUR", "IN": "INR", "IT": "EUR", "JP": "JPY", "KR": "KRW",
+	"LT": "EUR", "LU": "EUR", "LV": "EUR", "MT": "EUR", "MX": "MXN", "MY": "MYR", "NL": "EUR",
+	"NO": "NOK", "NZ": "NZD", "PH": "PHP", "PL": "PLN", "PT": "EUR", "RO": "RON", "SE": "SEK",
+	"SG": "SGD", "SI": "EUR", "SK": "EUR", "TH": "THB", "TR": "TRY", "US": "USD", "VN": "VND",
+}
+
+// crownDependencies use UK bank identifiers: their IBANs start with GB and their BICs may carry
+// either their own country code or GB
+var crownDependencies = map[string]bool{"JE": true, "GG": true, "IM": true}
+
+// sameBankCountry reports whether the country of a bank identifier matches the bank country
+func sameBankCountry(code, country string) bool {
+	return code == country ||
+		(code == "GB" && crownDependencies[country]) ||
+		(country == "GB" && crownDependencies[code])
+}
+
+// internationalCurrencies are accepted in any country
+var internationalCurrencies = map[string]bool{
+	"USD": true, "EUR": true, "GBP": true,
+}
+
+// normalizeBankValue removes the spaces and dashes of a bank identifier and upper-cases it
+func normalizeBankValue(value string) string {
+	return strings.ToUpper(strings.NewReplacer(" ", "", "-", "").Replace(value))
+}
+
+func isDigits(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isLetters(value string) bool {
+	for _, r := range value {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return value != ""
+}
+
+func isAlphanumeric(value string) bool {
+	for _, r := range value {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return value != ""
+}
+
+// ValidateIBAN checks the country length and the mod-97 check digits of an IBAN. Spaces are
+// ignored.
+func ValidateIBAN(iban string) error {
+	iban = normalizeBankValue(iban)
+	if len(iban) < 5 || !isLetters(iban[:2]) || !isDigits(iban[2:4]) || !isAlphanumeric(iban) {
+		return &FieldError{Field: "iban", Message: "is not a valid IBAN"}
+	}
+
+	length, ok := ibanLengths[iban[:2]]
+	if !ok {
+		return &FieldError{Field: "iban", Message: fmt.Sprintf("has unknown country code %s", iban[:2])}
+	}
+	if len(iban) != length {
+		return &FieldError{Field: "iban", Message: fmt.Sprintf("must be %d characters long for %s, got %d", length, iban[:2], len(iban))}
+	}
+
+	// Move the first four characters to the end and convert letters to numbers (A=10 ... Z=35)
+	remainder := 0
+	for _, r := range iban[4:] + iban[:4] {
+		if r >= 'A' {
+			remainder = (remainder*100 + int(r-'A'+10)) % 97
+		} else {
+			remainder = (remainder*10 + int(r-'0')) % 97
+		}
+	}
+	if remainder != 1 {
+		return &FieldError{Field: "iban", Message: "has invalid check digits"}
+	}
+	return nil
+}
+
+// ValidateBIC checks the structure of a SWIFT/BIC code: 4 letters for the bank, 2 for the
+// country, 2 alphanumerics for the location and an optional 3-character branch code
+func ValidateBIC(bic string) error {
+	bic = normalizeBankValue(bic)
+	if (len(bic) != 8 && len(bic) != 11) || !isLetters(bic[:6]) || !isAlphanumeric(bic[6:]) {
+		return &FieldError{Field: "swiftCode", Message: "is not a valid BIC (expected 8 or 11 characters, e.g. DEUTDEFF or DEUTDEFF500)"}
+	}
+	return nil
+}
+
+// ValidateABARoutingNumber checks the length and checksum of a US ABA routing number
+func ValidateABARoutingNumber(routingNumber string) error {
+	routingNumber = normalizeBankValue(routingNumber)
+	if len(routingNumber) != 9 || !isDigits(routingNumber) {
+		return &FieldError{Field: "routingNumber", Message: "must be 9 digits"}
+	}
+
+	weights := [9]int{3, 7, 1, 3, 7, 1, 3, 7, 1}
+	sum := 0
+	for i, r := range routingNumber {
+		sum += int(r-'0') * weights[i]
+	}
+	if sum%10 != 0 {
+		return &FieldError{Field: "routingNumber", Message: "has an invalid checksum"}
+	}
+	return nil
+}
+
+// ValidateSortCode checks the format of a UK sort code, e.g. 12-34-56. It is carried in the
+// bankCode field of payees.
+func ValidateSortCode(sortCode string) error {
+	if sortCode = normalizeBankValue(sortCode); len(sortCode) != 6 || !isDigits(sortCode) {
+		return &FieldError{Field: "bankCode", Message: "must be a 6-digit sort code"}
+	}
+	return nil
+}
+
+// ValidateUKAccountNumber checks the format of a UK account number
+func ValidateUKAccountNumber(accountNumber string) error {
+	if accountNumber = normalizeBankValue(accountNumber); len(accountNumber) != 8 || !isDigits(accountNumber) {
+		return &FieldError{Field: "accountNumber", Message: "must be 8 digits for UK accounts"}
+	}
+	return nil
+}
+
+// ValidateCurrencyCountry checks that a currency is plausible for a bank country: either its
+// local currency or an international currency (USD, EUR, GBP). Unknown countries are accepted.
+// Banks commonly hold other currencies too, so a mismatch is a warning rather than an error (see
+// CreatePayeeRequest.Warnings).
+func ValidateCurrencyCountry(currency, country string) error {
+	currency = strings.ToUpper(currency)
+	local, ok := countryCurrencies[strings.ToUpper(country)]
+	if !ok || currency == local || internationalCurrencies[currency] {
+		return nil
+	}
+	return &FieldError{Field: "currency", Message: fmt.Sprintf("%s is unusual for bank country %s (expected %s)", currency, strings.ToUpper(country), local)}
+}
+
+// Validate checks the payee request offline: required fields, IBAN, BIC, ABA routing number, UK
+// sort code and account number. It returns ValidationErrors listing every invalid field, or nil.
+// CreatePayee calls it before sending the request.
+func (r *CreatePayeeRequest) Validate() error {
+	var errs ValidationErrors
+	add := func(err error) {
+		var fieldErr *FieldError
+		if errors.As(err, &fieldErr) {
+			errs = append(errs, fieldErr)
+		}
+	}
+	required := func(field, value string) {
+		if strings.TrimSpace(value) == "" {
+			errs = append(errs, &FieldError{Field: field, Message: "is required"})
+		}
+	}
+
+	required("accountId", r.AccountID)
+	required("beneficiaryName", r.BeneficiaryName)
+	required("bankCountry", r.BankCountry)
+	required("currency", r.Currency)
+	if r.IBAN == "" && r.AccountNumber == "" {
+		errs = append(errs, &FieldError{Field: "accountNumber", Message: "or iban is required"})
+	}
+
+	country := strings.ToUpper(r.BankCountry)
+	if country != "" && (len(country) != 2 || !isLetters(country)) {
+		errs = append(errs, &FieldError{Field: "bankCountry", Message: "must be a 2-letter ISO country code"})
+		country = ""
+	}
+
+	if r.IBAN != "" {
+		if err := ValidateIBAN(r.IBAN); err != nil {
+			add(err)
+		} else if iban := normalizeBankValue(r.IBAN); country != "" && !sameBankCountry(iban[:2], country) {
+			errs = append(errs, &FieldError{Field: "iban", Message: fmt.Sprintf("is from %s but bankCountry is %s", iban[:2], country)})
+		}
+	}
+	if r.SwiftCode != "" {
+		if err := ValidateBIC(r.SwiftCode); err != nil {
+			add(err)
+		} else if bic := normalizeBankValue(r.SwiftCode); country != "" && !sameBankCountry(bic[4:6], country) {
+			errs = append(errs, &FieldError{Field: "swiftCode", Message: fmt.Sprintf("is from %s but bankCountry is %s", bic[4:6], country)})
+		}
+	}
+
+	switch country {
+	case "US":
+		// Domestic transfers need a routing number, international wires a BIC
+		if r.RoutingNumber != "" {
+			add(ValidateABARoutingNumber(r.RoutingNumber))
+		} else if r.SwiftCode == "" {
+			errs = append(errs, &FieldError{Field: "routingNumber", Message: "or swiftCode is required for US accounts"})
+		}
+		if r.AccountNumber != "" {
+			if n := normalizeBankValue(r.AccountNumber); len(n) < 4 || len(n) > 17 || !isDigits(n) {
+				errs = append(errs, &FieldError{Field: "accountNumber", Message: "must be 4 to 17 digits for US accounts"})
+			}
+		}
+	case "GB":
+		// UK accounts are paid either by IBAN or by sort code and account number
+		if r.IBAN == "" {
+			if r.BankCode == "" {
+				errs = append(errs, &FieldError{Field: "bankCode", Message: "(sort code) is required for UK accounts without an IBAN"})
+			} else {
+				add(ValidateSortCode(r.BankCode))
+			}
+			if r.AccountNumber != "" {
+				add(ValidateUKAccountNumber(r.AccountNumber))
+			}
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// Warnings checks the payee request for unusual but possibly valid details, currently a currency
+// that is neither local nor international for the bank country. It returns nil when there is
+// nothing to report.
+func (r *CreatePayeeRequest) Warnings() ValidationErrors {
+	var warnings ValidationErrors
+	if r.Currency != "" && r.BankCountry != "" {
+		var fieldErr *FieldError
+		if errors.As(ValidateCurrencyCountry(r.Currency, r.BankCountry), &fieldErr) {
+			warnings = append(warnings, fieldErr)
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,215 @@
+package interlace
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateIBAN(t *testing.T) {
+	tests := []struct {
+		iban string
+		err  string
+	}{
+		{iban: "GB82WEST12345698765432"},
+		{iban: "GB82 WEST 1234 5698 7654 32"},
+		{iban: "de89370400440532013000"},
+		{iban: "FR1420041010050500013M02606"},
+		{iban: "GB82WEST12345698765431", err: "has invalid check digits"},
+		{iban: "GB82WEST1234569876543", err: "must be 22 characters long for GB, got 21"},
+		{iban: "ZZ82WEST12345698765432", err: "has unknown country code ZZ"},
+		{iban: "GBXXWEST12345698765432", err: "is not a valid IBAN"},
+		{iban: "GB82WEST1234569876543!", err: "is not a valid IBAN"},
+		{iban: "", err: "is not a valid IBAN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.iban, func(t *testing.T) {
+			err := ValidateIBAN(tt.iban)
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, "iban "+tt.err, err.Error())
+			assert.True(t, errors.Is(err, ErrInvalidBankDetails))
+		})
+	}
+}
+
+func TestValidateBIC(t *testing.T) {
+	tests := []struct {
+		bic   string
+		valid bool
+	}{
+		{bic: "DEUTDEFF", valid: true},
+		{bic: "DEUTDEFF500", valid: true},
+		{bic: "deut de ff", valid: true},
+		{bic: "NWBKGB2L", valid: true},
+		{bic: "DEUTDEF", valid: false},
+		{bic: "DEUTDEFF50", valid: false},
+		{bic: "DEU1DEFF", valid: false},
+		{bic: "DEUTDEF!", valid: false},
+		{bic: "", valid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.bic, func(t *testing.T) {
+			err := ValidateBIC(tt.bic)
+			if tt.valid {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, ErrInvalidBankDetails))
+		})
+	}
+}
+
+func TestValidateABARoutingNumber(t *testing.T) {
+	tests := []struct {
+		routingNumber string
+		err           string
+	}{
+		{routingNumber: "021000021"},
+		{routingNumber: "011000015"},
+		{routingNumber: "021-000-021"},
+		{routingNumber: "021000022", err: "has an invalid checksum"},
+		{routingNumber: "02100002", err: "must be 9 digits"},
+		{routingNumber: "02100002A", err: "must be 9 digits"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.routingNumber, func(t *testing.T) {
+			err := ValidateABARoutingNumber(tt.routingNumber)
+			if tt.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, "routingNumber "+tt.err, err.Error())
+		})
+	}
+}
+
+func TestCreatePayeeRequestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		req    CreatePayeeRequest
+		fields []string
+	}{
+		{
+			name: "valid IBAN payee",
+			req: CreatePayeeRequest{AccountID: "acc", BeneficiaryName: "Jane", BankCountry: "DE", Currency: "EUR",
+				IBAN: "DE89370400440532013000", SwiftCode: "DEUTDEFF"},
+		},
+		{
+			name: "US payee paid by wire without a routing number",
+			req: CreatePayeeRequest{AccountID: "acc", BeneficiaryName: "Jane", BankCountry: "US", Currency: "USD",
+				AccountNumber: "123456789", SwiftCode: "CHASUS33"},
+		},
+		{
+			name: "US payee needs a routing number or BIC",
+			req: CreatePayeeRequest{AccountID: "acc", BeneficiaryName: "Jane", BankCountry: "US", Currency: "USD",
+				AccountNumber: "123456789"},
+			fields: []string{"routingNumber"},
+		},
+		{
+			name: "Jersey payee with a UK IBAN",
+			req: CreatePayeeRequest{AccountID: "acc", BeneficiaryName: "Jane", BankCountry: "JE", Currency: "GBP",
+				IBAN: "GB82WEST12345698765432", SwiftCode: "NWBKGB2L"},
+		},
+		{
+			name: "IBAN from another country",
+			req: CreatePayeeRequest{AccountID: "acc", BeneficiaryName: "Jane", BankCountry: "FR", Currency: "EUR",
+				IBAN: "DE89370400440532013000"},
+			fields: []string{"iban"},
+		},
+		{
+			name: "UK payee without IBAN needs a sort code",
+			req: CreatePayeeRequest{AccountID: "acc", BeneficiaryName: "Jane", BankCountry: "GB", Currency: "GBP",
+				AccountNumber: "1234567"},
+			fields: []string{"bankCode", "accountNumber"},
+		},
+		{
+			name:   "missing fields",
+			req:    CreatePayeeRequest{},
+			fields: []string{"accountId", "beneficiaryName", "bankCountry", "currency", "accountNumber"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if len(tt.fields) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			var errs ValidationErrors
+			require.ErrorAs(t, err, &errs)
+			require.Len(t, errs, len(tt.fields), err.Error())
+			for _, field := range tt.fields {
+				assert.NotNil(t, errs.Field(field), "expected an error for %s", field)
+			}
+		})
+	}
+}
+
+func TestCreatePayeeRequestWarnings(t *testing.T) {
+	req := &CreatePayeeRequest{BankCountry: "JP", Currency: "EUR"}
+	assert.Empty(t, req.Warnings())
+
+	req.Currency = "THB"
+	warnings := req.Warnings()
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "currency", warnings[0].Field)
+}
+
+func TestCreatePayeeValidates(t *testing.T) {
+	server := &recordingServer{}
+	client := newTestHTTPClient(t, server, nil)
+	payouts := NewPayoutClient(client)
+
+	tests := []struct {
+		name  string
+		req   CreatePayeeRequest
+		field string
+	}{
+		{
+			name: "invalid IBAN is not sent",
+			req: CreatePayeeRequest{AccountID: "acc", BeneficiaryName: "Jane", BankCountry: "DE", Currency: "EUR",
+				IBAN: "DE89370400440532013001"},
+			field: "iban",
+		},
+		{
+			name: "invalid routing number is not sent",
+			req: CreatePayeeRequest{AccountID: "acc", BeneficiaryName: "Jane", BankCountry: "US", Currency: "USD",
+				AccountNumber: "123456789", RoutingNumber: "021000022"},
+			field: "routingNumber",
+		},
+		{
+			name: "unusual currency is only a warning",
+			req: CreatePayeeRequest{AccountID: "acc", BeneficiaryName: "Jane", BankCountry: "DE", Currency: "THB",
+				IBAN: "DE89370400440532013000"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent := len(server.keys)
+			_, err := payouts.CreatePayee(context.Background(), &tt.req)
+			if tt.field == "" {
+				require.NoError(t, err)
+				assert.Len(t, server.keys, sent+1)
+				return
+			}
+			var errs ValidationErrors
+			require.ErrorAs(t, err, &errs)
+			assert.NotNil(t, errs.Field(tt.field))
+			assert.Len(t, server.keys, sent)
+		})
+	}
+}
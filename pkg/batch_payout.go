@@ -0,0 +1,761 @@
+package interlace
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BatchPayout is a payout run imported from a CSV or ISO 20022 pain.001 file
+type BatchPayout struct {
+	// ID identifies the batch: the pain.001 message ID, empty for CSV. Payouts without an
+	// end-to-end ID get the merchant trade number "<ID>-<row>", so executing the batch twice never
+	// pays twice. A batch with such payouts needs an ID: set a stable one for the payout run, which
+	// is kept when a corrected file is imported again.
+	ID        string
+	AccountID string
+	// MessageName is the pain.001 version, e.g. pain.001.001.09, or "csv"
+	MessageName string
+	Items       []*BatchPayoutItem
+}
+
+// BatchPayoutItem is one payout of a batch
+type BatchPayoutItem struct {
+	// Row is the CSV line, or the 1-based position of the transaction in a pain.001 file
+	Row int
+	// PaymentInfoID is the pain.001 payment information block of the transaction
+	PaymentInfoID string
+	// Payee is the beneficiary to pay. It is nil when Payout.PayeeID names an existing payee;
+	// otherwise a payee with the same bank details is reused or created.
+	Payee *CreatePayeeRequest
+	// Payout is the payout to create. Its MerchantTradeNo is the end-to-end ID of the row, if any.
+	Payout CreatePayoutRequest
+}
+
+// batchPayoutColumns maps normalized CSV headers to item fields
+var batchPayoutColumns = map[string]func(item *BatchPayoutItem, value string){
+	"merchanttradeno":    func(i *BatchPayoutItem, v string) { i.Payout.MerchantTradeNo = v },
+	"endtoendid":         func(i *BatchPayoutItem, v string) { i.Payout.MerchantTradeNo = v },
+	"payeeid":            func(i *BatchPayoutItem, v string) { i.Payout.PayeeID = v },
+	"reference":          func(i *BatchPayoutItem, v string) { i.Payout.Reference = v },
+	"sourcecurrency":     func(i *BatchPayoutItem, v string) { i.Payout.SourceCurrency = strings.ToUpper(v) },
+	"currency":           func(i *BatchPayoutItem, v string) { i.Payout.TargetCurrency = strings.ToUpper(v) },
+	"targetcurrency":     func(i *BatchPayoutItem, v string) { i.Payout.TargetCurrency = strings.ToUpper(v) },
+	"beneficiaryname":    func(i *BatchPayoutItem, v string) { i.Payee.BeneficiaryName = v },
+	"name":               func(i *BatchPayoutItem, v string) { i.Payee.BeneficiaryName = v },
+	"beneficiarytype":    func(i *BatchPayoutItem, v string) { i.Payee.BeneficiaryType = v },
+	"beneficiaryaddress": func(i *BatchPayoutItem, v string) { i.Payee.BeneficiaryAddress = v },
+	"bankname":           func(i *BatchPayoutItem, v string) { i.Payee.BankName = v },
+	"bankcode":           func(i *BatchPayoutItem, v string) { i.Payee.BankCode = v },
+	"sortcode":           func(i *BatchPayoutItem, v string) { i.Payee.BankCode = v },
+	"bankcountry":        func(i *BatchPayoutItem, v string) { i.Payee.BankCountry = strings.ToUpper(v) },
+	"country":            func(i *BatchPayoutItem, v string) { i.Payee.BankCountry = strings.ToUpper(v) },
+	"accountnumber":      func(i *BatchPayoutItem, v string) { i.Payee.AccountNumber = v },
+	"iban":               func(i *BatchPayoutItem, v string) { i.Payee.IBAN = v },
+	"swiftcode":          func(i *BatchPayoutItem, v string) { i.Payee.SwiftCode = v },
+	"bic":                func(i *BatchPayoutItem, v string) { i.Payee.SwiftCode = v },
+	"routingnumber":      func(i *BatchPayoutItem, v string) { i.Payee.RoutingNumber = v },
+	"aba":                func(i *BatchPayoutItem, v string) { i.Payee.RoutingNumber = v },
+}
+
+// ParseBatchPayoutCSV parses a payout run from CSV. The first line is a header naming the
+// columns, case-insensitively and ignoring spaces, dashes and underscores: amount, currency
+// (target currency), source_currency (defaults to currency), merchant_trade_no or end_to_end_id,
+// reference, and either payee_id or the payee's bank details (beneficiary_name, bank_country,
+// iban, account_number, bank_code or sort_code, swift_code or bic, routing_number, bank_name,
+// beneficiary_type, beneficiary_address). Other columns are ignored. Rows without a
+// merchant_trade_no need the batch ID to be set, see BatchPayout.ID.
+func ParseBatchPayoutCSV(r io.Reader, accountID string) (*BatchPayout, error) {
+	batch := &BatchPayout{
+		AccountID:   accountID,
+		MessageName: "csv",
+	}
+
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read batch payout CSV header: %w", err)
+	}
+	amountColumn := -1
+	for i, name := range header {
+		name = strings.ToLower(strings.NewReplacer(" ", "", "_", "", "-", "", "\ufeff", "").Replace(name))
+		header[i] = name
+		if name == "amount" || name == "sourceamount" {
+			amountColumn = i
+		}
+	}
+	if amountColumn < 0 {
+		return nil, errors.New("batch payout CSV has no amount column")
+	}
+
+	var errs []error
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			errs = append(errs, err)
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				continue
+			}
+			break
+		}
+		line, _ := reader.FieldPos(0)
+
+		item := &BatchPayoutItem{Row: line, Payee: &CreatePayeeRequest{}}
+		blank := true
+		for i, value := range record {
+			value = strings.TrimSpace(value)
+			if value == "" || i >= len(header) {
+				continue
+			}
+			blank = false
+			if set, ok := batchPayoutColumns[header[i]]; ok {
+				set(item, value)
+			}
+		}
+		if blank {
+			continue
+		}
+
+		if amountColumn < len(record) {
+			value := strings.TrimSpace(record[amountColumn])
+			if item.Payout.SourceAmount, err = strconv.ParseFloat(value, 64); err != nil {
+				errs = append(errs, fmt.Errorf("line %d: invalid amount %q", line, value))
+				continue
+			}
+		}
+		batch.addItem(item)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return batch, nil
+}
+
+// pain001Document is the subset of an ISO 20022 customer credit transfer initiation used for
+// payouts. Element names are matched without namespace, so pain.001.001.03 to .11 are read alike.
+type pain001Document struct {
+	XMLName    xml.Name
+	MessageID  string `xml:"CstmrCdtTrfInitn>GrpHdr>MsgId"`
+	PaymentInf []struct {
+		ID           string               `xml:"PmtInfId"`
+		Transactions []pain001Transaction `xml:"CdtTrfTxInf"`
+	} `xml:"CstmrCdtTrfInitn>PmtInf"`
+}
+
+type pain001Amount struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"Ccy,attr"`
+}
+
+type pain001Transaction struct {
+	EndToEndID         string        `xml:"PmtId>EndToEndId"`
+	InstructedAmount   pain001Amount `xml:"Amt>InstdAmt"`
+	EquivalentAmount   pain001Amount `xml:"Amt>EqvtAmt>Amt"`
+	CurrencyOfTransfer string        `xml:"Amt>EqvtAmt>CcyOfTrf"`
+	AgentBICFI         string        `xml:"CdtrAgt>FinInstnId>BICFI"`
+	AgentBIC           string        `xml:"CdtrAgt>FinInstnId>BIC"`
+	AgentMemberID      string        `xml:"CdtrAgt>FinInstnId>ClrSysMmbId>MmbId"`
+	AgentName          string        `xml:"CdtrAgt>FinInstnId>Nm"`
+	CreditorName       string        `xml:"Cdtr>Nm"`
+	CreditorCountry    string        `xml:"Cdtr>PstlAdr>Ctry"`
+	AddressLines       []string      `xml:"Cdtr>PstlAdr>AdrLine"`
+	IBAN               string        `xml:"CdtrAcct>Id>IBAN"`
+	OtherAccountID     string        `xml:"CdtrAcct>Id>Othr>Id"`
+	AccountCurrency    string        `xml:"CdtrAcct>Ccy"`
+	Remittance         []string      `xml:"RmtInf>Ustrd"`
+}
+
+// ParsePain001 parses a payout run from an ISO 20022 pain.001 credit transfer initiation. Each
+// CdtTrfTxInf becomes a payout: the instructed amount is debited in its currency and paid out in
+// the creditor account currency (or the same currency). With an equivalent amount, that amount is
+// debited and paid out in the currency of transfer.
+func ParsePain001(r io.Reader, accountID string) (*BatchPayout, error) {
+	var doc pain001Document
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to parse pain.001: %w", err)
+	}
+	if doc.XMLName.Local != "Document" || doc.MessageID == "" {
+		return nil, errors.New("not a pain.001 document: CstmrCdtTrfInitn/GrpHdr/MsgId is missing")
+	}
+
+	batch := &BatchPayout{
+		ID:          doc.MessageID,
+		AccountID:   accountID,
+		MessageName: "pain.001",
+	}
+	if i := strings.LastIndex(doc.XMLName.Space, ":"); i >= 0 && strings.HasPrefix(doc.XMLName.Space[i+1:], "pain.001") {
+		batch.MessageName = doc.XMLName.Space[i+1:]
+	}
+
+	var errs []error
+	row := 0
+	for _, info := range doc.PaymentInf {
+		for _, tx := range info.Transactions {
+			row++
+			item := &BatchPayoutItem{
+				Row:           row,
+				PaymentInfoID: info.ID,
+				Payee: &CreatePayeeRequest{
+					BeneficiaryName:    strings.TrimSpace(tx.CreditorName),
+					BankName:           strings.TrimSpace(tx.AgentName),
+					IBAN:               strings.TrimSpace(tx.IBAN),
+					AccountNumber:      strings.TrimSpace(tx.OtherAccountID),
+					SwiftCode:          strings.TrimSpace(tx.AgentBICFI + tx.AgentBIC),
+					BeneficiaryAddress: strings.Join(tx.AddressLines, ", "),
+				},
+				Payout: CreatePayoutRequest{
+					MerchantTradeNo: strings.TrimSpace(tx.EndToEndID),
+					Reference:       strings.Join(tx.Remittance, " "),
+				},
+			}
+			// NOTPROVIDED is the ISO 20022 placeholder for a missing end-to-end ID
+			if item.Payout.MerchantTradeNo == "NOTPROVIDED" {
+				item.Payout.MerchantTradeNo = ""
+			}
+
+			amount := tx.InstructedAmount
+			item.Payout.TargetCurrency = tx.AccountCurrency
+			if tx.EquivalentAmount.Value != "" {
+				amount = tx.EquivalentAmount
+				item.Payout.TargetCurrency = tx.CurrencyOfTransfer
+			}
+			value, err := strconv.ParseFloat(strings.TrimSpace(amount.Value), 64)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("transaction %d: invalid amount %q", row, amount.Value))
+				continue
+			}
+			item.Payout.SourceAmount = value
+			item.Payout.SourceCurrency = strings.ToUpper(amount.Currency)
+			item.Payout.TargetCurrency = strings.ToUpper(item.Payout.TargetCurrency)
+
+			item.Payee.BankCountry = inferBankCountry(item.Payee)
+			if item.Payee.BankCountry == "" {
+				item.Payee.BankCountry = strings.ToUpper(tx.CreditorCountry)
+			}
+			if memberID := strings.TrimSpace(tx.AgentMemberID); memberID != "" {
+				if item.Payee.BankCountry == "US" {
+					item.Payee.RoutingNumber = memberID
+				} else {
+					item.Payee.BankCode = memberID
+				}
+			}
+			batch.addItem(item)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	return batch, nil
+}
+
+// LoadBatchPayoutFile reads a payout run from a file: pain.001 for .xml files, CSV otherwise
+func LoadBatchPayoutFile(path, accountID string) (*BatchPayout, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open batch payout file: %w", err)
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".xml") {
+		return ParsePain001(f, accountID)
+	}
+	return ParseBatchPayoutCSV(f, accountID)
+}
+
+// addItem fills the defaults of an item and adds it to the batch
+func (b *BatchPayout) addItem(item *BatchPayoutItem) {
+	item.Payout.AccountID = b.AccountID
+	if item.Payout.SourceCurrency == "" {
+		item.Payout.SourceCurrency = item.Payout.TargetCurrency
+	}
+	if item.Payout.TargetCurrency == "" {
+		item.Payout.TargetCurrency = item.Payout.SourceCurrency
+	}
+
+	if item.Payout.PayeeID != "" {
+		item.Payee = nil
+	} else {
+		item.Payee.AccountID = b.AccountID
+		item.Payee.Currency = item.Payout.TargetCurrency
+		if item.Payee.BankCountry == "" {
+			item.Payee.BankCountry = inferBankCountry(item.Payee)
+		}
+	}
+	b.Items = append(b.Items, item)
+}
+
+// inferBankCountry returns the country of the IBAN or BIC of a payee, or ""
+func inferBankCountry(payee *CreatePayeeRequest) string {
+	if iban := normalizeBankValue(payee.IBAN); len(iban) >= 2 && isLetters(iban[:2]) {
+		return iban[:2]
+	}
+	if bic := normalizeBankValue(payee.SwiftCode); len(bic) >= 6 && isLetters(bic[4:6]) {
+		return bic[4:6]
+	}
+	return ""
+}
+
+// merchantTradeNo returns the merchant trade number of an item, derived from the batch ID and
+// row when the item has none
+func (b *BatchPayout) merchantTradeNo(item *BatchPayoutItem) string {
+	if item.Payout.MerchantTradeNo != "" || b.ID == "" {
+		return item.Payout.MerchantTradeNo
+	}
+	return fmt.Sprintf("%s-%d", b.ID, item.Row)
+}
+
+// Validate checks every item of the batch offline, including the bank details of new payees, and
+// returns the errors of all invalid rows joined together
+func (b *BatchPayout) Validate() error {
+	if b.AccountID == "" {
+		return fmt.Errorf("accountId is required")
+	}
+	if len(b.Items) == 0 {
+		return errors.New("batch payout has no items")
+	}
+
+	var errs []error
+	seen := make(map[string]int)
+	for _, item := range b.Items {
+		payout := &item.Payout
+		var rowErrs []error
+		if payout.SourceAmount <= 0 {
+			rowErrs = append(rowErrs, errors.New("amount must be greater than 0"))
+		}
+		if payout.SourceCurrency == "" || payout.TargetCurrency == "" {
+			rowErrs = append(rowErrs, errors.New("currency is required"))
+		}
+		merchantTradeNo := b.merchantTradeNo(item)
+		if merchantTradeNo == "" {
+			rowErrs = append(rowErrs, errors.New("merchantTradeNo is required when the batch has no ID"))
+		} else if row, ok := seen[merchantTradeNo]; ok {
+			rowErrs = append(rowErrs, fmt.Errorf("merchantTradeNo %s is also used by row %d", merchantTradeNo, row))
+		} else {
+			seen[merchantTradeNo] = item.Row
+		}
+		if payout.PayeeID == "" {
+			if item.Payee == nil {
+				rowErrs = append(rowErrs, errors.New("payeeId or payee bank details are required"))
+			} else if err := item.Payee.Validate(); err != nil {
+				rowErrs = append(rowErrs, err)
+			}
+		}
+		for _, err := range rowErrs {
+			errs = append(errs, fmt.Errorf("row %d: %w", item.Row, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// BatchPayoutOptions controls the execution of a batch payout
+type BatchPayoutOptions struct {
+	// DryRun validates the batch and matches existing payees without creating anything
+	DryRun bool
+	// Concurrency is the number of payouts created at once. Defaults to 4.
+	Concurrency int
+	// RequestsPerSecond caps the rate of create requests. Defaults to 10; negative disables the cap.
+	RequestsPerSecond float64
+	// OnProgress is called after every payout with the number of items processed so far
+	OnProgress func(processed, total int)
+}
+
+// BatchPayoutResult is the outcome of one item of a batch
+type BatchPayoutResult struct {
+	Row             int     `json:"row"`
+	PaymentInfoID   string  `json:"paymentInfoId,omitempty"`
+	MerchantTradeNo string  `json:"merchantTradeNo"`
+	BeneficiaryName string  `json:"beneficiaryName,omitempty"`
+	Amount          float64 `json:"amount"`
+	Currency        string  `json:"currency"`
+	PayeeID         string  `json:"payeeId,omitempty"`
+	// PayeeCreated reports whether the payee was created for the batch (or, in a dry run, would be)
+	PayeeCreated bool   `json:"payeeCreated"`
+	PayoutID     string `json:"payoutId,omitempty"`
+	// Status is the status of the created payout
+	Status string `json:"status,omitempty"`
+	Error  string `json:"error,omitempty"`
+	// Warning reports unusual payee details, such as a currency that is not local to the bank
+	Warning string `json:"warning,omitempty"`
+}
+
+// Failed reports whether the item could not be paid out
+func (r *BatchPayoutResult) Failed() bool {
+	return r.Error != "" || statusState(r.Status) == OperationFailed
+}
+
+// BatchPayoutReport holds the outcome of every item of a batch
+type BatchPayoutReport struct {
+	BatchID     string
+	MessageName string
+	DryRun      bool
+	Results     []BatchPayoutResult
+	Succeeded   int
+	Failed      int
+}
+
+// ExecuteBatch validates the whole batch, then resolves the payees (reusing existing payees of
+// the account with the same bank details) and creates the payouts. Nothing is created when
+// validation fails. Payouts use their end-to-end ID as merchant trade number, and payees an
+// idempotency key derived from it, so a batch that was interrupted can be executed again
+// safely. Failed items are reported per row; an error is returned only for an invalid batch, a
+// failure to list payees or a done ctx. WithIdempotencyKey and WithResponseMeta are ignored,
+// since they cannot be shared by the requests made for each item.
+func (c *PayoutClient) ExecuteBatch(ctx context.Context, batch *BatchPayout, opts *BatchPayoutOptions, callOpts ...CallOption) (*BatchPayoutReport, error) {
+	ctx = fanOutContext(ctx, callOpts)
+	if batch == nil {
+		return nil, errors.New("batch payout is required")
+	}
+	if err := batch.Validate(); err != nil {
+		return nil, err
+	}
+	if opts == nil {
+		opts = &BatchPayoutOptions{}
+	}
+
+	report := &BatchPayoutReport{
+		BatchID:     batch.ID,
+		MessageName: batch.MessageName,
+		DryRun:      opts.DryRun,
+		Results:     make([]BatchPayoutResult, len(batch.Items)),
+	}
+	for i, item := range batch.Items {
+		result := &report.Results[i]
+		result.Row = item.Row
+		result.PaymentInfoID = item.PaymentInfoID
+		result.MerchantTradeNo = batch.merchantTradeNo(item)
+		result.Amount = item.Payout.SourceAmount
+		result.Currency = item.Payout.SourceCurrency
+		result.PayeeID = item.Payout.PayeeID
+		if item.Payee != nil {
+			result.BeneficiaryName = item.Payee.BeneficiaryName
+			if warnings := item.Payee.Warnings(); warnings != nil {
+				result.Warning = warnings.Error()
+			}
+		}
+	}
+
+	rps := opts.RequestsPerSecond
+	if rps == 0 {
+		rps = 10
+	}
+	limiter := newRateLimiter(rps)
+
+	if err := c.resolveBatchPayees(ctx, batch, report, limiter); err != nil {
+		return nil, err
+	}
+	if opts.DryRun {
+		return report, nil
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		processed int
+		done      = make([]bool, len(batch.Items))
+		sem       = make(chan struct{}, concurrency)
+	)
+	for i, item := range batch.Items {
+		if report.Results[i].Error != "" {
+			done[i] = true
+			continue
+		}
+		if limiter.wait(ctx) != nil {
+			break
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int, item *BatchPayoutItem) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			result := &report.Results[i]
+			req := item.Payout
+			req.MerchantTradeNo = result.MerchantTradeNo
+			req.PayeeID = result.PayeeID
+			if payout, err := c.CreatePayout(ctx, &req); err != nil {
+				result.Error = err.Error()
+			} else {
+				result.PayoutID = payout.ID
+				result.Status = payout.Status
+			}
+			done[i] = true
+
+			if opts.OnProgress != nil {
+				mu.Lock()
+				processed++
+				opts.OnProgress(processed, len(batch.Items))
+				mu.Unlock()
+			}
+		}(i, item)
+	}
+	wg.Wait()
+
+	for i := range report.Results {
+		result := &report.Results[i]
+		if !done[i] {
+			result.Error = "not processed"
+			if ctx.Err() != nil {
+				result.Error = ctx.Err().Error()
+			}
+		}
+		if result.Failed() {
+			report.Failed++
+		} else {
+			report.Succeeded++
+		}
+	}
+
+	return report, ctx.Err()
+}
+
+// resolveBatchPayees sets the payee of every result, matching the account's existing payees by
+// bank details and creating the missing ones once per distinct bank account
+func (c *PayoutClient) resolveBatchPayees(ctx context.Context, batch *BatchPayout, report *BatchPayoutReport, limiter *rateLimiter) error {
+	existing := make(map[string]string)
+	list := &ListPayeesOptions{AccountID: batch.AccountID, Limit: selectPageSize}
+	err := walkPages(func(page int) (int, int, error) {
+		list.Page = page
+		payees, err := c.ListPayees(ctx, list)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to list payees (page %d): %w", page, err)
+		}
+		for _, payee := range payees.List {
+			if statusState(payee.Status) == OperationFailed {
+				continue
+			}
+			key := payeeKey(payee.BankCountry, payee.Currency, payee.IBAN, payee.BankCode, payee.RoutingNumber, payee.AccountNumber)
+			if _, ok := existing[key]; !ok {
+				existing[key] = payee.ID
+			}
+		}
+		return len(payees.List), payees.Total, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	created := make(map[string]error)
+	for i, item := range batch.Items {
+		if item.Payee == nil {
+			continue
+		}
+		result := &report.Results[i]
+		p := item.Payee
+		key := payeeKey(p.BankCountry, p.Currency, p.IBAN, p.BankCode, p.RoutingNumber, p.AccountNumber)
+		if id, ok := existing[key]; ok {
+			result.PayeeID = id
+			continue
+		}
+		if err, ok := created[key]; ok {
+			// Another row of the batch pays the same account
+			result.PayeeCreated = true
+			if err != nil {
+				result.Error = err.Error()
+			}
+			continue
+		}
+
+		result.PayeeCreated = true
+		if report.DryRun {
+			created[key] = nil
+			continue
+		}
+		if err := limiter.wait(ctx); err != nil {
+			return err
+		}
+		payee, err := c.CreatePayee(ctx, p, WithIdempotencyKey(result.MerchantTradeNo+"-payee"))
+		created[key] = err
+		if err != nil {
+			result.Error = err.Error()
+			continue
+		}
+		existing[key] = payee.ID
+		result.PayeeID = payee.ID
+	}
+
+	// Rows sharing a payee created above pick up its ID
+	for i, item := range batch.Items {
+		result := &report.Results[i]
+		if item.Payee != nil && result.PayeeID == "" && result.Error == "" {
+			p := item.Payee
+			result.PayeeID = existing[payeeKey(p.BankCountry, p.Currency, p.IBAN, p.BankCode, p.RoutingNumber, p.AccountNumber)]
+		}
+	}
+	return nil
+}
+
+// payeeKey identifies a bank account in a currency, by IBAN when there is one
+func payeeKey(country, currency, iban, bankCode, routingNumber, accountNumber string) string {
+	currency = strings.ToUpper(currency)
+	if iban != "" {
+		return "iban:" + normalizeBankValue(iban) + ":" + currency
+	}
+	return strings.Join([]string{"account", strings.ToUpper(country), normalizeBankValue(bankCode), normalizeBankValue(routingNumber), normalizeBankValue(accountNumber), currency}, ":")
+}
+
+// WriteCSV writes the report as CSV, one line per item
+func (r *BatchPayoutReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"row", "merchant_trade_no", "beneficiary_name", "amount", "currency", "payee_id", "payee_created", "payout_id", "status", "error", "warning"})
+	for _, result := range r.Results {
+		writer.Write([]string{
+			strconv.Itoa(result.Row),
+			result.MerchantTradeNo,
+			result.BeneficiaryName,
+			formatAmount(result.Amount),
+			result.Currency,
+			result.PayeeID,
+			strconv.FormatBool(result.PayeeCreated),
+			result.PayoutID,
+			result.Status,
+			result.Error,
+			result.Warning,
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ISO 20022 transaction and group statuses used in pain.002 reports
+const (
+	pain002Accepted   = "ACSP" // accepted, settlement in process
+	pain002Completed  = "ACSC" // accepted, settlement completed
+	pain002Pending    = "PDNG"
+	pain002Rejected   = "RJCT"
+	pain002Partial    = "PART"
+	pain002Namespace  = "urn:iso:std:iso:20022:tech:xsd:pain.002.001.10"
+	pain002TimeFormat = "2006-01-02T15:04:05"
+)
+
+// pain002Status returns the ISO 20022 transaction status of a result
+func (r *BatchPayoutResult) pain002Status(dryRun bool) string {
+	switch {
+	case r.Failed():
+		return pain002Rejected
+	case dryRun:
+		return pain002Pending
+	case statusState(r.Status) == OperationSucceeded:
+		return pain002Completed
+	}
+	return pain002Accepted
+}
+
+type pain002Document struct {
+	XMLName  xml.Name `xml:"Document"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	MsgID    string   `xml:"CstmrPmtStsRpt>GrpHdr>MsgId"`
+	Created  string   `xml:"CstmrPmtStsRpt>GrpHdr>CreDtTm"`
+	Original struct {
+		MsgID       string `xml:"OrgnlMsgId"`
+		MsgName     string `xml:"OrgnlMsgNmId"`
+		NbOfTxs     int    `xml:"OrgnlNbOfTxs"`
+		GroupStatus string `xml:"GrpSts"`
+	} `xml:"CstmrPmtStsRpt>OrgnlGrpInfAndSts"`
+	PaymentInfos []*pain002PaymentInfo `xml:"CstmrPmtStsRpt>OrgnlPmtInfAndSts"`
+}
+
+type pain002PaymentInfo struct {
+	ID           string               `xml:"OrgnlPmtInfId"`
+	Transactions []pain002Transaction `xml:"TxInfAndSts"`
+}
+
+type pain002Transaction struct {
+	EndToEndID string         `xml:"OrgnlEndToEndId"`
+	Status     string         `xml:"TxSts"`
+	Reason     *pain002Reason `xml:"StsRsnInf"`
+}
+
+type pain002Reason struct {
+	AdditionalInfo string `xml:"AddtlInf"`
+}
+
+// WritePain002 writes the report as an ISO 20022 pain.002 customer payment status report,
+// answering the original pain.001 (or CSV) batch. Transactions are ACSC when paid, ACSP while in
+// process, PDNG in dry runs and RJCT with the error as additional information when failed.
+func (r *BatchPayoutReport) WritePain002(w io.Writer) error {
+	doc := pain002Document{
+		Xmlns:   pain002Namespace,
+		MsgID:   r.BatchID + "-status",
+		Created: time.Now().UTC().Format(pain002TimeFormat),
+	}
+	doc.Original.MsgID = r.BatchID
+	doc.Original.MsgName = r.MessageName
+	doc.Original.NbOfTxs = len(r.Results)
+
+	infos := make(map[string]*pain002PaymentInfo)
+	rejected := 0
+	for i := range r.Results {
+		result := &r.Results[i]
+		tx := pain002Transaction{EndToEndID: result.MerchantTradeNo, Status: result.pain002Status(r.DryRun)}
+		if tx.Status == pain002Rejected {
+			rejected++
+			reason := result.Error
+			if reason == "" {
+				reason = "payout " + strings.ToLower(result.Status)
+			}
+			// AddtlInf is limited to 105 characters
+			if runes := []rune(reason); len(runes) > 105 {
+				reason = string(runes[:105])
+			}
+			tx.Reason = &pain002Reason{AdditionalInfo: reason}
+		}
+
+		id := result.PaymentInfoID
+		if id == "" {
+			id = r.BatchID
+		}
+		info, ok := infos[id]
+		if !ok {
+			info = &pain002PaymentInfo{ID: id}
+			infos[id] = info
+			doc.PaymentInfos = append(doc.PaymentInfos, info)
+		}
+		info.Transactions = append(info.Transactions, tx)
+	}
+
+	switch {
+	case rejected == len(r.Results):
+		doc.Original.GroupStatus = pain002Rejected
+	case rejected > 0:
+		doc.Original.GroupStatus = pain002Partial
+	case r.DryRun:
+		doc.Original.GroupStatus = pain002Pending
+	default:
+		doc.Original.GroupStatus = pain002Accepted
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write pain.002: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
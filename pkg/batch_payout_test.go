@@ -0,0 +1,181 @@
+package interlace
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBatchPayoutCSV(t *testing.T) {
+	tests := []struct {
+		name  string
+		csv   string
+		items []BatchPayoutItem
+		err   string
+	}{
+		{
+			name: "payee bank details",
+			csv: "Amount,Currency,Merchant Trade No,Beneficiary Name,IBAN,BIC\n" +
+				"100.50,eur,pay-1,Jane Doe,DE89370400440532013000,DEUTDEFF\n",
+			items: []BatchPayoutItem{{
+				Row: 2,
+				Payee: &CreatePayeeRequest{AccountID: "acc", BeneficiaryName: "Jane Doe", BankCountry: "DE", Currency: "EUR",
+					IBAN: "DE89370400440532013000", SwiftCode: "DEUTDEFF"},
+				Payout: CreatePayoutRequest{AccountID: "acc", SourceAmount: 100.50, SourceCurrency: "EUR", TargetCurrency: "EUR",
+					MerchantTradeNo: "pay-1"},
+			}},
+		},
+		{
+			name: "existing payee with source currency and blank lines",
+			csv: "\ufeffamount,source_currency,target_currency,payee_id,end-to-end-id,reference\n" +
+				",,,,,\n" +
+				"20,USD,GBP,payee-1,e2e-1,Invoice 7\n",
+			items: []BatchPayoutItem{{
+				Row: 3,
+				Payout: CreatePayoutRequest{AccountID: "acc", PayeeID: "payee-1", SourceAmount: 20, SourceCurrency: "USD",
+					TargetCurrency: "GBP", MerchantTradeNo: "e2e-1", Reference: "Invoice 7"},
+			}},
+		},
+		{
+			name: "missing amount column",
+			csv:  "currency,payee_id\nEUR,payee-1\n",
+			err:  "no amount column",
+		},
+		{
+			name: "invalid amounts are reported by line",
+			csv:  "amount,currency,payee_id\nten,EUR,payee-1\n5,EUR,payee-2\nx,EUR,payee-3\n",
+			err:  "line 2: invalid amount \"ten\"\nline 4: invalid amount \"x\"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batch, err := ParseBatchPayoutCSV(strings.NewReader(tt.csv), "acc")
+			if tt.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Empty(t, batch.ID)
+			assert.Equal(t, "csv", batch.MessageName)
+			require.Len(t, batch.Items, len(tt.items))
+			for i := range tt.items {
+				assert.Equal(t, tt.items[i], *batch.Items[i])
+			}
+		})
+	}
+}
+
+const testPain001 = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.09">
+  <CstmrCdtTrfInitn>
+    <GrpHdr><MsgId>MSG-1</MsgId></GrpHdr>
+    <PmtInf>
+      <PmtInfId>PMT-1</PmtInfId>
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>E2E-1</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="EUR">250.00</InstdAmt></Amt>
+        <CdtrAgt><FinInstnId><BICFI>DEUTDEFF</BICFI></FinInstnId></CdtrAgt>
+        <Cdtr><Nm>Jane Doe</Nm></Cdtr>
+        <CdtrAcct><Id><IBAN>DE89370400440532013000</IBAN></Id></CdtrAcct>
+        <RmtInf><Ustrd>Invoice</Ustrd><Ustrd>42</Ustrd></RmtInf>
+      </CdtTrfTxInf>
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>NOTPROVIDED</EndToEndId></PmtId>
+        <Amt><EqvtAmt><Amt Ccy="EUR">90.00</Amt><CcyOfTrf>USD</CcyOfTrf></EqvtAmt></Amt>
+        <CdtrAgt><FinInstnId><ClrSysMmbId><MmbId>021000021</MmbId></ClrSysMmbId></FinInstnId></CdtrAgt>
+        <Cdtr><Nm>John Roe</Nm><PstlAdr><Ctry>US</Ctry><AdrLine>1 Main St</AdrLine><AdrLine>New York</AdrLine></PstlAdr></Cdtr>
+        <CdtrAcct><Id><Othr><Id>123456789</Id></Othr></Id></CdtrAcct>
+      </CdtTrfTxInf>
+    </PmtInf>
+  </CstmrCdtTrfInitn>
+</Document>`
+
+func TestParsePain001(t *testing.T) {
+	batch, err := ParsePain001(strings.NewReader(testPain001), "acc")
+	require.NoError(t, err)
+
+	assert.Equal(t, "MSG-1", batch.ID)
+	assert.Equal(t, "pain.001.001.09", batch.MessageName)
+	require.Len(t, batch.Items, 2)
+
+	tests := []BatchPayoutItem{
+		{
+			Row:           1,
+			PaymentInfoID: "PMT-1",
+			Payee: &CreatePayeeRequest{AccountID: "acc", BeneficiaryName: "Jane Doe", BankCountry: "DE", Currency: "EUR",
+				IBAN: "DE89370400440532013000", SwiftCode: "DEUTDEFF"},
+			Payout: CreatePayoutRequest{AccountID: "acc", SourceAmount: 250, SourceCurrency: "EUR", TargetCurrency: "EUR",
+				MerchantTradeNo: "E2E-1", Reference: "Invoice 42"},
+		},
+		{
+			Row:           2,
+			PaymentInfoID: "PMT-1",
+			Payee: &CreatePayeeRequest{AccountID: "acc", BeneficiaryName: "John Roe", BankCountry: "US", Currency: "USD",
+				AccountNumber: "123456789", RoutingNumber: "021000021", BeneficiaryAddress: "1 Main St, New York"},
+			Payout: CreatePayoutRequest{AccountID: "acc", SourceAmount: 90, SourceCurrency: "EUR", TargetCurrency: "USD"},
+		},
+	}
+	for i, want := range tests {
+		assert.Equal(t, want, *batch.Items[i], "transaction %d", i+1)
+	}
+
+	assert.Equal(t, "E2E-1", batch.merchantTradeNo(batch.Items[0]))
+	assert.Equal(t, "MSG-1-2", batch.merchantTradeNo(batch.Items[1]))
+	assert.NoError(t, batch.Validate())
+}
+
+func TestParsePain001Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		xml  string
+		err  string
+	}{
+		{name: "not XML", xml: "amount,currency", err: "failed to parse pain.001"},
+		{name: "no message ID", xml: "<Document><CstmrCdtTrfInitn/></Document>", err: "MsgId is missing"},
+		{
+			name: "invalid amount",
+			xml: `<Document><CstmrCdtTrfInitn><GrpHdr><MsgId>M</MsgId></GrpHdr><PmtInf><CdtTrfTxInf>
+				<Amt><InstdAmt Ccy="EUR">1,00</InstdAmt></Amt></CdtTrfTxInf></PmtInf></CstmrCdtTrfInitn></Document>`,
+			err: "transaction 1: invalid amount \"1,00\"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePain001(strings.NewReader(tt.xml), "acc")
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestBatchPayoutValidateMerchantTradeNo(t *testing.T) {
+	batch, err := ParseBatchPayoutCSV(strings.NewReader("amount,currency,payee_id\n10,EUR,payee-1\n"), "acc")
+	require.NoError(t, err)
+
+	err = batch.Validate()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "row 2: merchantTradeNo is required when the batch has no ID")
+
+	batch.ID = "run-1"
+	assert.NoError(t, batch.Validate())
+	assert.Equal(t, "run-1-2", batch.merchantTradeNo(batch.Items[0]))
+}
+
+func TestBatchPayoutReportWriteCSV(t *testing.T) {
+	report := &BatchPayoutReport{Results: []BatchPayoutResult{
+		{Row: 2, MerchantTradeNo: "pay-1", BeneficiaryName: "Jane Doe", Amount: 100.5, Currency: "THB", PayeeID: "payee-1",
+			PayeeCreated: true, PayoutID: "po-1", Status: "PENDING", Warning: "currency THB is unusual for bank country DE (expected EUR)"},
+		{Row: 3, MerchantTradeNo: "pay-2", Amount: 20, Currency: "EUR", Error: "insufficient balance"},
+	}}
+
+	var buf strings.Builder
+	require.NoError(t, report.WriteCSV(&buf))
+	assert.Equal(t, "row,merchant_trade_no,beneficiary_name,amount,currency,payee_id,payee_created,payout_id,status,error,warning\n"+
+		"2,pay-1,Jane Doe,100.5,THB,payee-1,true,po-1,PENDING,,currency THB is unusual for bank country DE (expected EUR)\n"+
+		"3,pay-2,,20,EUR,,false,,,insufficient balance,\n", buf.String())
+}
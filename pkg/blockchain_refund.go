@@ -90,7 +90,9 @@ type BlockchainRefundListResponse struct {
 
 // CreateBlockchainRefund creates a blockchain refund transaction
 // POST /open-api/v3/crypto/refund
-func (c *BlockchainRefundClient) CreateBlockchainRefund(ctx context.Context, req *CreateBlockchainRefundRequest) (*BlockchainRefund, error) {
+func (c *BlockchainRefundClient) CreateBlockchainRefund(ctx context.Context, req *CreateBlockchainRefundRequest, callOpts ...CallOption) (*BlockchainRefund, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if req == nil {
 		return nil, fmt.Errorf("refund request is required")
 	}
@@ -131,7 +133,9 @@ func (c *BlockchainRefundClient) CreateBlockchainRefund(ctx context.Context, req
 
 // ListBlockchainRefunds retrieves all blockchain refunds with optional filtering
 // GET /open-api/v3/crypto/refunds
-func (c *BlockchainRefundClient) ListBlockchainRefunds(ctx context.Context, options *ListBlockchainRefundsOptions) (*BlockchainRefundListResponse, error) {
+func (c *BlockchainRefundClient) ListBlockchainRefunds(ctx context.Context, options *ListBlockchainRefundsOptions, callOpts ...CallOption) (*BlockchainRefundListResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	var queryParams url.Values
 	if options != nil {
 		queryParams = url.Values{}
@@ -182,7 +186,9 @@ func (c *BlockchainRefundClient) ListBlockchainRefunds(ctx context.Context, opti
 
 // GetRefundGasFee retrieves estimated gas fee for a refund
 // POST /open-api/v3/crypto/refund/gas-fee
-func (c *BlockchainRefundClient) GetRefundGasFee(ctx context.Context, req *GetRefundGasFeeRequest) (*RefundGasFee, error) {
+func (c *BlockchainRefundClient) GetRefundGasFee(ctx context.Context, req *GetRefundGasFeeRequest, callOpts ...CallOption) (*RefundGasFee, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if req == nil {
 		return nil, fmt.Errorf("gas fee request is required")
 	}
@@ -214,7 +220,9 @@ func (c *BlockchainRefundClient) GetRefundGasFee(ctx context.Context, req *GetRe
 
 // GetBlockchainRefund retrieves a specific blockchain refund by ID
 // GET /open-api/v3/crypto/refund/{refundId}
-func (c *BlockchainRefundClient) GetBlockchainRefund(ctx context.Context, refundID string) (*BlockchainRefund, error) {
+func (c *BlockchainRefundClient) GetBlockchainRefund(ctx context.Context, refundID string, callOpts ...CallOption) (*BlockchainRefund, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if refundID == "" {
 		return nil, fmt.Errorf("refund ID is required")
 	}
@@ -233,3 +241,31 @@ func (c *BlockchainRefundClient) GetBlockchainRefund(ctx context.Context, refund
 
 	return &refund, nil
 }
+
+// CreateBlockchainRefundOperation creates a blockchain refund and returns an operation that
+// tracks it until it completes or fails
+func (c *BlockchainRefundClient) CreateBlockchainRefundOperation(ctx context.Context, req *CreateBlockchainRefundRequest, callOpts ...CallOption) (*Operation[BlockchainRefund], error) {
+	refund, err := c.CreateBlockchainRefund(ctx, req, callOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return newOperation(refund.RefundID, refund, c.refundOperationSpec(refund.RefundID)), nil
+}
+
+// BlockchainRefundOperation returns an operation that tracks an existing blockchain refund
+func (c *BlockchainRefundClient) BlockchainRefundOperation(refundID string) *Operation[BlockchainRefund] {
+	return newOperation(refundID, nil, c.refundOperationSpec(refundID))
+}
+
+func (c *BlockchainRefundClient) refundOperationSpec(refundID string) operationSpec[BlockchainRefund] {
+	return operationSpec[BlockchainRefund]{
+		poll: func(ctx context.Context) (*BlockchainRefund, error) {
+			return c.GetBlockchainRefund(ctx, refundID)
+		},
+		state: func(r *BlockchainRefund) (OperationState, string, string) {
+			return statusState(r.Status), r.Status, r.FailedReason
+		},
+		eventKeys: []string{"refundId", "id"},
+	}
+}
@@ -45,7 +45,9 @@ type CreateBudgetRequest struct {
 
 // CreateBudget creates a new budget
 // POST /open-api/v3/budgets
-func (c *BudgetClient) CreateBudget(ctx context.Context, req *CreateBudgetRequest) (*Budget, error) {
+func (c *BudgetClient) CreateBudget(ctx context.Context, req *CreateBudgetRequest, callOpts ...CallOption) (*Budget, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if req.AccountID == "" {
 		return nil, fmt.Errorf("accountId is required")
 	}
@@ -90,7 +92,9 @@ type BudgetListResponse struct {
 
 // ListBudgets retrieves a list of budgets with optional filtering
 // GET /open-api/v3/budgets
-func (c *BudgetClient) ListBudgets(ctx context.Context, options *ListBudgetsOptions) (*BudgetListResponse, error) {
+func (c *BudgetClient) ListBudgets(ctx context.Context, options *ListBudgetsOptions, callOpts ...CallOption) (*BudgetListResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	queryParams := url.Values{}
 	
 	if options != nil {
@@ -126,7 +130,9 @@ func (c *BudgetClient) ListBudgets(ctx context.Context, options *ListBudgetsOpti
 
 // GetBudget retrieves details of a specific budget
 // GET /open-api/v3/budgets/{id}
-func (c *BudgetClient) GetBudget(ctx context.Context, budgetID string) (*Budget, error) {
+func (c *BudgetClient) GetBudget(ctx context.Context, budgetID string, callOpts ...CallOption) (*Budget, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if budgetID == "" {
 		return nil, fmt.Errorf("budget ID cannot be empty")
 	}
@@ -155,7 +161,9 @@ type UpdateBudgetRequest struct {
 
 // UpdateBudget updates budget information
 // PATCH /open-api/v3/budgets/{id}
-func (c *BudgetClient) UpdateBudget(ctx context.Context, budgetID string, req *UpdateBudgetRequest) (*Budget, error) {
+func (c *BudgetClient) UpdateBudget(ctx context.Context, budgetID string, req *UpdateBudgetRequest, callOpts ...CallOption) (*Budget, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if budgetID == "" {
 		return nil, fmt.Errorf("budget ID cannot be empty")
 	}
@@ -185,7 +193,9 @@ type DeleteBudgetResponse struct {
 
 // DeleteBudget deletes a budget
 // DELETE /open-api/v3/budgets/{id}
-func (c *BudgetClient) DeleteBudget(ctx context.Context, budgetID string) (*DeleteBudgetResponse, error) {
+func (c *BudgetClient) DeleteBudget(ctx context.Context, budgetID string, callOpts ...CallOption) (*DeleteBudgetResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if budgetID == "" {
 		return nil, fmt.Errorf("budget ID cannot be empty")
 	}
@@ -230,7 +240,9 @@ type BudgetBalanceResponse struct {
 
 // IncreaseBudgetBalance increases the budget balance (top-up)
 // POST /open-api/v3/budgets/{id}/increase
-func (c *BudgetClient) IncreaseBudgetBalance(ctx context.Context, budgetID string, req *IncreaseBudgetBalanceRequest) (*BudgetBalanceResponse, error) {
+func (c *BudgetClient) IncreaseBudgetBalance(ctx context.Context, budgetID string, req *IncreaseBudgetBalanceRequest, callOpts ...CallOption) (*BudgetBalanceResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if budgetID == "" {
 		return nil, fmt.Errorf("budget ID cannot be empty")
 	}
@@ -267,7 +279,9 @@ type DecreaseBudgetBalanceRequest struct {
 
 // DecreaseBudgetBalance decreases the budget balance (withdraw)
 // POST /open-api/v3/budgets/{id}/decrease
-func (c *BudgetClient) DecreaseBudgetBalance(ctx context.Context, budgetID string, req *DecreaseBudgetBalanceRequest) (*BudgetBalanceResponse, error) {
+func (c *BudgetClient) DecreaseBudgetBalance(ctx context.Context, budgetID string, req *DecreaseBudgetBalanceRequest, callOpts ...CallOption) (*BudgetBalanceResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if budgetID == "" {
 		return nil, fmt.Errorf("budget ID cannot be empty")
 	}
@@ -314,7 +328,9 @@ type BudgetTransaction struct {
 
 // GetBudgetTransaction retrieves details of a specific budget transaction
 // GET /open-api/v3/budgets/{id}/transactions/{transactionId}
-func (c *BudgetClient) GetBudgetTransaction(ctx context.Context, budgetID, transactionID string) (*BudgetTransaction, error) {
+func (c *BudgetClient) GetBudgetTransaction(ctx context.Context, budgetID, transactionID string, callOpts ...CallOption) (*BudgetTransaction, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if budgetID == "" {
 		return nil, fmt.Errorf("budget ID cannot be empty")
 	}
@@ -357,7 +373,9 @@ type BudgetTransactionListResponse struct {
 
 // ListBudgetTransactions retrieves a list of budget transactions
 // GET /open-api/v3/budgets/{id}/transactions
-func (c *BudgetClient) ListBudgetTransactions(ctx context.Context, budgetID string, options *ListBudgetTransactionsOptions) (*BudgetTransactionListResponse, error) {
+func (c *BudgetClient) ListBudgetTransactions(ctx context.Context, budgetID string, options *ListBudgetTransactionsOptions, callOpts ...CallOption) (*BudgetTransactionListResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if budgetID == "" {
 		return nil, fmt.Errorf("budget ID cannot be empty")
 	}
@@ -0,0 +1,363 @@
+package interlace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BudgetUsage is the utilization of a budget over the monitoring window
+type BudgetUsage struct {
+	Budget Budget
+	// Spent is the card spend of the budget's cards over the window
+	Spent float64
+	// Funded is the total of the budget balance increases over the window
+	Funded float64
+	// Utilization is the share of the funds available over the window that was spent:
+	// Spent / (Spent + AvailableBalance)
+	Utilization float64
+	// BurnRate is the average spend per day over the window
+	BurnRate float64
+	// ExhaustsAt is when the available balance runs out at the current burn rate. It is nil when
+	// nothing was spent.
+	ExhaustsAt *time.Time
+}
+
+// BudgetAlertKind is the reason of a budget alert
+type BudgetAlertKind string
+
+// Budget alert kinds
+const (
+	// BudgetAlertUtilization is raised when utilization crosses one of the thresholds
+	BudgetAlertUtilization BudgetAlertKind = "utilization"
+	// BudgetAlertExhaustion is raised when the budget is projected to run out within the horizon
+	BudgetAlertExhaustion BudgetAlertKind = "exhaustion"
+	// BudgetAlertDepleted is raised when the available balance is used up
+	BudgetAlertDepleted BudgetAlertKind = "depleted"
+)
+
+// BudgetAlert is emitted by the budget monitor when a threshold is crossed
+type BudgetAlert struct {
+	Kind  BudgetAlertKind
+	Usage BudgetUsage
+	// Threshold is the utilization threshold that was crossed, for BudgetAlertUtilization
+	Threshold float64
+	Message   string
+}
+
+// BudgetNotifier delivers budget alerts, e.g. to chat, email or a pager
+type BudgetNotifier interface {
+	Notify(ctx context.Context, alert *BudgetAlert) error
+}
+
+// BudgetNotifierFunc adapts a function to BudgetNotifier
+type BudgetNotifierFunc func(ctx context.Context, alert *BudgetAlert) error
+
+// Notify implements BudgetNotifier
+func (f BudgetNotifierFunc) Notify(ctx context.Context, alert *BudgetAlert) error {
+	return f(ctx, alert)
+}
+
+// BudgetMonitor computes budget utilization, burn rate and projected exhaustion, and alerts when
+// thresholds are crossed. Each alert is emitted once per crossing: it fires again only after the
+// budget went back below the threshold.
+//
+//	monitor := interlace.NewBudgetMonitor(client, interlace.BudgetNotifierFunc(
+//		func(ctx context.Context, alert *interlace.BudgetAlert) error {
+//			log.Print(alert.Message)
+//			return nil
+//		}))
+//	monitor.AccountID = accountID
+//	err := monitor.Run(ctx, time.Hour)
+type BudgetMonitor struct {
+	client   *Client
+	notifier BudgetNotifier
+
+	// AccountID restricts monitoring to the budgets of an account
+	AccountID string
+	// BudgetIDs restricts monitoring to the listed budgets
+	BudgetIDs []string
+	// Window is the period over which spend is measured. Defaults to 7 days.
+	Window time.Duration
+	// UtilizationThresholds are fractions of utilization that raise alerts. Defaults to 0.5, 0.8
+	// and 0.95.
+	UtilizationThresholds []float64
+	// ExhaustionHorizon raises an alert when a budget is projected to run out within it. Zero
+	// disables exhaustion alerts.
+	ExhaustionHorizon time.Duration
+
+	// OnError is called with the errors of Run
+	OnError func(err error)
+
+	now func() time.Time
+
+	mu     sync.Mutex
+	raised map[string]map[string]bool // Budget ID to the alerts currently raised
+}
+
+// NewBudgetMonitor creates a budget monitor with the default settings
+func NewBudgetMonitor(client *Client, notifier BudgetNotifier) *BudgetMonitor {
+	return &BudgetMonitor{
+		client:                client,
+		notifier:              notifier,
+		Window:                7 * 24 * time.Hour,
+		UtilizationThresholds: []float64{0.5, 0.8, 0.95},
+		now:                   time.Now,
+		raised:                make(map[string]map[string]bool),
+	}
+}
+
+// Run checks the budgets right away and then every interval until ctx is done. Errors are passed
+// to OnError; Run only returns ctx.Err().
+func (m *BudgetMonitor) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := m.Check(ctx); err != nil && m.OnError != nil && !errors.Is(err, context.Canceled) {
+			m.OnError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check computes the usage of every monitored budget and notifies the alerts raised since the
+// previous check. Budgets that could not be measured are reported in the returned error.
+func (m *BudgetMonitor) Check(ctx context.Context) ([]BudgetUsage, error) {
+	budgets, err := m.budgets(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var usages []BudgetUsage
+	var errs []error
+	for i := range budgets {
+		usage, err := m.Usage(ctx, &budgets[i])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		usages = append(usages, *usage)
+
+		for _, alert := range m.alerts(usage) {
+			if m.notifier == nil {
+				continue
+			}
+			if err := m.notifier.Notify(ctx, alert); err != nil {
+				errs = append(errs, fmt.Errorf("failed to notify %s alert for budget %s: %w", alert.Kind, usage.Budget.ID, err))
+			}
+		}
+	}
+	return usages, errors.Join(errs...)
+}
+
+// Usage measures a budget over the monitoring window
+func (m *BudgetMonitor) Usage(ctx context.Context, budget *Budget) (*BudgetUsage, error) {
+	window := m.Window
+	if window <= 0 {
+		window = 7 * 24 * time.Hour
+	}
+	now := m.now()
+	since := now.Add(-window)
+
+	usage := &BudgetUsage{Budget: *budget}
+
+	funded, err := m.funded(ctx, budget.ID, since)
+	if err != nil {
+		return nil, err
+	}
+	usage.Funded = funded
+
+	spent, err := m.spent(ctx, budget, since)
+	if err != nil {
+		return nil, err
+	}
+	usage.Spent = spent
+
+	if total := spent + budget.AvailableBalance; total > 0 {
+		usage.Utilization = spent / total
+	}
+	usage.BurnRate = spent / (window.Hours() / 24)
+	if usage.BurnRate > 0 {
+		days := math.Max(budget.AvailableBalance, 0) / usage.BurnRate
+		exhaustsAt := now.Add(time.Duration(days * float64(24*time.Hour)))
+		usage.ExhaustsAt = &exhaustsAt
+	}
+	return usage, nil
+}
+
+// budgets returns the monitored budgets
+func (m *BudgetMonitor) budgets(ctx context.Context) ([]Budget, error) {
+	if len(m.BudgetIDs) > 0 {
+		budgets := make([]Budget, 0, len(m.BudgetIDs))
+		for _, id := range m.BudgetIDs {
+			budget, err := m.client.Budget.GetBudget(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			budgets = append(budgets, *budget)
+		}
+		return budgets, nil
+	}
+
+	var budgets []Budget
+	err := walkPages(func(page int) (int, int, error) {
+		list, err := m.client.Budget.ListBudgets(ctx, &ListBudgetsOptions{AccountID: m.AccountID, Limit: selectPageSize, Page: page})
+		if err != nil {
+			return 0, 0, err
+		}
+		budgets = append(budgets, list.List...)
+		return len(list.List), list.Total, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return budgets, nil
+}
+
+// funded sums the balance increases of a budget since the given time
+func (m *BudgetMonitor) funded(ctx context.Context, budgetID string, since time.Time) (float64, error) {
+	funded := 0.0
+	err := walkPages(func(page int) (int, int, error) {
+		list, err := m.client.Budget.ListBudgetTransactions(ctx, budgetID, &ListBudgetTransactionsOptions{
+			StartTime: since.UTC().Format(time.RFC3339),
+			Limit:     selectPageSize,
+			Page:      page,
+		})
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, tx := range list.List {
+			if tx.CardID == "" && tx.BalanceAfter > tx.BalanceBefore && !isFailedStatus(tx.Status) {
+				funded += tx.BalanceAfter - tx.BalanceBefore
+			}
+		}
+		return len(list.List), list.Total, nil
+	})
+	return funded, err
+}
+
+// spent sums the card spend of a budget's cards since the given time
+func (m *BudgetMonitor) spent(ctx context.Context, budget *Budget, since time.Time) (float64, error) {
+	cards, err := m.client.Card.SelectCards(ctx, CardListOptions{AccountID: budget.AccountID, BudgetID: budget.ID}, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	spent := 0.0
+	for _, card := range cards {
+		err := walkPages(func(page int) (int, int, error) {
+			list, err := m.client.CardTransaction.ListCardTransactions(ctx, &ListCardTransactionsOptions{
+				CardID:    card.ID,
+				StartTime: since.UTC().Format(time.RFC3339),
+				Limit:     selectPageSize,
+				Page:      page,
+			})
+			if err != nil {
+				return 0, 0, err
+			}
+			for i := range list.List {
+				if isCardSpend(&list.List[i]) {
+					spent += math.Abs(list.List[i].Amount)
+				}
+			}
+			return len(list.List), list.Total, nil
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return spent, nil
+}
+
+// isCardSpend reports whether a card transaction is a purchase that consumed funds
+func isCardSpend(tx *CardTransaction) bool {
+	if isFailedStatus(tx.Status) {
+		return false
+	}
+	switch strings.ToUpper(tx.Type) {
+	case "REFUND", "REVERSAL", "CREDIT", "TRANSFER_IN", "TRANSFER_OUT", "DEPOSIT", "WITHDRAWAL":
+		return false
+	}
+	return true
+}
+
+func isFailedStatus(status string) bool {
+	switch strings.ToUpper(status) {
+	case "DECLINED", "FAILED", "REVERSED", "VOID", "VOIDED":
+		return true
+	}
+	return statusState(status) == OperationFailed
+}
+
+// alerts returns the alerts newly raised by a usage and clears the ones no longer raised
+func (m *BudgetMonitor) alerts(usage *BudgetUsage) []*BudgetAlert {
+	budget := &usage.Budget
+	name := budget.Name
+	if name == "" {
+		name = budget.ID
+	}
+
+	var candidates []*BudgetAlert
+	thresholds := append([]float64(nil), m.UtilizationThresholds...)
+	sort.Float64s(thresholds)
+	crossed := 0
+	for crossed < len(thresholds) && usage.Utilization >= thresholds[crossed] {
+		crossed++
+	}
+	// Only the highest threshold crossed is reported
+	if crossed > 0 {
+		candidates = append(candidates, &BudgetAlert{
+			Kind:      BudgetAlertUtilization,
+			Threshold: thresholds[crossed-1],
+			Message: fmt.Sprintf("Budget %s is %.0f%% utilized (%s of %s %s spent in the window)",
+				name, usage.Utilization*100, formatAmount(usage.Spent), formatAmount(usage.Spent+budget.AvailableBalance), budget.Currency),
+		})
+	}
+	if budget.AvailableBalance <= 0 {
+		candidates = append(candidates, &BudgetAlert{
+			Kind:    BudgetAlertDepleted,
+			Message: fmt.Sprintf("Budget %s has no available balance left", name),
+		})
+	} else if m.ExhaustionHorizon > 0 && usage.ExhaustsAt != nil && usage.ExhaustsAt.Sub(m.now()) <= m.ExhaustionHorizon {
+		candidates = append(candidates, &BudgetAlert{
+			Kind: BudgetAlertExhaustion,
+			Message: fmt.Sprintf("Budget %s will run out around %s at %s %s per day",
+				name, usage.ExhaustsAt.Format(time.RFC3339), formatAmount(roundCents(usage.BurnRate)), budget.Currency),
+		})
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Every threshold crossed stays raised, so falling back from a higher threshold does not
+	// report the lower one again
+	current := make(map[string]bool)
+	for _, threshold := range thresholds[:crossed] {
+		current[fmt.Sprintf("%s:%g", BudgetAlertUtilization, threshold)] = true
+	}
+
+	var raised []*BudgetAlert
+	for _, alert := range candidates {
+		alert.Usage = *usage
+		key := string(alert.Kind)
+		if alert.Kind == BudgetAlertUtilization {
+			key = fmt.Sprintf("%s:%g", alert.Kind, alert.Threshold)
+		}
+		current[key] = true
+		if !m.raised[budget.ID][key] {
+			raised = append(raised, alert)
+		}
+	}
+	m.raised[budget.ID] = current
+	return raised
+}
@@ -0,0 +1,354 @@
+package interlace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// BudgetAllocation is the target available balance of a budget in a rebalance. Set either Amount
+// or Percent. Percentages apply to the funds left once the absolute amounts are allocated.
+type BudgetAllocation struct {
+	BudgetID string
+	Amount   float64
+	Percent  float64
+}
+
+// BudgetRebalanceRequest moves funds between budgets so that their available balances match the
+// allocations. The total available balance of the budgets is preserved.
+type BudgetRebalanceRequest struct {
+	// ID prefixes the merchant trade numbers of the balance operations, which double as their
+	// idempotency keys, so use a new ID for every rebalance, even after a rollback. A random ID is
+	// used when empty.
+	ID          string
+	Allocations []BudgetAllocation
+	// MinTransfer skips balance differences smaller than this amount. Defaults to 0.01. The
+	// skipped differences are moved by the largest operation, so that the operations balance.
+	MinTransfer float64
+	// DryRun only computes the operations
+	DryRun bool
+}
+
+// BudgetRebalanceState is the available balance of one budget around a rebalance
+type BudgetRebalanceState struct {
+	BudgetID string
+	Name     string
+	Before   float64
+	Target   float64
+	// After is the balance read back once the operations ran. It is zero for dry runs and when it
+	// could not be read.
+	After float64
+}
+
+// Budget rebalance operation types
+const (
+	BudgetOperationDecrease = "decrease"
+	BudgetOperationIncrease = "increase"
+)
+
+// Budget rebalance operation statuses
+const (
+	RebalancePlanned            = "planned"
+	RebalanceSucceeded          = "succeeded"
+	RebalanceFailed             = "failed"
+	RebalanceCompensated        = "compensated"
+	RebalanceCompensationFailed = "compensation_failed"
+	// RebalanceAmbiguous marks an operation that failed without a definite outcome, e.g. on a
+	// network error, and whose outcome could not be established by sending it again. Check the
+	// budget before retrying.
+	RebalanceAmbiguous = "ambiguous"
+)
+
+// BudgetRebalanceOp is one balance operation of a rebalance
+type BudgetRebalanceOp struct {
+	BudgetID        string
+	Type            string // BudgetOperationDecrease or BudgetOperationIncrease
+	Amount          float64
+	MerchantTradeNo string
+	Status          string
+	Error           string
+}
+
+// BudgetRebalanceReport holds the operations of a rebalance and the balances before and after
+type BudgetRebalanceReport struct {
+	ID         string
+	Currency   string
+	Budgets    []BudgetRebalanceState
+	Operations []BudgetRebalanceOp
+	// RolledBack reports whether an operation failed and the completed ones were compensated
+	RolledBack bool
+}
+
+// Rebalance moves funds between budgets to reach the target allocations with one decrease or
+// increase per budget. Decreases run first, then increases. When an operation fails, the
+// completed operations are reversed and the error is returned along with the report; operations
+// whose compensation failed are marked RebalanceCompensationFailed and need manual attention. An
+// operation failing without a definite outcome is sent again with its idempotency key during the
+// rollback and compensated if it ran; it stays RebalanceAmbiguous when that fails too.
+//
+//	report, err := client.Budget.Rebalance(ctx, &interlace.BudgetRebalanceRequest{
+//		Allocations: []interlace.BudgetAllocation{
+//			{BudgetID: "marketing", Percent: 60},
+//			{BudgetID: "sales", Percent: 40},
+//		},
+//	})
+func (c *BudgetClient) Rebalance(ctx context.Context, req *BudgetRebalanceRequest, callOpts ...CallOption) (*BudgetRebalanceReport, error) {
+	if req == nil || len(req.Allocations) == 0 {
+		return nil, errors.New("at least one budget allocation is required")
+	}
+
+	report := &BudgetRebalanceReport{ID: req.ID}
+	if report.ID == "" {
+		report.ID = "rebalance-" + NewIdempotencyKey()
+	}
+
+	seen := make(map[string]bool)
+	for _, allocation := range req.Allocations {
+		if allocation.BudgetID == "" {
+			return nil, errors.New("budget ID cannot be empty")
+		}
+		if seen[allocation.BudgetID] {
+			return nil, fmt.Errorf("budget %s is allocated twice", allocation.BudgetID)
+		}
+		seen[allocation.BudgetID] = true
+
+		budget, err := c.GetBudget(ctx, allocation.BudgetID, callOpts...)
+		if err != nil {
+			return nil, err
+		}
+		if report.Currency == "" {
+			report.Currency = budget.Currency
+		} else if !strings.EqualFold(report.Currency, budget.Currency) {
+			return nil, fmt.Errorf("budget %s is in %s while the others are in %s", budget.ID, budget.Currency, report.Currency)
+		}
+		report.Budgets = append(report.Budgets, BudgetRebalanceState{
+			BudgetID: allocation.BudgetID,
+			Name:     budget.Name,
+			Before:   budget.AvailableBalance,
+		})
+	}
+
+	if err := allocateBudgets(req.Allocations, report.Budgets); err != nil {
+		return nil, err
+	}
+
+	minTransfer := req.MinTransfer
+	if minTransfer <= 0 {
+		minTransfer = 0.01
+	}
+	deltas, err := rebalanceDeltas(report.Budgets, minTransfer)
+	if err != nil {
+		return nil, fmt.Errorf("rebalance %s: %w", report.ID, err)
+	}
+	var increases []BudgetRebalanceOp
+	for i, budget := range report.Budgets {
+		delta := deltas[i]
+		report.Budgets[i].Target = roundCents(budget.Before + delta)
+		if delta == 0 {
+			continue
+		}
+		op := BudgetRebalanceOp{
+			BudgetID: budget.BudgetID,
+			Type:     BudgetOperationDecrease,
+			Amount:   -delta,
+			Status:   RebalancePlanned,
+		}
+		if delta > 0 {
+			op.Type = BudgetOperationIncrease
+			op.Amount = delta
+		}
+		op.MerchantTradeNo = fmt.Sprintf("%s-%s-%s", report.ID, op.BudgetID, op.Type)
+
+		if op.Type == BudgetOperationDecrease {
+			report.Operations = append(report.Operations, op)
+		} else {
+			increases = append(increases, op)
+		}
+	}
+	report.Operations = append(report.Operations, increases...)
+
+	if req.DryRun || len(report.Operations) == 0 {
+		return report, nil
+	}
+
+	var failure error
+	for i := range report.Operations {
+		op := &report.Operations[i]
+		if err := c.applyRebalanceOp(ctx, report.Currency, op.Type, op, op.MerchantTradeNo, callOpts); err != nil {
+			op.Status = RebalanceFailed
+			if isAmbiguous(err) {
+				op.Status = RebalanceAmbiguous
+			}
+			op.Error = err.Error()
+			failure = fmt.Errorf("rebalance %s: %s of budget %s failed: %w", report.ID, op.Type, op.BudgetID, err)
+			break
+		}
+		op.Status = RebalanceSucceeded
+	}
+
+	if failure != nil {
+		report.RolledBack = true
+		// Compensation must run even when ctx was cancelled
+		compensateCtx := context.WithoutCancel(ctx)
+		for i := len(report.Operations) - 1; i >= 0; i-- {
+			op := &report.Operations[i]
+			if op.Status == RebalanceAmbiguous {
+				// Sending the operation again with its idempotency key tells whether it ran
+				if err := c.applyRebalanceOp(compensateCtx, report.Currency, op.Type, op, op.MerchantTradeNo, callOpts); err != nil {
+					if isAmbiguous(err) {
+						op.Error = err.Error()
+						failure = fmt.Errorf("%w; the outcome of the %s of budget %s is unknown: %v", failure, op.Type, op.BudgetID, err)
+						continue
+					}
+					op.Status = RebalanceFailed
+					continue
+				}
+				op.Status = RebalanceSucceeded
+			}
+			if op.Status != RebalanceSucceeded {
+				continue
+			}
+			reverse := BudgetOperationIncrease
+			if op.Type == BudgetOperationIncrease {
+				reverse = BudgetOperationDecrease
+			}
+			if err := c.applyRebalanceOp(compensateCtx, report.Currency, reverse, op, op.MerchantTradeNo+"-compensation", callOpts); err != nil {
+				op.Status = RebalanceCompensationFailed
+				op.Error = err.Error()
+				failure = fmt.Errorf("%w; compensating the %s of budget %s failed: %v", failure, op.Type, op.BudgetID, err)
+				continue
+			}
+			op.Status = RebalanceCompensated
+		}
+	}
+
+	for i := range report.Budgets {
+		if budget, err := c.GetBudget(context.WithoutCancel(ctx), report.Budgets[i].BudgetID, callOpts...); err == nil {
+			report.Budgets[i].After = budget.AvailableBalance
+		}
+	}
+
+	return report, failure
+}
+
+// isAmbiguous reports whether a failed request may still have been carried out
+func isAmbiguous(err error) bool {
+	var reqErr *RequestError
+	return errors.As(err, &reqErr)
+}
+
+// rebalanceDeltas returns the balance change of every budget. Changes smaller than minTransfer
+// are skipped and their sum is moved by the largest change instead, so that the changes still
+// add up to zero.
+func rebalanceDeltas(budgets []BudgetRebalanceState, minTransfer float64) ([]float64, error) {
+	deltas := make([]float64, len(budgets))
+	skipped := 0.0
+	largest := -1
+	for i, budget := range budgets {
+		delta := roundCents(budget.Target - budget.Before)
+		if math.Abs(delta) < minTransfer {
+			skipped += delta
+			continue
+		}
+		deltas[i] = delta
+		if largest < 0 || math.Abs(delta) > math.Abs(deltas[largest]) {
+			largest = i
+		}
+	}
+
+	skipped = roundCents(skipped)
+	if skipped == 0 {
+		return deltas, nil
+	}
+	if largest < 0 {
+		return make([]float64, len(budgets)), nil
+	}
+	adjusted := roundCents(deltas[largest] + skipped)
+	if math.Abs(adjusted) < minTransfer || (adjusted > 0) != (deltas[largest] > 0) {
+		return nil, fmt.Errorf("differences below the minimum transfer of %s cannot be balanced", formatAmount(minTransfer))
+	}
+	deltas[largest] = adjusted
+	return deltas, nil
+}
+
+// applyRebalanceOp runs a decrease or increase of op's budget and amount
+func (c *BudgetClient) applyRebalanceOp(ctx context.Context, currency, opType string, op *BudgetRebalanceOp, tradeNo string, callOpts []CallOption) error {
+	description := "Budget rebalance " + tradeNo
+	callOpts = append(callOpts[:len(callOpts):len(callOpts)], WithIdempotencyKey(tradeNo))
+
+	var err error
+	if opType == BudgetOperationDecrease {
+		_, err = c.DecreaseBudgetBalance(ctx, op.BudgetID, &DecreaseBudgetBalanceRequest{
+			Amount:          op.Amount,
+			Currency:        currency,
+			MerchantTradeNo: tradeNo,
+			Description:     description,
+		}, callOpts...)
+	} else {
+		_, err = c.IncreaseBudgetBalance(ctx, op.BudgetID, &IncreaseBudgetBalanceRequest{
+			Amount:          op.Amount,
+			Currency:        currency,
+			MerchantTradeNo: tradeNo,
+			Description:     description,
+		}, callOpts...)
+	}
+	return err
+}
+
+// allocateBudgets sets the target of every budget. Absolute amounts are allocated first and the
+// remaining funds are split by percentage, the last percentage allocation taking the rounding
+// remainder.
+func allocateBudgets(allocations []BudgetAllocation, budgets []BudgetRebalanceState) error {
+	total, absolute, percent := 0.0, 0.0, 0.0
+	lastPercent := -1
+	for i, allocation := range allocations {
+		total += budgets[i].Before
+		switch {
+		case allocation.Amount < 0 || allocation.Percent < 0:
+			return fmt.Errorf("budget %s: allocation cannot be negative", allocation.BudgetID)
+		case allocation.Amount > 0 && allocation.Percent > 0:
+			return fmt.Errorf("budget %s: set either an amount or a percentage", allocation.BudgetID)
+		case allocation.Percent > 0:
+			percent += allocation.Percent
+			lastPercent = i
+		default:
+			absolute += allocation.Amount
+		}
+	}
+	total = roundCents(total)
+	absolute = roundCents(absolute)
+
+	remaining := roundCents(total - absolute)
+	if remaining < 0 {
+		return fmt.Errorf("allocations of %s exceed the available %s", formatAmount(absolute), formatAmount(total))
+	}
+	if lastPercent < 0 && remaining != 0 {
+		return fmt.Errorf("allocations of %s do not match the available %s", formatAmount(absolute), formatAmount(total))
+	}
+	if lastPercent >= 0 && math.Abs(percent-100) > 1e-9 {
+		return fmt.Errorf("percentages add up to %s instead of 100", formatAmount(percent))
+	}
+
+	allocated := 0.0
+	for i, allocation := range allocations {
+		target := allocation.Amount
+		if allocation.Percent > 0 {
+			target = roundCents(remaining * allocation.Percent / 100)
+		}
+		if i == lastPercent {
+			continue
+		}
+		budgets[i].Target = target
+		allocated += target
+	}
+	if lastPercent >= 0 {
+		budgets[lastPercent].Target = roundCents(total - allocated)
+	}
+	return nil
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
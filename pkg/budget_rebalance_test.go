@@ -0,0 +1,148 @@
+package interlace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAllocateBudgets(t *testing.T) {
+	tests := []struct {
+		name        string
+		before      []float64
+		allocations []BudgetAllocation
+		targets     []float64
+		err         string
+	}{
+		{
+			name:        "absolute amounts",
+			before:      []float64{100, 50},
+			allocations: []BudgetAllocation{{BudgetID: "a", Amount: 30}, {BudgetID: "b", Amount: 120}},
+			targets:     []float64{30, 120},
+		},
+		{
+			name:        "percentages split the remainder after absolute amounts",
+			before:      []float64{100, 100, 100},
+			allocations: []BudgetAllocation{{BudgetID: "a", Amount: 100}, {BudgetID: "b", Percent: 25}, {BudgetID: "c", Percent: 75}},
+			targets:     []float64{100, 50, 150},
+		},
+		{
+			name:        "last percentage takes the rounding remainder",
+			before:      []float64{10.01, 0, 0},
+			allocations: []BudgetAllocation{{BudgetID: "a", Percent: 33.33}, {BudgetID: "b", Percent: 33.33}, {BudgetID: "c", Percent: 33.34}},
+			targets:     []float64{3.34, 3.34, 3.33},
+		},
+		{
+			name:        "absolute amounts must match the total",
+			before:      []float64{100, 50},
+			allocations: []BudgetAllocation{{BudgetID: "a", Amount: 100}, {BudgetID: "b", Amount: 40}},
+			err:         "do not match the available",
+		},
+		{
+			name:        "absolute amounts cannot exceed the total",
+			before:      []float64{100, 50},
+			allocations: []BudgetAllocation{{BudgetID: "a", Amount: 100}, {BudgetID: "b", Amount: 60}},
+			err:         "exceed the available",
+		},
+		{
+			name:        "percentages must add up to 100",
+			before:      []float64{100, 50},
+			allocations: []BudgetAllocation{{BudgetID: "a", Percent: 50}, {BudgetID: "b", Percent: 40}},
+			err:         "percentages add up to",
+		},
+		{
+			name:        "amount and percentage are exclusive",
+			before:      []float64{100, 50},
+			allocations: []BudgetAllocation{{BudgetID: "a", Amount: 50, Percent: 50}, {BudgetID: "b", Percent: 50}},
+			err:         "either an amount or a percentage",
+		},
+		{
+			name:        "negative allocations are rejected",
+			before:      []float64{100, 50},
+			allocations: []BudgetAllocation{{BudgetID: "a", Amount: -10}, {BudgetID: "b", Amount: 160}},
+			err:         "cannot be negative",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budgets := make([]BudgetRebalanceState, len(tt.before))
+			for i, before := range tt.before {
+				budgets[i] = BudgetRebalanceState{BudgetID: tt.allocations[i].BudgetID, Before: before}
+			}
+
+			err := allocateBudgets(tt.allocations, budgets)
+			if tt.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.err)
+				return
+			}
+			require.NoError(t, err)
+			for i, budget := range budgets {
+				assert.InDelta(t, tt.targets[i], budget.Target, 1e-9, "budget %s", budget.BudgetID)
+			}
+		})
+	}
+}
+
+func TestRebalanceDeltas(t *testing.T) {
+	tests := []struct {
+		name        string
+		before      []float64
+		targets     []float64
+		minTransfer float64
+		deltas      []float64
+		err         string
+	}{
+		{
+			name:        "differences are moved",
+			before:      []float64{100, 50},
+			targets:     []float64{75, 75},
+			minTransfer: 0.01,
+			deltas:      []float64{-25, 25},
+		},
+		{
+			name:        "skipped differences are moved by the largest change",
+			before:      []float64{100, 50, 10},
+			targets:     []float64{70, 80.5, 9.5},
+			minTransfer: 1,
+			deltas:      []float64{-30, 30, 0},
+		},
+		{
+			name:        "only small differences move nothing",
+			before:      []float64{10, 10},
+			targets:     []float64{10.5, 9.5},
+			minTransfer: 1,
+			deltas:      []float64{0, 0},
+		},
+		{
+			name:        "largest change cannot drop below the minimum",
+			before:      []float64{10, 10, 10},
+			targets:     []float64{8.5, 10.9, 10.6},
+			minTransfer: 1,
+			err:         "cannot be balanced",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budgets := make([]BudgetRebalanceState, len(tt.before))
+			for i := range tt.before {
+				budgets[i] = BudgetRebalanceState{Before: tt.before[i], Target: tt.targets[i]}
+			}
+
+			deltas, err := rebalanceDeltas(budgets, tt.minTransfer)
+			if tt.err != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, deltas, len(tt.deltas))
+			for i := range deltas {
+				assert.InDelta(t, tt.deltas[i], deltas[i], 1e-9, "budget %d", i)
+			}
+		})
+	}
+}
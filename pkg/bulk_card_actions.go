@@ -0,0 +1,323 @@
+package interlace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// CardAction is an action applied to cards in bulk
+type CardAction string
+
+// Card actions
+const (
+	CardActionFreeze          CardAction = "freeze"
+	CardActionUnfreeze        CardAction = "unfreeze"
+	CardActionRemove          CardAction = "remove"
+	CardActionVelocityControl CardAction = "set_velocity_control"
+)
+
+// BulkCardActionRequest selects cards and the action to apply to them. A dry run selects the
+// cards; the action is then applied to exactly the cards of its report.
+//
+//	req := &interlace.BulkCardActionRequest{
+//		Filter: interlace.CardListOptions{CardholderID: cardholderID},
+//		Action: interlace.CardActionFreeze,
+//		DryRun: true,
+//	}
+//	preview, err := client.Card.BulkAction(ctx, req)
+//	req.DryRun, req.Preview = false, preview
+//	report, err := client.Card.BulkAction(ctx, req)
+type BulkCardActionRequest struct {
+	// Filter selects the cards. Limit and Page are ignored: every page is fetched. Cards missing
+	// a filtered field are excluded.
+	Filter CardListOptions
+	// Match optionally narrows the selection further, e.g. on card status or label
+	Match func(card *Card) bool
+
+	Action CardAction
+	// VelocityControl is the limit applied by CardActionVelocityControl
+	VelocityControl *VelocityControlRequest
+
+	// DryRun only selects the cards, without applying the action
+	DryRun bool
+	// Preview is the report of the dry run of the same action. The action is applied to its
+	// cards, without selecting them again; it is required unless DryRun is set.
+	Preview *BulkCardActionReport
+	// Concurrency is the number of cards processed at once. Defaults to 4.
+	Concurrency int
+	// RequestsPerSecond caps the rate of action requests. Defaults to 10; negative disables the cap.
+	RequestsPerSecond float64
+
+	// OnProgress is called after every card with the number of cards processed so far
+	OnProgress func(processed, total int)
+}
+
+// BulkCardActionResult is the outcome of the action on one card
+type BulkCardActionResult struct {
+	Card  Card   `json:"card"`
+	Error string `json:"error,omitempty"`
+}
+
+// Failed reports whether the action failed on the card
+func (r *BulkCardActionResult) Failed() bool {
+	return r.Error != ""
+}
+
+// BulkCardActionReport holds the selected cards and, unless DryRun was set, the outcome of the
+// action on each
+type BulkCardActionReport struct {
+	Action    CardAction
+	DryRun    bool
+	Results   []BulkCardActionResult
+	Succeeded int
+	Failed    int
+}
+
+// CardIDs returns the IDs of the selected cards
+func (r *BulkCardActionReport) CardIDs() []string {
+	ids := make([]string, len(r.Results))
+	for i, result := range r.Results {
+		ids[i] = result.Card.ID
+	}
+	return ids
+}
+
+// selectPageSize is the page size used to walk card lists
+const selectPageSize = 100
+
+// walkPages calls fetch for pages 1, 2, ... of a selectPageSize list until a page is short or
+// the reported total is reached. fetch returns the number of items on the page and the total.
+func walkPages(fetch func(page int) (count, total int, err error)) error {
+	for page := 1; ; page++ {
+		count, total, err := fetch(page)
+		if err != nil {
+			return err
+		}
+		if count < selectPageSize || page*selectPageSize >= total {
+			return nil
+		}
+	}
+}
+
+// SelectCards returns every card matching the filter, fetching all pages. The filter's Limit and
+// Page are ignored. match may be nil.
+func (c *CardClient) SelectCards(ctx context.Context, filter CardListOptions, match func(card *Card) bool, callOpts ...CallOption) ([]Card, error) {
+	var cards []Card
+	filter.Limit = selectPageSize
+	for page := 1; ; page++ {
+		filter.Page = page
+		list, err := c.ListCards(ctx, &filter, callOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list cards (page %d): %w", page, err)
+		}
+
+		for i := range list.Cards {
+			card := &list.Cards[i]
+			if matchesCardFilter(card, &filter) && (match == nil || match(card)) {
+				cards = append(cards, *card)
+			}
+		}
+
+		if len(list.Cards) == 0 || (!list.HasMore && page*selectPageSize >= list.TotalCount) {
+			return cards, nil
+		}
+	}
+}
+
+// matchesCardFilter re-applies the filter fields the list endpoint may not honour. A card
+// without a filtered field does not match, so that a filter is never widened.
+func matchesCardFilter(card *Card, filter *CardListOptions) bool {
+	if filter.CardholderID != "" && card.CardholderID != filter.CardholderID {
+		return false
+	}
+	if filter.BudgetID != "" && card.BudgetID != filter.BudgetID {
+		return false
+	}
+	if filter.CardBIN != "" && card.CardBIN != filter.CardBIN {
+		return false
+	}
+	return true
+}
+
+// BulkAction applies an action to every card selected by the request and reports the outcome per
+// card. With DryRun set it only previews the selection; the action is then applied to the cards
+// of that preview, passed as Preview, so that cards changing in between are not affected. An
+// error is returned when the request is invalid, the selection fails or ctx is done; cards that
+// were not processed are then reported as failed. WithIdempotencyKey and WithResponseMeta are
+// ignored, since they cannot be shared by the requests made for each card.
+func (c *CardClient) BulkAction(ctx context.Context, req *BulkCardActionRequest, callOpts ...CallOption) (*BulkCardActionReport, error) {
+	ctx = fanOutContext(ctx, callOpts)
+	apply, err := c.cardAction(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var cards []Card
+	if req.DryRun {
+		if cards, err = c.SelectCards(ctx, req.Filter, req.Match); err != nil {
+			return nil, err
+		}
+	} else {
+		if req.Preview == nil || !req.Preview.DryRun || req.Preview.Action != req.Action {
+			return nil, fmt.Errorf("preview of a %s dry run is required", req.Action)
+		}
+		for _, result := range req.Preview.Results {
+			cards = append(cards, result.Card)
+		}
+	}
+
+	report := &BulkCardActionReport{
+		Action:  req.Action,
+		DryRun:  req.DryRun,
+		Results: make([]BulkCardActionResult, len(cards)),
+	}
+	for i := range cards {
+		report.Results[i].Card = cards[i]
+	}
+	if req.DryRun {
+		return report, nil
+	}
+
+	concurrency := req.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+	rps := req.RequestsPerSecond
+	if rps == 0 {
+		rps = 10
+	}
+	limiter := newRateLimiter(rps)
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		processed int
+		done      = make([]bool, len(cards))
+		sem       = make(chan struct{}, concurrency)
+	)
+	for i := range cards {
+		if limiter.wait(ctx) != nil {
+			break
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			if err := apply(ctx, cards[i].ID); err != nil {
+				report.Results[i].Error = err.Error()
+			}
+			done[i] = true
+
+			if req.OnProgress != nil {
+				mu.Lock()
+				processed++
+				req.OnProgress(processed, len(cards))
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for i := range report.Results {
+		if !done[i] {
+			report.Results[i].Error = "not processed"
+			if ctx.Err() != nil {
+				report.Results[i].Error = ctx.Err().Error()
+			}
+		}
+		if report.Results[i].Failed() {
+			report.Failed++
+		} else {
+			report.Succeeded++
+		}
+	}
+
+	return report, ctx.Err()
+}
+
+// cardAction returns the function applying the requested action to one card
+func (c *CardClient) cardAction(req *BulkCardActionRequest) (func(ctx context.Context, cardID string) error, error) {
+	if req == nil {
+		return nil, errors.New("bulk card action request is required")
+	}
+
+	switch req.Action {
+	case CardActionFreeze:
+		return func(ctx context.Context, cardID string) error {
+			_, err := c.FreezeCard(ctx, cardID)
+			return err
+		}, nil
+	case CardActionUnfreeze:
+		return func(ctx context.Context, cardID string) error {
+			_, err := c.UnfreezeCard(ctx, cardID)
+			return err
+		}, nil
+	case CardActionRemove:
+		return func(ctx context.Context, cardID string) error {
+			_, err := c.RemoveCard(ctx, cardID)
+			return err
+		}, nil
+	case CardActionVelocityControl:
+		if req.VelocityControl == nil {
+			return nil, errors.New("velocity control is required for the set_velocity_control action")
+		}
+		return func(ctx context.Context, cardID string) error {
+			_, err := c.SetCardVelocityControl(ctx, cardID, req.VelocityControl)
+			return err
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported card action %q", req.Action)
+}
+
+// rateLimiter spaces out requests to a fixed rate. It is safe for concurrent use. A nil
+// rateLimiter does not limit.
+type rateLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// newRateLimiter creates a limiter allowing perSecond requests per second, or nil when perSecond
+// is not positive
+func newRateLimiter(perSecond float64) *rateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// wait blocks until the next request may be sent or ctx is done
+func (l *rateLimiter) wait(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	at := l.next
+	if now := time.Now(); at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
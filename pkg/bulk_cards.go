@@ -0,0 +1,485 @@
+package interlace
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxBatchCards is the largest number of cards the batch creation endpoints accept
+const maxBatchCards = 100
+
+// BulkCardRow is one card to issue in bulk. Rows with a BudgetID issue budget cards, the others
+// prepaid cards.
+type BulkCardRow struct {
+	// Row is the 1-based position of the row in its input. It is kept when a report is fed back.
+	Row int `json:"row"`
+	// Ref is an optional caller reference, e.g. an employee ID, copied to the report
+	Ref  string                  `json:"ref,omitempty"`
+	Card CreateBudgetCardRequest `json:"card"`
+	// IdempotencyKey is the key of the batch request that issued the row. It is kept in reports
+	// for issued and ambiguous rows, and rows fed back with the same key are issued again in one
+	// chunk with that key, so that cards issued by an ambiguous request are not issued twice.
+	IdempotencyKey string `json:"idempotencyKey,omitempty"`
+}
+
+// BulkCardResult is the outcome of one row
+type BulkCardResult struct {
+	BulkCardRow
+	CardID string `json:"cardId,omitempty"`
+	Error  string `json:"error,omitempty"`
+	// Ambiguous reports that the batch request of the row failed without a definite answer, e.g.
+	// on a network error: its cards may have been issued. Retry the row with its IdempotencyKey
+	// rather than issuing it under a new one.
+	Ambiguous bool `json:"ambiguous,omitempty"`
+}
+
+// Failed reports whether no card was issued for the row
+func (r *BulkCardResult) Failed() bool {
+	return r.CardID == ""
+}
+
+// BulkCardReport holds the outcome of every row of a bulk issuance, in input order
+type BulkCardReport struct {
+	Results   []BulkCardResult
+	Succeeded int
+	Failed    int
+}
+
+// Failures returns the rows that failed, ready to be issued again
+func (r *BulkCardReport) Failures() []BulkCardRow {
+	var rows []BulkCardRow
+	for _, result := range r.Results {
+		if result.Failed() {
+			rows = append(rows, result.BulkCardRow)
+		}
+	}
+	return rows
+}
+
+// bulkCardColumns are the CSV columns of bulk issuance inputs and reports
+var bulkCardColumns = []string{
+	"row", "ref", "binId", "cardholderId", "budgetId", "shippingAddressId", "label",
+	"dailySpendingLimit", "singleTransLimit", "monthlySpendingLimit", "threeDSecureAuthRequired",
+	"idempotencyKey", "cardId", "error", "ambiguous",
+}
+
+// WriteCSV writes the report as CSV. The file can be read back with ReadBulkCardRowsCSV to
+// retry the failed rows only.
+func (r *BulkCardReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(bulkCardColumns); err != nil {
+		return err
+	}
+
+	for _, result := range r.Results {
+		card := result.Card
+		record := []string{
+			strconv.Itoa(result.Row), result.Ref, card.BinID, card.CardholderID, card.BudgetID,
+			card.ShippingAddressID, card.Label,
+			formatAmount(card.DailySpendingLimit), formatAmount(card.SingleTransLimit),
+			formatAmount(card.MonthlySpendingLimit), strconv.FormatBool(card.ThreeDSecureAuthRequired),
+			result.IdempotencyKey, result.CardID, result.Error, strconv.FormatBool(result.Ambiguous),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSONL writes the report as JSON Lines, one result per line. The file can be read back
+// with ReadBulkCardRowsJSONL to retry the failed rows only.
+func (r *BulkCardReport) WriteJSONL(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for _, result := range r.Results {
+		if err := enc.Encode(result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func formatAmount(amount float64) string {
+	if amount == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(amount, 'f', -1, 64)
+}
+
+// ReadBulkCardRowsCSV reads bulk issuance rows from CSV with a header line. Columns are matched
+// by name (see BulkCardReport.WriteCSV) and unknown columns are ignored. Rows that already have
+// a cardId, as in a report of an earlier run, are skipped.
+func ReadBulkCardRowsCSV(r io.Reader) ([]BulkCardRow, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+
+	var rows []BulkCardRow
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV line %d: %w", line, err)
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		if field("cardId") != "" {
+			continue
+		}
+
+		row := BulkCardRow{
+			Row: line - 1,
+			Ref: field("ref"),
+			Card: CreateBudgetCardRequest{
+				BinID:             field("binId"),
+				CardholderID:      field("cardholderId"),
+				BudgetID:          field("budgetId"),
+				ShippingAddressID: field("shippingAddressId"),
+				Label:             field("label"),
+			},
+			IdempotencyKey: field("idempotencyKey"),
+		}
+		if value := field("row"); value != "" {
+			if row.Row, err = strconv.Atoi(value); err != nil {
+				return nil, fmt.Errorf("invalid row on CSV line %d: %w", line, err)
+			}
+		}
+		for name, target := range map[string]*float64{
+			"dailySpendingLimit":   &row.Card.DailySpendingLimit,
+			"singleTransLimit":     &row.Card.SingleTransLimit,
+			"monthlySpendingLimit": &row.Card.MonthlySpendingLimit,
+		} {
+			if value := field(name); value != "" {
+				if *target, err = strconv.ParseFloat(value, 64); err != nil {
+					return nil, fmt.Errorf("invalid %s on CSV line %d: %w", name, line, err)
+				}
+			}
+		}
+		if value := field("threeDSecureAuthRequired"); value != "" {
+			if row.Card.ThreeDSecureAuthRequired, err = strconv.ParseBool(value); err != nil {
+				return nil, fmt.Errorf("invalid threeDSecureAuthRequired on CSV line %d: %w", line, err)
+			}
+		}
+
+		rows = append(rows, row)
+	}
+}
+
+// ReadBulkCardRowsJSONL reads bulk issuance rows from JSON Lines. Each line is either a
+// BulkCardRow, a BulkCardResult from an earlier report, or a bare card request with an optional
+// "ref" field. Rows that already have a cardId are skipped.
+func ReadBulkCardRowsJSONL(r io.Reader) ([]BulkCardRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var rows []BulkCardRow
+	for line := 1; scanner.Scan(); line++ {
+		data := strings.TrimSpace(scanner.Text())
+		if data == "" {
+			continue
+		}
+
+		var result struct {
+			BulkCardResult
+			CreateBudgetCardRequest
+		}
+		if err := json.Unmarshal([]byte(data), &result); err != nil {
+			return nil, fmt.Errorf("invalid JSON on line %d: %w", line, err)
+		}
+		if result.CardID != "" {
+			continue
+		}
+
+		row := result.BulkCardRow
+		if row.Card == (CreateBudgetCardRequest{}) {
+			row.Card = result.CreateBudgetCardRequest
+		}
+		if row.Row == 0 {
+			row.Row = line
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read JSON lines: %w", err)
+	}
+
+	return rows, nil
+}
+
+// BulkCardIssuer issues any number of cards through the batch creation endpoints. Rows are
+// split into chunks of at most 100 cards, which run with bounded concurrency. Chunks that fail
+// with a network error, 429 or 5xx response are retried with the same idempotency key, so a
+// retried chunk never issues its cards twice. The key is recorded in the report, and rows of a
+// chunk that still failed ambiguously keep it when the report is issued again.
+//
+//	rows, err := interlace.ReadBulkCardRowsCSV(file)
+//	issuer := interlace.NewBulkCardIssuer(client.Card)
+//	report, err := issuer.Issue(ctx, rows)
+//	report.WriteCSV(out) // feed out back into ReadBulkCardRowsCSV to retry the failures
+type BulkCardIssuer struct {
+	cards *CardClient
+
+	// ChunkSize is the number of cards per batch request, at most 100. Defaults to 100.
+	ChunkSize int
+	// Concurrency is the number of chunks in flight. Defaults to 4.
+	Concurrency int
+	// MaxRetries is the number of times a chunk is retried after a transient failure. Defaults to 3.
+	MaxRetries int
+	// RetryWait is the delay before the first retry of a chunk, doubled on every retry. Defaults to 1s.
+	RetryWait time.Duration
+
+	// OnProgress is called after every chunk with the number of rows processed so far
+	OnProgress func(processed, total int)
+}
+
+// NewBulkCardIssuer creates a bulk issuer with the default settings
+func NewBulkCardIssuer(cards *CardClient) *BulkCardIssuer {
+	return &BulkCardIssuer{
+		cards:       cards,
+		ChunkSize:   maxBatchCards,
+		Concurrency: 4,
+		MaxRetries:  3,
+		RetryWait:   time.Second,
+	}
+}
+
+// Issue issues a card for every row and reports the outcome of each. An error is only returned
+// when ctx is done; rows that were not processed are then reported as failed too.
+func (b *BulkCardIssuer) Issue(ctx context.Context, rows []BulkCardRow) (*BulkCardReport, error) {
+	results := make([]BulkCardResult, len(rows))
+	for i := range rows {
+		results[i].BulkCardRow = rows[i]
+		if results[i].Row == 0 {
+			results[i].Row = i + 1
+		}
+	}
+
+	chunks := b.chunks(rows)
+	concurrency := b.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		processed int
+		sem       = make(chan struct{}, concurrency)
+	)
+	for _, chunk := range chunks {
+		if ctx.Err() != nil {
+			break
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(chunk []int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			b.issueChunk(ctx, rows, chunk, results)
+
+			if b.OnProgress != nil {
+				mu.Lock()
+				processed += len(chunk)
+				b.OnProgress(processed, len(rows))
+				mu.Unlock()
+			}
+		}(chunk)
+	}
+	wg.Wait()
+
+	report := &BulkCardReport{Results: results}
+	for i := range results {
+		if results[i].Failed() {
+			if results[i].Error == "" {
+				results[i].Error = "not processed"
+				if ctx.Err() != nil {
+					results[i].Error = ctx.Err().Error()
+				}
+			}
+			report.Failed++
+		} else {
+			report.Succeeded++
+		}
+	}
+
+	return report, ctx.Err()
+}
+
+// chunks groups row indexes by card type into chunks of at most ChunkSize rows, keeping the rows
+// sharing an idempotency key in one chunk
+func (b *BulkCardIssuer) chunks(rows []BulkCardRow) [][]int {
+	size := b.ChunkSize
+	if size <= 0 || size > maxBatchCards {
+		size = maxBatchCards
+	}
+
+	// Rows of an earlier chunk are issued together again, under the key of that chunk
+	var chunks [][]int
+	keyed := make(map[string]int)
+	var prepaid, budget []int
+	for i, row := range rows {
+		if row.IdempotencyKey != "" {
+			if n, ok := keyed[row.IdempotencyKey]; ok {
+				chunks[n] = append(chunks[n], i)
+			} else {
+				keyed[row.IdempotencyKey] = len(chunks)
+				chunks = append(chunks, []int{i})
+			}
+			continue
+		}
+		if row.Card.BudgetID != "" {
+			budget = append(budget, i)
+		} else {
+			prepaid = append(prepaid, i)
+		}
+	}
+
+	for _, indexes := range [][]int{prepaid, budget} {
+		for len(indexes) > 0 {
+			n := size
+			if n > len(indexes) {
+				n = len(indexes)
+			}
+			chunks = append(chunks, indexes[:n])
+			indexes = indexes[n:]
+		}
+	}
+	return chunks
+}
+
+// issueChunk issues the cards of a chunk and records the outcome of each of its rows
+func (b *BulkCardIssuer) issueChunk(ctx context.Context, rows []BulkCardRow, chunk []int, results []BulkCardResult) {
+	budget := rows[chunk[0]].Card.BudgetID != ""
+	key := rows[chunk[0]].IdempotencyKey
+	if key == "" {
+		key = NewIdempotencyKey()
+	}
+	ctx = ContextWithIdempotencyKey(ctx, key)
+	for _, i := range chunk {
+		results[i].IdempotencyKey = key
+	}
+
+	var (
+		cards []Card
+		err   error
+		meta  ResponseMeta
+	)
+	wait := b.RetryWait
+	for attempt := 0; ; attempt++ {
+		meta = ResponseMeta{}
+		cards, err = b.createCards(ctx, rows, chunk, budget, &meta)
+		if err == nil || attempt >= b.MaxRetries || !isTransientBulkError(ctx, err, &meta) {
+			break
+		}
+		if sleepContext(ctx, wait) != nil {
+			break
+		}
+		wait *= 2
+	}
+
+	if err != nil {
+		// Rows definitely not issued get a new key when issued again
+		var reqErr *RequestError
+		ambiguous := errors.As(err, &reqErr) || meta.StatusCode >= 500
+		for _, i := range chunk {
+			results[i].Error = err.Error()
+			results[i].Ambiguous = ambiguous
+			if !ambiguous {
+				results[i].IdempotencyKey = ""
+			}
+		}
+		return
+	}
+
+	// The batch endpoints return the cards in request order
+	if len(cards) != len(chunk) {
+		for _, i := range chunk {
+			results[i].Error = fmt.Sprintf("batch returned %d cards for %d rows, check the issued cards before retrying", len(cards), len(chunk))
+			results[i].Ambiguous = true
+		}
+		return
+	}
+	for n, i := range chunk {
+		results[i].CardID = cards[n].ID
+	}
+}
+
+func (b *BulkCardIssuer) createCards(ctx context.Context, rows []BulkCardRow, chunk []int, budget bool, meta *ResponseMeta) ([]Card, error) {
+	if budget {
+		reqs := make([]CreateBudgetCardRequest, len(chunk))
+		for n, i := range chunk {
+			reqs[n] = rows[i].Card
+		}
+		resp, err := b.cards.BatchCreateBudgetCards(ctx, reqs, WithResponseMeta(meta))
+		if err != nil {
+			return nil, err
+		}
+		return resp.List, nil
+	}
+
+	reqs := make([]CreatePrepaidCardRequest, len(chunk))
+	for n, i := range chunk {
+		card := rows[i].Card
+		reqs[n] = CreatePrepaidCardRequest{
+			BinID:                    card.BinID,
+			CardholderID:             card.CardholderID,
+			ShippingAddressID:        card.ShippingAddressID,
+			Label:                    card.Label,
+			DailySpendingLimit:       card.DailySpendingLimit,
+			SingleTransLimit:         card.SingleTransLimit,
+			MonthlySpendingLimit:     card.MonthlySpendingLimit,
+			ThreeDSecureAuthRequired: card.ThreeDSecureAuthRequired,
+		}
+	}
+	resp, err := b.cards.BatchCreatePrepaidCards(ctx, reqs, WithResponseMeta(meta))
+	if err != nil {
+		return nil, err
+	}
+	return resp.List, nil
+}
+
+// isTransientBulkError reports whether a failed batch request may succeed when retried
+func isTransientBulkError(ctx context.Context, err error, meta *ResponseMeta) bool {
+	if ctx.Err() != nil || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		return true
+	}
+	return meta.StatusCode == http.StatusTooManyRequests || meta.StatusCode >= 500
+}
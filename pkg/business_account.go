@@ -185,7 +185,9 @@ type BusinessAccountTransactionListResponse struct {
 
 // GetBusinessAccounts retrieves all business accounts
 // GET /open-api/v3/business/accounts
-func (c *BusinessAccountClient) GetBusinessAccounts(ctx context.Context, legalEntityID string) ([]BusinessAccount, error) {
+func (c *BusinessAccountClient) GetBusinessAccounts(ctx context.Context, legalEntityID string, callOpts ...CallOption) ([]BusinessAccount, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	queryParams := url.Values{}
 	if legalEntityID != "" {
 		queryParams.Set("legalEntityId", legalEntityID)
@@ -209,7 +211,9 @@ func (c *BusinessAccountClient) GetBusinessAccounts(ctx context.Context, legalEn
 
 // GetAccountBalance retrieves the balance of a business account
 // GET /open-api/v3/business/account/{accountId}/balance
-func (c *BusinessAccountClient) GetAccountBalance(ctx context.Context, accountID string) (*BusinessAccountBalance, error) {
+func (c *BusinessAccountClient) GetAccountBalance(ctx context.Context, accountID string, callOpts ...CallOption) (*BusinessAccountBalance, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if accountID == "" {
 		return nil, fmt.Errorf("account ID is required")
 	}
@@ -231,7 +235,9 @@ func (c *BusinessAccountClient) GetAccountBalance(ctx context.Context, accountID
 
 // GetAccountTransactions retrieves transactions for a business account
 // GET /open-api/v3/business/account/transactions
-func (c *BusinessAccountClient) GetAccountTransactions(ctx context.Context, options *ListBusinessAccountTransactionsOptions) (*BusinessAccountTransactionListResponse, error) {
+func (c *BusinessAccountClient) GetAccountTransactions(ctx context.Context, options *ListBusinessAccountTransactionsOptions, callOpts ...CallOption) (*BusinessAccountTransactionListResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	var queryParams url.Values
 	if options != nil {
 		queryParams = url.Values{}
@@ -285,7 +291,9 @@ func (c *BusinessAccountClient) GetAccountTransactions(ctx context.Context, opti
 
 // CreateLegalEntity creates a new legal entity
 // POST /open-api/v3/business/legal-entity
-func (c *BusinessAccountClient) CreateLegalEntity(ctx context.Context, req *CreateLegalEntityRequest) (*LegalEntity, error) {
+func (c *BusinessAccountClient) CreateLegalEntity(ctx context.Context, req *CreateLegalEntityRequest, callOpts ...CallOption) (*LegalEntity, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if req == nil {
 		return nil, fmt.Errorf("legal entity request is required")
 	}
@@ -320,7 +328,9 @@ func (c *BusinessAccountClient) CreateLegalEntity(ctx context.Context, req *Crea
 
 // GetLegalEntity retrieves a legal entity by ID
 // GET /open-api/v3/business/legal-entity/{entityId}
-func (c *BusinessAccountClient) GetLegalEntity(ctx context.Context, entityID string) (*LegalEntity, error) {
+func (c *BusinessAccountClient) GetLegalEntity(ctx context.Context, entityID string, callOpts ...CallOption) (*LegalEntity, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if entityID == "" {
 		return nil, fmt.Errorf("entity ID is required")
 	}
@@ -342,7 +352,9 @@ func (c *BusinessAccountClient) GetLegalEntity(ctx context.Context, entityID str
 
 // UpdateLegalEntity updates an existing legal entity
 // PUT /open-api/v3/business/legal-entity/{entityId}
-func (c *BusinessAccountClient) UpdateLegalEntity(ctx context.Context, entityID string, req *UpdateLegalEntityRequest) (*LegalEntity, error) {
+func (c *BusinessAccountClient) UpdateLegalEntity(ctx context.Context, entityID string, req *UpdateLegalEntityRequest, callOpts ...CallOption) (*LegalEntity, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if entityID == "" {
 		return nil, fmt.Errorf("entity ID is required")
 	}
@@ -368,7 +380,9 @@ func (c *BusinessAccountClient) UpdateLegalEntity(ctx context.Context, entityID
 
 // CreateVirtualAccount creates a virtual bank account for a legal entity
 // POST /open-api/v3/business/virtual-account
-func (c *BusinessAccountClient) CreateVirtualAccount(ctx context.Context, req *CreateVirtualAccountRequest) (*BusinessAccount, error) {
+func (c *BusinessAccountClient) CreateVirtualAccount(ctx context.Context, req *CreateVirtualAccountRequest, callOpts ...CallOption) (*BusinessAccount, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if req == nil {
 		return nil, fmt.Errorf("virtual account request is required")
 	}
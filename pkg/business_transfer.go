@@ -78,7 +78,9 @@ type BusinessTransferListResponse struct {
 
 // CreateIntraAccountTransfer creates an intra-account business transfer
 // POST /open-api/v3/business/transfer/internal
-func (c *BusinessTransferClient) CreateIntraAccountTransfer(ctx context.Context, req *IntraAccountTransferRequest) (*BusinessTransfer, error) {
+func (c *BusinessTransferClient) CreateIntraAccountTransfer(ctx context.Context, req *IntraAccountTransferRequest, callOpts ...CallOption) (*BusinessTransfer, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if req == nil {
 		return nil, fmt.Errorf("transfer request is required")
 	}
@@ -119,7 +121,9 @@ func (c *BusinessTransferClient) CreateIntraAccountTransfer(ctx context.Context,
 
 // CreateDifferentAccountTransfer creates a different-account business transfer
 // POST /open-api/v3/business/transfer/external
-func (c *BusinessTransferClient) CreateDifferentAccountTransfer(ctx context.Context, req *DifferentAccountTransferRequest) (*BusinessTransfer, error) {
+func (c *BusinessTransferClient) CreateDifferentAccountTransfer(ctx context.Context, req *DifferentAccountTransferRequest, callOpts ...CallOption) (*BusinessTransfer, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if req == nil {
 		return nil, fmt.Errorf("transfer request is required")
 	}
@@ -157,7 +161,9 @@ func (c *BusinessTransferClient) CreateDifferentAccountTransfer(ctx context.Cont
 
 // ListBusinessTransfers retrieves all business transfers with optional filtering
 // GET /open-api/v3/business/transfers
-func (c *BusinessTransferClient) ListBusinessTransfers(ctx context.Context, options *ListBusinessTransfersOptions) (*BusinessTransferListResponse, error) {
+func (c *BusinessTransferClient) ListBusinessTransfers(ctx context.Context, options *ListBusinessTransfersOptions, callOpts ...CallOption) (*BusinessTransferListResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	var queryParams url.Values
 	if options != nil {
 		queryParams = url.Values{}
@@ -202,3 +208,66 @@ func (c *BusinessTransferClient) ListBusinessTransfers(ctx context.Context, opti
 
 	return &resp, nil
 }
+
+// CreateIntraAccountTransferOperation creates an intra-account transfer and returns an
+// operation that tracks it until it completes or fails
+func (c *BusinessTransferClient) CreateIntraAccountTransferOperation(ctx context.Context, req *IntraAccountTransferRequest, callOpts ...CallOption) (*Operation[BusinessTransfer], error) {
+	transfer, err := c.CreateIntraAccountTransfer(ctx, req, callOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return newOperation(transfer.TransferID, transfer, c.transferOperationSpec(req.AccountID, transfer.TransferID)), nil
+}
+
+// CreateDifferentAccountTransferOperation creates a transfer between accounts and returns an
+// operation that tracks it until it completes or fails
+func (c *BusinessTransferClient) CreateDifferentAccountTransferOperation(ctx context.Context, req *DifferentAccountTransferRequest, callOpts ...CallOption) (*Operation[BusinessTransfer], error) {
+	transfer, err := c.CreateDifferentAccountTransfer(ctx, req, callOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return newOperation(transfer.TransferID, transfer, c.transferOperationSpec(req.FromAccountID, transfer.TransferID)), nil
+}
+
+// BusinessTransferOperation returns an operation that tracks an existing business transfer of
+// the given account
+func (c *BusinessTransferClient) BusinessTransferOperation(accountID, transferID string) *Operation[BusinessTransfer] {
+	return newOperation(transferID, nil, c.transferOperationSpec(accountID, transferID))
+}
+
+func (c *BusinessTransferClient) transferOperationSpec(accountID, transferID string) operationSpec[BusinessTransfer] {
+	return operationSpec[BusinessTransfer]{
+		poll: func(ctx context.Context) (*BusinessTransfer, error) {
+			return c.findBusinessTransfer(ctx, accountID, transferID)
+		},
+		state: func(t *BusinessTransfer) (OperationState, string, string) {
+			return statusState(t.Status), t.Status, t.FailedReason
+		},
+		eventKeys: []string{"transferId", "id"},
+	}
+}
+
+// findBusinessTransfer looks up a transfer in the transfer list, since the API has no endpoint
+// to get a single transfer
+func (c *BusinessTransferClient) findBusinessTransfer(ctx context.Context, accountID, transferID string) (*BusinessTransfer, error) {
+	options := &ListBusinessTransfersOptions{AccountID: accountID, Limit: 100}
+	for page := 1; ; page++ {
+		options.Page = page
+		resp, err := c.ListBusinessTransfers(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range resp.Transfers {
+			if resp.Transfers[i].TransferID == transferID {
+				return &resp.Transfers[i], nil
+			}
+		}
+
+		if len(resp.Transfers) == 0 || page*options.Limit >= resp.TotalCount {
+			return nil, fmt.Errorf("business transfer %s not found", transferID)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package interlace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Cache is the storage backend of the response cache. Implementations must be safe for
+// concurrent use. Keys start with the endpoint path, so DeletePrefix with a path removes every
+// cached response of that endpoint.
+type Cache interface {
+	// Get returns the entry stored under key, or nil when there is none
+	Get(ctx context.Context, key string) (*CacheEntry, error)
+	Set(ctx context.Context, key string, entry *CacheEntry) error
+	// DeletePrefix removes every entry whose key starts with prefix. An empty prefix clears the cache.
+	DeletePrefix(ctx context.Context, prefix string) error
+}
+
+// CacheEntry is a cached response body
+type CacheEntry struct {
+	Body     []byte
+	StoredAt time.Time
+	// ExpiresAt is when the entry can no longer be served, even as stale. Backends may evict
+	// the entry from then on.
+	ExpiresAt time.Time
+}
+
+// CacheConfig enables caching of slow-changing reference data: card BINs, BIN recommendations,
+// consumption scenarios, physical card fees and convert currency pairs.
+type CacheConfig struct {
+	// Backend stores the cached responses. Defaults to NewMemoryCache().
+	Backend Cache
+	// TTL is how long a response is served from the cache. Defaults to 10 minutes.
+	TTL time.Duration
+	// StaleWhileRevalidate is how long after TTL an expired response is still served while it
+	// is refreshed in the background. Zero disables stale responses.
+	StaleWhileRevalidate time.Duration
+}
+
+// CacheStats holds counters of the response cache
+type CacheStats struct {
+	Hits          int64 // Fresh responses served from the cache
+	StaleHits     int64 // Stale responses served while being revalidated
+	Misses        int64
+	Revalidations int64 // Background refreshes of stale responses
+	Errors        int64 // Backend errors and failed revalidations
+}
+
+// MemoryCache is an in-memory Cache
+type MemoryCache struct {
+	mu      sync.Mutex
+	entries map[string]*CacheEntry
+}
+
+// NewMemoryCache creates an empty in-memory cache
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{
+		entries: make(map[string]*CacheEntry),
+	}
+}
+
+// Get implements Cache
+func (m *MemoryCache) Get(ctx context.Context, key string) (*CacheEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	if !entry.ExpiresAt.IsZero() && time.Now().After(entry.ExpiresAt) {
+		delete(m.entries, key)
+		return nil, nil
+	}
+	return entry, nil
+}
+
+// Set implements Cache
+func (m *MemoryCache) Set(ctx context.Context, key string, entry *CacheEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries[key] = entry
+	return nil
+}
+
+// DeletePrefix implements Cache
+func (m *MemoryCache) DeletePrefix(ctx context.Context, prefix string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key := range m.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(m.entries, key)
+		}
+	}
+	return nil
+}
+
+// responseCache serves cacheable GET requests from a Cache backend
+type responseCache struct {
+	backend              Cache
+	ttl                  time.Duration
+	staleWhileRevalidate time.Duration
+
+	hits          atomic.Int64
+	staleHits     atomic.Int64
+	misses        atomic.Int64
+	revalidations atomic.Int64
+	errors        atomic.Int64
+
+	mu           sync.Mutex
+	revalidating map[string]bool
+}
+
+func newResponseCache(config CacheConfig) *responseCache {
+	if config.Backend == nil {
+		config.Backend = NewMemoryCache()
+	}
+	if config.TTL <= 0 {
+		config.TTL = 10 * time.Minute
+	}
+
+	return &responseCache{
+		backend:              config.Backend,
+		ttl:                  config.TTL,
+		staleWhileRevalidate: config.StaleWhileRevalidate,
+		revalidating:         make(map[string]bool),
+	}
+}
+
+func (rc *responseCache) stats() CacheStats {
+	return CacheStats{
+		Hits:          rc.hits.Load(),
+		StaleHits:     rc.staleHits.Load(),
+		Misses:        rc.misses.Load(),
+		Revalidations: rc.revalidations.Load(),
+		Errors:        rc.errors.Load(),
+	}
+}
+
+// cacheKey identifies a cached response. Responses fetched on behalf of different sub-accounts
+// are cached separately.
+func cacheKey(opts *RequestOptions, callOpts *callOptions) string {
+	key := opts.Endpoint
+	if len(opts.QueryParams) > 0 {
+		separator := "?"
+		if strings.Contains(key, "?") {
+			separator = "&"
+		}
+		key += separator + opts.QueryParams.Encode()
+	}
+	if callOpts.onBehalfOf != "" {
+		key += "#" + callOpts.onBehalfOf
+	}
+	return key
+}
+
+// doCachedRequest serves a cacheable request from the cache, falling back to the API
+func (c *HTTPClient) doCachedRequest(ctx context.Context, opts *RequestOptions, callOpts *callOptions, result interface{}) error {
+	rc := c.cache
+	key := cacheKey(opts, callOpts)
+
+	var entry *CacheEntry
+	if !callOpts.skipCache {
+		var err error
+		if entry, err = rc.backend.Get(ctx, key); err != nil {
+			rc.errors.Add(1)
+			entry = nil
+		}
+	}
+
+	if entry != nil {
+		age := time.Since(entry.StoredAt)
+		switch {
+		case age < rc.ttl:
+			rc.hits.Add(1)
+			return c.serveCached(entry, callOpts, result)
+		case age < rc.ttl+rc.staleWhileRevalidate:
+			rc.staleHits.Add(1)
+			c.revalidate(ctx, opts, key)
+			return c.serveCached(entry, callOpts, result)
+		}
+	}
+
+	rc.misses.Add(1)
+	body, err := c.fetchForCache(ctx, opts, key)
+	if err != nil {
+		return err
+	}
+	if result != nil {
+		if err := json.Unmarshal(body, result); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+// fetchForCache performs the request and stores its response body in the cache
+func (c *HTTPClient) fetchForCache(ctx context.Context, opts *RequestOptions, key string) ([]byte, error) {
+	var body json.RawMessage
+	if err := c.doRequest(ctx, opts, &body); err != nil {
+		return nil, err
+	}
+
+	rc := c.cache
+	now := time.Now()
+	entry := &CacheEntry{
+		Body:      body,
+		StoredAt:  now,
+		ExpiresAt: now.Add(rc.ttl + rc.staleWhileRevalidate),
+	}
+	if err := rc.backend.Set(ctx, key, entry); err != nil {
+		rc.errors.Add(1)
+	}
+
+	return body, nil
+}
+
+// revalidate refreshes a stale entry in the background, at most once at a time per key
+func (c *HTTPClient) revalidate(ctx context.Context, opts *RequestOptions, key string) {
+	rc := c.cache
+
+	rc.mu.Lock()
+	if rc.revalidating[key] {
+		rc.mu.Unlock()
+		return
+	}
+	rc.revalidating[key] = true
+	rc.mu.Unlock()
+
+	// The refresh outlives the call, so it must not be cancelled with it nor record into the
+	// caller's response metadata
+	ctx = ContextWithCallOptions(context.WithoutCancel(ctx), WithResponseMeta(nil))
+	optsCopy := *opts
+
+	go func() {
+		defer func() {
+			rc.mu.Lock()
+			delete(rc.revalidating, key)
+			rc.mu.Unlock()
+		}()
+
+		rc.revalidations.Add(1)
+		if _, err := c.fetchForCache(ctx, &optsCopy, key); err != nil {
+			rc.errors.Add(1)
+		}
+	}()
+}
+
+func (c *HTTPClient) serveCached(entry *CacheEntry, callOpts *callOptions, result interface{}) error {
+	if meta := callOpts.responseMeta; meta != nil {
+		*meta = ResponseMeta{RawBody: entry.Body, Cached: true}
+	}
+	if result != nil {
+		if err := json.Unmarshal(entry.Body, result); err != nil {
+			return fmt.Errorf("failed to decode cached response: %w", err)
+		}
+	}
+	return nil
+}
+
+// CacheStats returns the counters of the response cache. All counters are zero when caching is
+// disabled.
+func (c *HTTPClient) CacheStats() CacheStats {
+	if c.cache == nil {
+		return CacheStats{}
+	}
+	return c.cache.stats()
+}
+
+// InvalidateCache removes the cached responses of every endpoint whose path starts with
+// endpoint, e.g. "/open-api/v3/card/bins". An empty endpoint clears the whole cache.
+func (c *HTTPClient) InvalidateCache(ctx context.Context, endpoint string) error {
+	if c.cache == nil {
+		return nil
+	}
+	return c.cache.backend.DeletePrefix(ctx, endpoint)
+}
+
+// CacheStats returns the counters of the response cache
+func (c *Client) CacheStats() CacheStats {
+	return c.httpClient.CacheStats()
+}
+
+// InvalidateCache removes cached reference data. See HTTPClient.InvalidateCache.
+func (c *Client) InvalidateCache(ctx context.Context, endpoint string) error {
+	return c.httpClient.InvalidateCache(ctx, endpoint)
+}
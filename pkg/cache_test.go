@@ -0,0 +1,179 @@
+package interlace
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingServer answers every request with the number of requests it received for the path
+type countingServer struct {
+	mu       sync.Mutex
+	requests map[string]int
+	accounts []string
+}
+
+func (s *countingServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.requests == nil {
+		s.requests = make(map[string]int)
+	}
+	s.requests[r.URL.Path]++
+	s.accounts = append(s.accounts, r.Header.Get(OnBehalfOfHeader))
+	fmt.Fprintf(w, `{"n":%d}`, s.requests[r.URL.Path])
+}
+
+func (s *countingServer) count(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[path]
+}
+
+const testFeesEndpoint = "/open-api/v3/physical-card/fees"
+
+func newTestCachingClient(t *testing.T, server *countingServer, backend *MemoryCache) *HTTPClient {
+	return newTestHTTPClient(t, server, func(cfg *Config) {
+		cfg.Cache = &CacheConfig{Backend: backend, TTL: time.Minute, StaleWhileRevalidate: time.Minute}
+	})
+}
+
+// cachedGet performs a cacheable GET and returns the request number the response came from
+func cachedGet(t *testing.T, client *HTTPClient, endpoint string, callOpts ...CallOption) int {
+	var result struct {
+		N int `json:"n"`
+	}
+	ctx := ContextWithCallOptions(context.Background(), callOpts...)
+	require.NoError(t, client.DoRequest(ctx, &RequestOptions{Method: http.MethodGet, Endpoint: endpoint, Cacheable: true}, &result))
+	return result.N
+}
+
+// age moves every cached entry back in time
+func age(backend *MemoryCache, d time.Duration) {
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	for _, entry := range backend.entries {
+		entry.StoredAt = entry.StoredAt.Add(-d)
+		entry.ExpiresAt = entry.ExpiresAt.Add(-d)
+	}
+}
+
+func TestResponseCacheTTL(t *testing.T) {
+	server := &countingServer{}
+	backend := NewMemoryCache()
+	client := newTestCachingClient(t, server, backend)
+
+	assert.Equal(t, 1, cachedGet(t, client, testFeesEndpoint))
+	assert.Equal(t, 1, cachedGet(t, client, testFeesEndpoint))
+
+	var meta ResponseMeta
+	assert.Equal(t, 1, cachedGet(t, client, testFeesEndpoint, WithResponseMeta(&meta)))
+	assert.True(t, meta.Cached)
+
+	assert.Equal(t, 2, cachedGet(t, client, testFeesEndpoint, WithoutCache()), "WithoutCache fetches and stores")
+	assert.Equal(t, 2, cachedGet(t, client, testFeesEndpoint))
+
+	// Past TTL and the stale window the entry is gone
+	age(backend, 2*time.Minute+time.Second)
+	assert.Equal(t, 3, cachedGet(t, client, testFeesEndpoint))
+
+	assert.Equal(t, CacheStats{Hits: 3, Misses: 3}, client.CacheStats())
+	assert.Equal(t, 3, server.count(testFeesEndpoint))
+}
+
+func TestResponseCacheStaleWhileRevalidate(t *testing.T) {
+	server := &countingServer{}
+	backend := NewMemoryCache()
+	client := newTestCachingClient(t, server, backend)
+
+	assert.Equal(t, 1, cachedGet(t, client, testFeesEndpoint))
+	age(backend, 90*time.Second)
+
+	// The stale response is served while it is refreshed in the background
+	assert.Equal(t, 1, cachedGet(t, client, testFeesEndpoint))
+	assert.Eventually(t, func() bool {
+		client.cache.mu.Lock()
+		defer client.cache.mu.Unlock()
+		return server.count(testFeesEndpoint) == 2 && len(client.cache.revalidating) == 0
+	}, time.Second, time.Millisecond)
+
+	assert.Equal(t, 2, cachedGet(t, client, testFeesEndpoint))
+	assert.Equal(t, CacheStats{Hits: 1, StaleHits: 1, Misses: 1, Revalidations: 1}, client.CacheStats())
+}
+
+func TestResponseCacheInvalidation(t *testing.T) {
+	server := &countingServer{}
+	client := newTestCachingClient(t, server, NewMemoryCache())
+	bins := "/open-api/v3/card/bins?accountId=acc"
+
+	assert.Equal(t, 1, cachedGet(t, client, testFeesEndpoint))
+	assert.Equal(t, 1, cachedGet(t, client, bins))
+
+	// Mutations are not cached nor served from the cache
+	require.NoError(t, client.DoRequest(context.Background(), &RequestOptions{Method: http.MethodPost,
+		Endpoint: testFeesEndpoint, Cacheable: true}, nil))
+	assert.Equal(t, 2, server.count(testFeesEndpoint))
+	assert.Equal(t, 1, cachedGet(t, client, testFeesEndpoint))
+
+	// After a change, invalidating an endpoint refetches it only
+	require.NoError(t, client.InvalidateCache(context.Background(), "/open-api/v3/card/bins"))
+	assert.Equal(t, 1, cachedGet(t, client, testFeesEndpoint))
+	assert.Equal(t, 2, cachedGet(t, client, bins))
+
+	require.NoError(t, client.InvalidateCache(context.Background(), ""))
+	assert.Equal(t, 3, cachedGet(t, client, testFeesEndpoint))
+	assert.Equal(t, 3, cachedGet(t, client, bins))
+}
+
+func TestResponseCacheOnBehalfOf(t *testing.T) {
+	server := &countingServer{}
+	client := newTestCachingClient(t, server, NewMemoryCache())
+
+	assert.Equal(t, 1, cachedGet(t, client, testFeesEndpoint))
+	assert.Equal(t, 2, cachedGet(t, client, testFeesEndpoint, WithOnBehalfOf("sub-1")))
+	assert.Equal(t, 3, cachedGet(t, client, testFeesEndpoint, WithOnBehalfOf("sub-2")))
+	assert.Equal(t, 2, cachedGet(t, client, testFeesEndpoint, WithOnBehalfOf("sub-1")))
+	assert.Equal(t, 1, cachedGet(t, client, testFeesEndpoint))
+	assert.Equal(t, []string{"", "sub-1", "sub-2"}, server.accounts)
+}
+
+func TestCacheKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     RequestOptions
+		callOpts callOptions
+		want     string
+	}{
+		{name: "path", opts: RequestOptions{Endpoint: "/fees"}, want: "/fees"},
+		{
+			name: "query parameters",
+			opts: RequestOptions{Endpoint: "/bins", QueryParams: url.Values{"b": {"2"}, "a": {"1"}}},
+			want: "/bins?a=1&b=2",
+		},
+		{
+			name: "query in the endpoint",
+			opts: RequestOptions{Endpoint: "/bins?accountId=acc", QueryParams: url.Values{"page": {"1"}}},
+			want: "/bins?accountId=acc&page=1",
+		},
+		{
+			name:     "on behalf of a sub-account",
+			opts:     RequestOptions{Endpoint: "/bins?accountId=acc"},
+			callOpts: callOptions{onBehalfOf: "sub-1"},
+			want:     "/bins?accountId=acc#sub-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, cacheKey(&tt.opts, &tt.callOpts))
+		})
+	}
+}
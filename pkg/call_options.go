@@ -0,0 +1,156 @@
+package interlace
+
+import (
+	"context"
+	"time"
+)
+
+// OnBehalfOfHeader is the HTTP header that makes a request act for a specific sub-account
+const OnBehalfOfHeader = "x-on-behalf-of"
+
+// CallOption customizes a single API call. Every sub-client method accepts call options as its
+// trailing arguments; they can also be carried in a context with ContextWithCallOptions.
+//
+//	card, err := client.Card.FreezeCard(ctx, cardID,
+//		interlace.WithTimeout(5*time.Second),
+//		interlace.WithOnBehalfOf(subAccountID),
+//	)
+type CallOption func(*callOptions)
+
+// callOptions holds the settings collected from CallOptions
+type callOptions struct {
+	timeout        time.Duration
+	headers        map[string]string
+	idempotencyKey string
+	maxRetries     *int
+	onBehalfOf     string
+	responseMeta   *ResponseMeta
+	skipCache      bool
+	requester      string
+	approvalID     string
+}
+
+// WithTimeout bounds the duration of each request made by the call, including retries
+func WithTimeout(timeout time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithHeader adds an HTTP header to the request, overriding headers set by the SDK
+func WithHeader(key, value string) CallOption {
+	return func(o *callOptions) {
+		if o.headers == nil {
+			o.headers = make(map[string]string)
+		}
+		o.headers[key] = value
+	}
+}
+
+// WithIdempotencyKey sets the idempotency key of a mutating request instead of a generated one.
+// A merchant reference already set on the request body takes precedence.
+func WithIdempotencyKey(key string) CallOption {
+	return func(o *callOptions) {
+		o.idempotencyKey = key
+	}
+}
+
+// WithMaxRetries overrides Config.MaxRetries for the call
+func WithMaxRetries(maxRetries int) CallOption {
+	return func(o *callOptions) {
+		o.maxRetries = &maxRetries
+	}
+}
+
+// WithOnBehalfOf makes the request act for the given sub-account
+func WithOnBehalfOf(accountID string) CallOption {
+	return func(o *callOptions) {
+		o.onBehalfOf = accountID
+	}
+}
+
+// WithResponseMeta records the response metadata of the call into meta
+func WithResponseMeta(meta *ResponseMeta) CallOption {
+	return func(o *callOptions) {
+		o.responseMeta = meta
+	}
+}
+
+// WithoutCache fetches reference data from the API instead of the cache. The fresh response
+// still replaces the cached one.
+func WithoutCache() CallOption {
+	return func(o *callOptions) {
+		o.skipCache = true
+	}
+}
+
+// WithRequester identifies the user making the call in the audit trail of the approvals it
+// creates (see ApprovalConfig). It is required for calls that need approval, and the requester
+// cannot approve their own requests.
+func WithRequester(user string) CallOption {
+	return func(o *callOptions) {
+		o.requester = user
+	}
+}
+
+// withApprovalID marks the call as the execution of an approved request, which is not captured
+// for approval again
+func withApprovalID(id string) CallOption {
+	return func(o *callOptions) {
+		o.approvalID = id
+	}
+}
+
+// withoutPerCallOptions drops the options that apply to a single request. Helpers fanning out
+// many requests add it: a shared idempotency key would make the API deduplicate all their
+// requests into the first one, and a shared ResponseMeta would be written concurrently.
+func withoutPerCallOptions() CallOption {
+	return func(o *callOptions) {
+		o.idempotencyKey = ""
+		o.responseMeta = nil
+	}
+}
+
+// fanOutContext returns a context carrying callOpts without the per-call options, for helpers
+// making many requests
+func fanOutContext(ctx context.Context, callOpts []CallOption) context.Context {
+	return ContextWithCallOptions(ContextWithCallOptions(ctx, callOpts...), withoutPerCallOptions())
+}
+
+type callOptionsContextKey struct{}
+
+// ContextWithCallOptions returns a context carrying the given call options on top of any options
+// already present in ctx. Options passed directly to a method are applied after those from the
+// context.
+func ContextWithCallOptions(ctx context.Context, opts ...CallOption) context.Context {
+	if len(opts) == 0 {
+		return ctx
+	}
+
+	merged := callOptionsFromContext(ctx).clone()
+	for _, opt := range opts {
+		opt(merged)
+	}
+
+	return context.WithValue(ctx, callOptionsContextKey{}, merged)
+}
+
+// callOptionsFromContext returns the call options carried by the context. The result is never
+// nil and must not be modified.
+func callOptionsFromContext(ctx context.Context) *callOptions {
+	if opts, ok := ctx.Value(callOptionsContextKey{}).(*callOptions); ok {
+		return opts
+	}
+	return &callOptions{}
+}
+
+func (o *callOptions) clone() *callOptions {
+	cloned := *o
+	if o.headers != nil {
+		cloned.headers = make(map[string]string, len(o.headers))
+		for key, value := range o.headers {
+			cloned.headers[key] = value
+		}
+	}
+	return &cloned
+}
@@ -0,0 +1,19 @@
+package interlace
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFanOutContextDropsResponseMeta(t *testing.T) {
+	var meta ResponseMeta
+	ctx := fanOutContext(context.Background(), []CallOption{WithResponseMeta(&meta), WithIdempotencyKey("key-1"),
+		WithOnBehalfOf("sub-1")})
+
+	callOpts := callOptionsFromContext(ctx)
+	assert.Nil(t, callOpts.responseMeta)
+	assert.Empty(t, callOpts.idempotencyKey)
+	assert.Equal(t, "sub-1", callOpts.onBehalfOf)
+}
@@ -8,12 +8,15 @@ import (
 
 // CardListOptions contains the query parameters for listing cards
 type CardListOptions struct {
-	AccountID  string `json:"accountId,omitempty"`
-	CardStatus string `json:"cardStatus,omitempty"`
-	CardType   string `json:"cardType,omitempty"`
-	IsActive   *bool  `json:"isActive,omitempty"`
-	Limit      int    `json:"limit,omitempty"`
-	Page       int    `json:"page,omitempty"`
+	AccountID    string `json:"accountId,omitempty"`
+	CardholderID string `json:"cardholderId,omitempty"`
+	BudgetID     string `json:"budgetId,omitempty"`
+	CardBIN      string `json:"cardBin,omitempty"`
+	CardStatus   string `json:"cardStatus,omitempty"`
+	CardType     string `json:"cardType,omitempty"`
+	IsActive     *bool  `json:"isActive,omitempty"`
+	Limit        int    `json:"limit,omitempty"`
+	Page         int    `json:"page,omitempty"`
 }
 
 // CardClient handles card-related operations
@@ -30,13 +33,24 @@ func NewCardClient(httpClient *HTTPClient) *CardClient {
 
 // ListCards retrieves a list of cards with optional filtering
 // GET /open-api/v3/card-list
-func (c *CardClient) ListCards(ctx context.Context, options *CardListOptions) (*CardListResponse, error) {
+func (c *CardClient) ListCards(ctx context.Context, options *CardListOptions, callOpts ...CallOption) (*CardListResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	queryParams := url.Values{}
 	
 	if options != nil {
 		if options.AccountID != "" {
 			queryParams.Set("accountId", options.AccountID)
 		}
+		if options.CardholderID != "" {
+			queryParams.Set("cardholderId", options.CardholderID)
+		}
+		if options.BudgetID != "" {
+			queryParams.Set("budgetId", options.BudgetID)
+		}
+		if options.CardBIN != "" {
+			queryParams.Set("cardBin", options.CardBIN)
+		}
 		if options.CardStatus != "" {
 			queryParams.Set("cardStatus", options.CardStatus)
 		}
@@ -76,7 +90,9 @@ func (c *CardClient) ListCards(ctx context.Context, options *CardListOptions) (*
 
 // GetCardPrivateInfo retrieves sensitive card information (encrypted)
 // GET /open-api/v3/cards/{id}
-func (c *CardClient) GetCardPrivateInfo(ctx context.Context, cardID string) (*CardPrivateInfo, error) {
+func (c *CardClient) GetCardPrivateInfo(ctx context.Context, cardID string, callOpts ...CallOption) (*CardPrivateInfo, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if cardID == "" {
 		return nil, fmt.Errorf("card ID cannot be empty")
 	}
@@ -98,7 +114,9 @@ func (c *CardClient) GetCardPrivateInfo(ctx context.Context, cardID string) (*Ca
 
 // RemoveCard deletes a card
 // DELETE /open-api/v3/cards/{id}
-func (c *CardClient) RemoveCard(ctx context.Context, cardID string) (*CardRemoveResponse, error) {
+func (c *CardClient) RemoveCard(ctx context.Context, cardID string, callOpts ...CallOption) (*CardRemoveResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if cardID == "" {
 		return nil, fmt.Errorf("card ID cannot be empty")
 	}
@@ -120,7 +138,9 @@ func (c *CardClient) RemoveCard(ctx context.Context, cardID string) (*CardRemove
 
 // FreezeCard freezes a card to prevent transactions
 // POST /open-api/v3/cards/{id}/freeze
-func (c *CardClient) FreezeCard(ctx context.Context, cardID string) (*Card, error) {
+func (c *CardClient) FreezeCard(ctx context.Context, cardID string, callOpts ...CallOption) (*Card, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if cardID == "" {
 		return nil, fmt.Errorf("card ID cannot be empty")
 	}
@@ -142,7 +162,9 @@ func (c *CardClient) FreezeCard(ctx context.Context, cardID string) (*Card, erro
 
 // UnfreezeCard unfreezes a card to allow transactions
 // POST /open-api/v3/cards/{id}/unfreeze
-func (c *CardClient) UnfreezeCard(ctx context.Context, cardID string) (*Card, error) {
+func (c *CardClient) UnfreezeCard(ctx context.Context, cardID string, callOpts ...CallOption) (*Card, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if cardID == "" {
 		return nil, fmt.Errorf("card ID cannot be empty")
 	}
@@ -170,7 +192,9 @@ type VelocityControlRequest struct {
 
 // SetCardVelocityControl sets transaction limits for a card
 // PUT /open-api/v3/cards/{id}/velocity-control
-func (c *CardClient) SetCardVelocityControl(ctx context.Context, cardID string, req *VelocityControlRequest) (*Card, error) {
+func (c *CardClient) SetCardVelocityControl(ctx context.Context, cardID string, req *VelocityControlRequest, callOpts ...CallOption) (*Card, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if cardID == "" {
 		return nil, fmt.Errorf("card ID cannot be empty")
 	}
@@ -205,7 +229,9 @@ type CreatePrepaidCardRequest struct {
 
 // CreatePrepaidCard creates a single prepaid card synchronously
 // POST /open-api/v3/prepaid-card
-func (c *CardClient) CreatePrepaidCard(ctx context.Context, req *CreatePrepaidCardRequest) (*Card, error) {
+func (c *CardClient) CreatePrepaidCard(ctx context.Context, req *CreatePrepaidCardRequest, callOpts ...CallOption) (*Card, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if req.BinID == "" {
 		return nil, fmt.Errorf("binId is required")
 	}
@@ -242,7 +268,9 @@ type BatchCreatePrepaidCardsResponse struct {
 
 // BatchCreatePrepaidCards creates multiple prepaid cards at once (max 100)
 // POST /open-api/v3/prepaid-cards
-func (c *CardClient) BatchCreatePrepaidCards(ctx context.Context, cards []CreatePrepaidCardRequest) (*BatchCreatePrepaidCardsResponse, error) {
+func (c *CardClient) BatchCreatePrepaidCards(ctx context.Context, cards []CreatePrepaidCardRequest, callOpts ...CallOption) (*BatchCreatePrepaidCardsResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if len(cards) == 0 {
 		return nil, fmt.Errorf("cards list cannot be empty")
 	}
@@ -285,7 +313,9 @@ type CreateBudgetCardRequest struct {
 
 // CreateBudgetCard creates a single budget card synchronously
 // POST /open-api/v3/budget-card
-func (c *CardClient) CreateBudgetCard(ctx context.Context, req *CreateBudgetCardRequest) (*Card, error) {
+func (c *CardClient) CreateBudgetCard(ctx context.Context, req *CreateBudgetCardRequest, callOpts ...CallOption) (*Card, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if req.BinID == "" {
 		return nil, fmt.Errorf("binId is required")
 	}
@@ -325,7 +355,9 @@ type BatchCreateBudgetCardsResponse struct {
 
 // BatchCreateBudgetCards creates multiple budget cards at once (max 100)
 // POST /open-api/v3/budget-cards
-func (c *CardClient) BatchCreateBudgetCards(ctx context.Context, cards []CreateBudgetCardRequest) (*BatchCreateBudgetCardsResponse, error) {
+func (c *CardClient) BatchCreateBudgetCards(ctx context.Context, cards []CreateBudgetCardRequest, callOpts ...CallOption) (*BatchCreateBudgetCardsResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if len(cards) == 0 {
 		return nil, fmt.Errorf("cards list cannot be empty")
 	}
@@ -368,7 +400,9 @@ type CardSummary struct {
 
 // GetCardSummary retrieves the summary information for a card
 // GET /open-api/v3/cards/{id}/card-summary
-func (c *CardClient) GetCardSummary(ctx context.Context, cardID string) (*CardSummary, error) {
+func (c *CardClient) GetCardSummary(ctx context.Context, cardID string, callOpts ...CallOption) (*CardSummary, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if cardID == "" {
 		return nil, fmt.Errorf("card ID cannot be empty")
 	}
@@ -400,7 +434,9 @@ type UpdateCardRequest struct {
 
 // UpdateCard updates card information
 // PUT /open-api/v3/card
-func (c *CardClient) UpdateCard(ctx context.Context, req *UpdateCardRequest) (*Card, error) {
+func (c *CardClient) UpdateCard(ctx context.Context, req *UpdateCardRequest, callOpts ...CallOption) (*Card, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if req.CardID == "" {
 		return nil, fmt.Errorf("cardId is required")
 	}
@@ -428,7 +464,9 @@ type BindWalletRequest struct {
 
 // BindWallet binds a wallet to a card for spending (supports USDC and USDT)
 // POST /open-api/v3/cards/{id}/bind-wallet
-func (c *CardClient) BindWallet(ctx context.Context, cardID string, req *BindWalletRequest) (*Card, error) {
+func (c *CardClient) BindWallet(ctx context.Context, cardID string, req *BindWalletRequest, callOpts ...CallOption) (*Card, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if cardID == "" {
 		return nil, fmt.Errorf("card ID cannot be empty")
 	}
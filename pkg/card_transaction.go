@@ -44,7 +44,9 @@ type CardTransferInResponse struct {
 
 // CardTransferIn transfers funds from Quantum account to prepaid card
 // POST /open-api/v3/cards/transfer-in
-func (c *CardTransactionClient) CardTransferIn(ctx context.Context, req *CardTransferInRequest) (*CardTransferInResponse, error) {
+func (c *CardTransactionClient) CardTransferIn(ctx context.Context, req *CardTransferInRequest, callOpts ...CallOption) (*CardTransferInResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if req.CardID == "" {
 		return nil, fmt.Errorf("cardId is required")
 	}
@@ -97,7 +99,9 @@ type CardTransferOutResponse struct {
 
 // CardTransferOut transfers funds from prepaid card to Quantum account
 // POST /open-api/v3/cards/transfer-out
-func (c *CardTransactionClient) CardTransferOut(ctx context.Context, req *CardTransferOutRequest) (*CardTransferOutResponse, error) {
+func (c *CardTransactionClient) CardTransferOut(ctx context.Context, req *CardTransferOutRequest, callOpts ...CallOption) (*CardTransferOutResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if req.CardID == "" {
 		return nil, fmt.Errorf("cardId is required")
 	}
@@ -177,7 +181,9 @@ type CardTransactionListResponse struct {
 
 // ListCardTransactions retrieves a list of card transactions with optional filtering
 // GET /open-api/v3/cards/transaction-list
-func (c *CardTransactionClient) ListCardTransactions(ctx context.Context, options *ListCardTransactionsOptions) (*CardTransactionListResponse, error) {
+func (c *CardTransactionClient) ListCardTransactions(ctx context.Context, options *ListCardTransactionsOptions, callOpts ...CallOption) (*CardTransactionListResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	queryParams := url.Values{}
 	
 	if options != nil {
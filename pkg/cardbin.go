@@ -20,7 +20,10 @@ func NewCardBinClient(httpClient *HTTPClient) *CardBinClient {
 
 // ListCardBins retrieves a list of all available card BINs
 // GET /open-api/v3/card/bins
-func (c *CardBinClient) ListCardBins(ctx context.Context, accountID string) (*CardBinListResponse, error) {
+// The response is cached when Config.Cache is set
+func (c *CardBinClient) ListCardBins(ctx context.Context, accountID string, callOpts ...CallOption) (*CardBinListResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if accountID == "" {
 		return nil, fmt.Errorf("accountId cannot be empty")
 	}
@@ -32,6 +35,7 @@ func (c *CardBinClient) ListCardBins(ctx context.Context, accountID string) (*Ca
 		Method:      "GET",
 		Endpoint:    "/open-api/v3/card/bins?" + params.Encode(),
 		RequireAuth: true,
+		Cacheable:   true,
 	}
 
 	var response CardBinListResponse
@@ -45,7 +49,9 @@ func (c *CardBinClient) ListCardBins(ctx context.Context, accountID string) (*Ca
 
 // ListCardBinsMaintain retrieves a list of card BINs under maintenance
 // GET /open-api/v3/card/bins/maintain
-func (c *CardBinClient) ListCardBinsMaintain(ctx context.Context, accountID string) (*CardBinListResponse, error) {
+func (c *CardBinClient) ListCardBinsMaintain(ctx context.Context, accountID string, callOpts ...CallOption) (*CardBinListResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if accountID == "" {
 		return nil, fmt.Errorf("accountId cannot be empty")
 	}
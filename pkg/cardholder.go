@@ -72,7 +72,9 @@ type CardholderListOptions struct {
 
 // CreateCardholder creates a new cardholder
 // POST /open-api/v3/cardholders
-func (c *CardholderClient) CreateCardholder(ctx context.Context, req *CreateCardholderRequest) (*Cardholder, error) {
+func (c *CardholderClient) CreateCardholder(ctx context.Context, req *CreateCardholderRequest, callOpts ...CallOption) (*Cardholder, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -98,7 +100,9 @@ func (c *CardholderClient) CreateCardholder(ctx context.Context, req *CreateCard
 
 // ListCardholders retrieves a list of cardholders
 // GET /open-api/v3/cardholders
-func (c *CardholderClient) ListCardholders(ctx context.Context, opts *CardholderListOptions) (*CardholderListResponse, error) {
+func (c *CardholderClient) ListCardholders(ctx context.Context, opts *CardholderListOptions, callOpts ...CallOption) (*CardholderListResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	params := url.Values{}
 	if opts != nil {
 		if opts.AccountID != "" {
@@ -134,7 +138,9 @@ func (c *CardholderClient) ListCardholders(ctx context.Context, opts *Cardholder
 
 // GetCardholder retrieves a specific cardholder by ID
 // GET /open-api/v3/cardholders/{id}
-func (c *CardholderClient) GetCardholder(ctx context.Context, cardholderID string) (*Cardholder, error) {
+func (c *CardholderClient) GetCardholder(ctx context.Context, cardholderID string, callOpts ...CallOption) (*Cardholder, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if cardholderID == "" {
 		return nil, fmt.Errorf("cardholder ID cannot be empty")
 	}
@@ -156,7 +162,9 @@ func (c *CardholderClient) GetCardholder(ctx context.Context, cardholderID strin
 
 // UpdateCardholder updates a cardholder's information
 // PATCH /open-api/v3/cardholders/{id}
-func (c *CardholderClient) UpdateCardholder(ctx context.Context, cardholderID string, req *UpdateCardholderRequest) (*Cardholder, error) {
+func (c *CardholderClient) UpdateCardholder(ctx context.Context, cardholderID string, req *UpdateCardholderRequest, callOpts ...CallOption) (*Cardholder, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if cardholderID == "" {
 		return nil, fmt.Errorf("cardholder ID cannot be empty")
 	}
@@ -0,0 +1,226 @@
+package interlace
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// CheckoutResult is the outcome of a shopper returning from the hosted checkout
+type CheckoutResult struct {
+	// Payment is the payment as queried from the API
+	Payment *Payment
+	State   OperationState
+	// Fulfilled reports whether OnPaid ran for this return. It is false when the order was
+	// already fulfilled, by an earlier return or a payment notification sharing the store.
+	Fulfilled bool
+	// Err is the error of the fulfilment hooks, if any
+	Err error
+}
+
+// CheckoutHandler is an http.Handler for hosted checkout. A POST creates the payment of the
+// shopper's order and redirects the shopper to its CheckoutURL. The ReturnURL landing re-queries
+// the payment, fulfils paid orders and renders the result. Query parameters only identify the
+// order: its status always comes from QueryPayment.
+//
+//	checkout, err := interlace.NewCheckoutHandler(client.Payment, "https://shop.example/checkout/return", secret)
+//	checkout.NewOrder = func(r *http.Request) (*interlace.CreatePaymentRequest, error) { ... }
+//	checkout.OnPaid = func(ctx context.Context, payment *interlace.Payment) error { ... }
+//	http.Handle("/checkout/", checkout)
+type CheckoutHandler struct {
+	payments  *PaymentClient
+	returnURL *url.URL
+	secret    string
+
+	// NewOrder builds the payment of a checkout request, typically from the shopper's cart. Its
+	// ReturnURL is set by the handler. Required.
+	NewOrder func(r *http.Request) (*CreatePaymentRequest, error)
+	// NotifyURL is set on the payments that do not have one, so that orders are also fulfilled
+	// when the shopper does not return
+	NotifyURL string
+	// OnPaid fulfils an order once its payment succeeded
+	OnPaid func(ctx context.Context, payment *Payment) error
+	// OnFailed is called once when the payment of an order failed
+	OnFailed func(ctx context.Context, payment *Payment) error
+	// OnResult renders the return landing. Defaults to a JSON summary of the payment.
+	OnResult func(w http.ResponseWriter, r *http.Request, result *CheckoutResult)
+	// Store makes the hooks run once per payment and final state (paid or failed). Share it with
+	// a PaymentNotifyHandler so that an order is fulfilled once whichever arrives first.
+	// Defaults to a MemoryNotificationStore.
+	Store NotificationStore
+}
+
+// NewCheckoutHandler creates a checkout handler returning shoppers to returnURL, which must be
+// an absolute URL served by the handler. The secret signs the order reference of the return
+// URL, so that shoppers cannot look up other orders; an empty secret disables signing.
+func NewCheckoutHandler(payments *PaymentClient, returnURL, secret string) (*CheckoutHandler, error) {
+	parsed, err := url.Parse(returnURL)
+	if err != nil || !parsed.IsAbs() {
+		return nil, fmt.Errorf("return URL must be an absolute URL")
+	}
+	return &CheckoutHandler{
+		payments:  payments,
+		returnURL: parsed,
+		secret:    secret,
+		Store:     NewMemoryNotificationStore(0),
+	}, nil
+}
+
+// sign returns the signature of an order reference
+func (h *CheckoutHandler) sign(merchantTradeNo string) string {
+	mac := hmac.New(sha256.New, []byte(h.secret))
+	mac.Write([]byte(merchantTradeNo))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ReturnURL returns the return URL of an order
+func (h *CheckoutHandler) ReturnURL(merchantTradeNo string) string {
+	returnURL := *h.returnURL
+	query := returnURL.Query()
+	query.Set("merchantTradeNo", merchantTradeNo)
+	if h.secret != "" {
+		query.Set("signature", h.sign(merchantTradeNo))
+	}
+	returnURL.RawQuery = query.Encode()
+	return returnURL.String()
+}
+
+// ServeHTTP routes requests for the return URL path to Return and the others to Start
+func (h *CheckoutHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.TrimSuffix(r.URL.Path, "/") == strings.TrimSuffix(h.returnURL.Path, "/") {
+		h.Return(w, r)
+		return
+	}
+	h.Start(w, r)
+}
+
+// Start creates the payment of an order and redirects the shopper to the hosted checkout
+func (h *CheckoutHandler) Start(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.NewOrder == nil {
+		http.Error(w, "Checkout is not configured", http.StatusInternalServerError)
+		return
+	}
+
+	order, err := h.NewOrder(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid order: %v", err), http.StatusBadRequest)
+		return
+	}
+	if order == nil || order.MerchantTradeNo == "" {
+		http.Error(w, "Invalid order: merchantTradeNo is required", http.StatusBadRequest)
+		return
+	}
+	order.ReturnURL = h.ReturnURL(order.MerchantTradeNo)
+	if order.NotifyURL == "" {
+		order.NotifyURL = h.NotifyURL
+	}
+
+	payment, err := h.payments.CreatePayment(r.Context(), order)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create payment: %v", err), http.StatusBadGateway)
+		return
+	}
+	if payment.CheckoutURL == "" {
+		http.Error(w, "Payment has no checkout URL", http.StatusBadGateway)
+		return
+	}
+	http.Redirect(w, r, payment.CheckoutURL, http.StatusSeeOther)
+}
+
+// Return handles the shopper landing on the return URL. The payment is re-queried and, when it
+// reached a final state, the matching hook runs once.
+func (h *CheckoutHandler) Return(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	merchantTradeNo := r.URL.Query().Get("merchantTradeNo")
+	if merchantTradeNo == "" {
+		http.Error(w, "Missing order reference", http.StatusBadRequest)
+		return
+	}
+	if h.secret != "" && !hmac.Equal([]byte(r.URL.Query().Get("signature")), []byte(h.sign(merchantTradeNo))) {
+		http.Error(w, "Invalid order reference", http.StatusForbidden)
+		return
+	}
+
+	ctx := r.Context()
+	payment, err := h.payments.QueryPayment(ctx, merchantTradeNo)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to query payment: %v", err), http.StatusBadGateway)
+		return
+	}
+	if payment.MerchantTradeNo == "" {
+		// Keeps the hooks keyed like the notifications of the order
+		payment.MerchantTradeNo = merchantTradeNo
+	}
+
+	result := &CheckoutResult{Payment: payment, State: paymentState(payment.Status)}
+	switch result.State {
+	case OperationSucceeded:
+		result.Fulfilled, result.Err = h.runOnce(ctx, payment, h.OnPaid)
+	case OperationFailed:
+		_, result.Err = h.runOnce(ctx, payment, h.OnFailed)
+	}
+
+	if h.OnResult != nil {
+		h.OnResult(w, r, result)
+		return
+	}
+	h.writeResult(w, result)
+}
+
+// runOnce runs a hook unless it already ran for the payment state. A failed hook is forgotten,
+// so that it runs again on the next return or notification.
+func (h *CheckoutHandler) runOnce(ctx context.Context, payment *Payment, hook func(context.Context, *Payment) error) (bool, error) {
+	if hook == nil {
+		return false, nil
+	}
+	// Same key as the notifications of the payment, marked once the payment state is known from
+	// the API like PaymentNotifyHandler does, so that a shared store runs hooks once
+	key := (&PaymentNotification{Type: OrderTypePayment, Payment: payment}).key()
+	if h.Store != nil {
+		fresh, err := h.Store.MarkProcessed(ctx, key)
+		if err != nil {
+			return false, fmt.Errorf("failed to record order fulfilment: %w", err)
+		}
+		if !fresh {
+			return false, nil
+		}
+	}
+	if err := hook(ctx, payment); err != nil {
+		if h.Store != nil {
+			h.Store.Forget(context.WithoutCancel(ctx), key)
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// writeResult writes the default JSON summary of a checkout result
+func (h *CheckoutHandler) writeResult(w http.ResponseWriter, result *CheckoutResult) {
+	response := map[string]interface{}{
+		"merchantTradeNo": result.Payment.MerchantTradeNo,
+		"status":          result.Payment.Status,
+		"state":           result.State.String(),
+	}
+	status := http.StatusOK
+	if result.Err != nil {
+		status = http.StatusInternalServerError
+		response["error"] = result.Err.Error()
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,196 @@
+package interlace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// checkoutServer fakes the payment creation and query endpoints
+type checkoutServer struct {
+	mu      sync.Mutex
+	status  string
+	created []CreatePaymentRequest
+}
+
+func (s *checkoutServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.URL.Path != "/open-api/v3/acquiring/payments" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method == http.MethodPost {
+		var req CreatePaymentRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		s.created = append(s.created, req)
+		json.NewEncoder(w).Encode(Payment{ID: "pay-1", MerchantTradeNo: req.MerchantTradeNo, Status: "PENDING",
+			CheckoutURL: "https://pay.example/checkout/pay-1"})
+		return
+	}
+	json.NewEncoder(w).Encode(Payment{ID: "pay-1", MerchantTradeNo: r.URL.Query().Get("orderNo"), Status: s.status})
+}
+
+func (s *checkoutServer) setStatus(status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status = status
+}
+
+func newTestCheckoutHandler(t *testing.T, server *checkoutServer) *CheckoutHandler {
+	handler, err := NewCheckoutHandler(newTestPaymentClient(t, server), "https://shop.example/checkout/return", "secret")
+	require.NoError(t, err)
+	handler.NewOrder = func(r *http.Request) (*CreatePaymentRequest, error) {
+		if r.FormValue("order") == "" {
+			return nil, errors.New("empty cart")
+		}
+		return &CreatePaymentRequest{MerchantTradeNo: r.FormValue("order"), Amount: "10.00", Currency: "USD", Country: "US"}, nil
+	}
+	return handler
+}
+
+func TestCheckoutHandlerStart(t *testing.T) {
+	server := &checkoutServer{}
+	handler := newTestCheckoutHandler(t, server)
+	handler.NotifyURL = "https://shop.example/notify"
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/checkout/start?order=order-1", nil))
+	require.Equal(t, http.StatusSeeOther, rec.Code, rec.Body.String())
+	assert.Equal(t, "https://pay.example/checkout/pay-1", rec.Header().Get("Location"))
+
+	require.Len(t, server.created, 1)
+	created := server.created[0]
+	assert.Equal(t, "https://shop.example/notify", created.NotifyURL)
+	returnURL, err := url.Parse(created.ReturnURL)
+	require.NoError(t, err)
+	assert.Equal(t, "/checkout/return", returnURL.Path)
+	assert.Equal(t, "order-1", returnURL.Query().Get("merchantTradeNo"))
+	assert.Equal(t, handler.sign("order-1"), returnURL.Query().Get("signature"))
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		status int
+	}{
+		{name: "GET", method: http.MethodGet, target: "/checkout/start?order=order-1", status: http.StatusMethodNotAllowed},
+		{name: "invalid order", method: http.MethodPost, target: "/checkout/start", status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+	assert.Len(t, server.created, 1)
+}
+
+func TestCheckoutHandlerReturnSignature(t *testing.T) {
+	server := &checkoutServer{status: "PAID"}
+	handler := newTestCheckoutHandler(t, server)
+	var paid int
+	handler.OnPaid = func(ctx context.Context, payment *Payment) error {
+		paid++
+		return nil
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		status int
+	}{
+		{name: "missing reference", target: "/checkout/return", status: http.StatusBadRequest},
+		{name: "missing signature", target: "/checkout/return?merchantTradeNo=order-1", status: http.StatusForbidden},
+		{
+			name:   "signature of another order",
+			target: "/checkout/return?merchantTradeNo=order-1&signature=" + handler.sign("order-2"),
+			status: http.StatusForbidden,
+		},
+		{name: "valid", target: handler.ReturnURL("order-1"), status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			assert.Equal(t, tt.status, rec.Code, rec.Body.String())
+		})
+	}
+	assert.Equal(t, 1, paid)
+}
+
+func TestCheckoutHandlerReturnRunsHooksOnce(t *testing.T) {
+	server := &checkoutServer{status: "PENDING"}
+	handler := newTestCheckoutHandler(t, server)
+	var paid int
+	fail := true
+	handler.OnPaid = func(ctx context.Context, payment *Payment) error {
+		paid++
+		if fail {
+			return errors.New("warehouse down")
+		}
+		return nil
+	}
+	var results []*CheckoutResult
+	handler.OnResult = func(w http.ResponseWriter, r *http.Request, result *CheckoutResult) {
+		results = append(results, result)
+	}
+	land := func() *CheckoutResult {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, handler.ReturnURL("order-1"), nil))
+		return results[len(results)-1]
+	}
+
+	result := land()
+	assert.Equal(t, OperationPending, result.State)
+	assert.False(t, result.Fulfilled)
+	assert.Zero(t, paid)
+
+	server.setStatus("SUCCESS")
+	result = land()
+	assert.Error(t, result.Err)
+	assert.False(t, result.Fulfilled)
+
+	fail = false
+	result = land()
+	assert.NoError(t, result.Err)
+	assert.True(t, result.Fulfilled, "a failed hook runs again")
+
+	result = land()
+	assert.False(t, result.Fulfilled)
+	assert.Equal(t, OperationSucceeded, result.State)
+	assert.Equal(t, 2, paid)
+}
+
+func TestCheckoutHandlerSharesStoreWithNotifications(t *testing.T) {
+	server := &checkoutServer{status: "SUCCESS"}
+	checkout := newTestCheckoutHandler(t, server)
+	notify := NewPaymentNotifyHandler(testWebhookSecret)
+	notify.Store = checkout.Store
+
+	var paid int
+	fulfil := func(ctx context.Context, payment *Payment) error {
+		paid++
+		return nil
+	}
+	checkout.OnPaid = fulfil
+	notify.OnPayment = fulfil
+
+	body := `{"id":"pay-1","merchantTradeNo":"order-1","status":"PAID"}`
+	rec := postNotification(notify, body, signNotification(body))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	checkout.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, checkout.ReturnURL("order-1"), nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 1, paid, "the order is fulfilled once")
+}
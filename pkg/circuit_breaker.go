@@ -0,0 +1,285 @@
+package interlace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned, wrapped in a *CircuitOpenError, when a request is rejected because
+// the circuit of its endpoint group is open. Check for it with errors.Is.
+var ErrCircuitOpen = errors.New("interlace: circuit breaker is open")
+
+// CircuitOpenError is returned instead of sending a request while the circuit of its endpoint
+// group is open
+type CircuitOpenError struct {
+	Group string
+	// RetryAfter is the time left until the circuit lets a probe request through
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("%v for endpoint group %q (retry after %s)", ErrCircuitOpen, e.Group, e.RetryAfter)
+}
+
+// Is makes errors.Is(err, ErrCircuitOpen) match
+func (e *CircuitOpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// CircuitState is the state of a circuit
+type CircuitState int
+
+// Circuit states
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// CircuitBreakerConfig configures the optional circuit breaker of the HTTP client. Each endpoint
+// group has its own circuit: after FailureThreshold consecutive failures the circuit opens and
+// requests fail immediately with ErrCircuitOpen. Once OpenTimeout has elapsed the circuit becomes
+// half-open and lets up to HalfOpenMaxProbes requests through; SuccessThreshold successful probes
+// close it again, while a failed probe reopens it.
+//
+// Network errors, timeouts, 429 and 5xx responses count as failures. Other API errors, such as
+// validation errors, count as successes since the API is responding.
+type CircuitBreakerConfig struct {
+	FailureThreshold  int           // Defaults to 5
+	OpenTimeout       time.Duration // Defaults to 30s
+	HalfOpenMaxProbes int           // Defaults to 1
+	SuccessThreshold  int           // Defaults to 1
+
+	// GroupFunc maps an endpoint path to its group. By default endpoints are grouped by the
+	// first path segment after /open-api/v3/, e.g. "cards" or "budgets".
+	GroupFunc func(endpoint string) string
+
+	// OnStateChange is called after a circuit changes state. It must not block.
+	OnStateChange func(group string, from, to CircuitState)
+}
+
+// EndpointGroup returns the default circuit breaker group of an endpoint path
+func EndpointGroup(endpoint string) string {
+	path := strings.TrimPrefix(endpoint, "/open-api/v3/")
+	path = strings.TrimPrefix(path, "/")
+	if i := strings.IndexAny(path, "/?"); i >= 0 {
+		path = path[:i]
+	}
+	return path
+}
+
+type circuitBreaker struct {
+	config CircuitBreakerConfig
+	now    func() time.Time
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	state      CircuitState
+	generation uint64 // Incremented on every state change
+	failures   int
+	successes  int
+	probes     int
+	openedAt   time.Time
+}
+
+type circuitTransition struct {
+	group    string
+	from, to CircuitState
+}
+
+func newCircuitBreaker(config CircuitBreakerConfig) *circuitBreaker {
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = 5
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = 30 * time.Second
+	}
+	if config.HalfOpenMaxProbes <= 0 {
+		config.HalfOpenMaxProbes = 1
+	}
+	if config.SuccessThreshold <= 0 {
+		config.SuccessThreshold = 1
+	}
+	if config.GroupFunc == nil {
+		config.GroupFunc = EndpointGroup
+	}
+
+	return &circuitBreaker{
+		config:   config,
+		now:      time.Now,
+		circuits: make(map[string]*circuit),
+	}
+}
+
+// state returns the current state of the group's circuit
+func (b *circuitBreaker) state(group string) CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if c, ok := b.circuits[group]; ok {
+		return c.state
+	}
+	return CircuitClosed
+}
+
+// allow reports whether a request to the group may be sent. The returned generation must be
+// passed to done once the outcome of the request is known.
+func (b *circuitBreaker) allow(group string) (uint64, error) {
+	var transitions []circuitTransition
+	defer func() { b.notify(transitions) }()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[group]
+	if !ok {
+		c = &circuit{}
+		b.circuits[group] = c
+	}
+
+	if c.state == CircuitOpen {
+		wait := c.openedAt.Add(b.config.OpenTimeout).Sub(b.now())
+		if wait > 0 {
+			return 0, &CircuitOpenError{Group: group, RetryAfter: wait}
+		}
+		transitions = append(transitions, b.transition(group, c, CircuitHalfOpen))
+	}
+
+	if c.state == CircuitHalfOpen {
+		if c.probes >= b.config.HalfOpenMaxProbes {
+			return 0, &CircuitOpenError{Group: group}
+		}
+		c.probes++
+	}
+
+	return c.generation, nil
+}
+
+// done records the outcome of a request allowed by allow. Outcomes reported for an earlier
+// state of the circuit are ignored.
+func (b *circuitBreaker) done(group string, generation uint64, success bool) {
+	var transitions []circuitTransition
+	defer func() { b.notify(transitions) }()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	c, ok := b.circuits[group]
+	if !ok || c.generation != generation {
+		return
+	}
+
+	switch c.state {
+	case CircuitClosed:
+		if success {
+			c.failures = 0
+			return
+		}
+		c.failures++
+		if c.failures >= b.config.FailureThreshold {
+			transitions = append(transitions, b.transition(group, c, CircuitOpen))
+		}
+	case CircuitHalfOpen:
+		c.probes--
+		if !success {
+			transitions = append(transitions, b.transition(group, c, CircuitOpen))
+			return
+		}
+		c.successes++
+		if c.successes >= b.config.SuccessThreshold {
+			transitions = append(transitions, b.transition(group, c, CircuitClosed))
+		}
+	}
+}
+
+// release gives back a half-open probe slot without recording an outcome, e.g. when the caller
+// cancelled the request
+func (b *circuitBreaker) release(group string, generation uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if c, ok := b.circuits[group]; ok && c.generation == generation && c.state == CircuitHalfOpen {
+		c.probes--
+	}
+}
+
+// transition moves the circuit to a new state. The caller must hold b.mu.
+func (b *circuitBreaker) transition(group string, c *circuit, to CircuitState) circuitTransition {
+	from := c.state
+	c.state = to
+	c.generation++
+	c.failures = 0
+	c.successes = 0
+	c.probes = 0
+	if to == CircuitOpen {
+		c.openedAt = b.now()
+	}
+	return circuitTransition{group: group, from: from, to: to}
+}
+
+func (b *circuitBreaker) notify(transitions []circuitTransition) {
+	if b.config.OnStateChange == nil {
+		return
+	}
+	for _, t := range transitions {
+		b.config.OnStateChange(t.group, t.from, t.to)
+	}
+}
+
+// isCircuitFailure reports whether a request outcome counts as a failure for the circuit breaker
+func isCircuitFailure(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// CircuitState returns the state of the circuit for the given endpoint group. It returns
+// CircuitClosed when the circuit breaker is disabled.
+func (c *HTTPClient) CircuitState(group string) CircuitState {
+	if c.breaker == nil {
+		return CircuitClosed
+	}
+	return c.breaker.state(group)
+}
+
+// callCircuit sends a request through the circuit breaker of its endpoint group
+func (c *HTTPClient) callCircuit(ctx context.Context, endpoint string, send func() ([]byte, *http.Response, error)) ([]byte, *http.Response, error) {
+	if c.breaker == nil {
+		return send()
+	}
+
+	group := c.breaker.config.GroupFunc(endpoint)
+	generation, err := c.breaker.allow(group)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	respBody, resp, err := send()
+	if errors.Is(ctx.Err(), context.Canceled) {
+		c.breaker.release(group, generation)
+	} else {
+		c.breaker.done(group, generation, !isCircuitFailure(resp, err))
+	}
+	return respBody, resp, err
+}
@@ -0,0 +1,187 @@
+package interlace
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a manually advanced clock
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestCircuitBreaker(config CircuitBreakerConfig) (*circuitBreaker, *fakeClock, *[]string) {
+	clock := &fakeClock{now: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}
+	var transitions []string
+	config.OnStateChange = func(group string, from, to CircuitState) {
+		transitions = append(transitions, group+": "+from.String()+" -> "+to.String())
+	}
+	breaker := newCircuitBreaker(config)
+	breaker.now = clock.Now
+	return breaker, clock, &transitions
+}
+
+func TestCircuitBreakerStates(t *testing.T) {
+	type step struct {
+		advance time.Duration
+		// outcomes are the outcomes of requests allowed in turn, nil when the request is rejected
+		outcomes []*bool
+		state    CircuitState
+	}
+	ok, fail := true, false
+	success, failure := &ok, &fail
+
+	tests := []struct {
+		name        string
+		config      CircuitBreakerConfig
+		steps       []step
+		transitions []string
+	}{
+		{
+			name:   "successes reset the failure count",
+			config: CircuitBreakerConfig{FailureThreshold: 3},
+			steps: []step{
+				{outcomes: []*bool{failure, failure, success, failure, failure}, state: CircuitClosed},
+			},
+		},
+		{
+			name:   "open, half-open and closed",
+			config: CircuitBreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute},
+			steps: []step{
+				{outcomes: []*bool{failure, failure, nil}, state: CircuitOpen},
+				{advance: 59 * time.Second, outcomes: []*bool{nil}, state: CircuitOpen},
+				{advance: time.Second, outcomes: []*bool{success}, state: CircuitClosed},
+			},
+			transitions: []string{"cards: closed -> open", "cards: open -> half-open", "cards: half-open -> closed"},
+		},
+		{
+			name:   "failed probe reopens the circuit",
+			config: CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute},
+			steps: []step{
+				{outcomes: []*bool{failure}, state: CircuitOpen},
+				{advance: time.Minute, outcomes: []*bool{failure, nil}, state: CircuitOpen},
+				{advance: 30 * time.Second, outcomes: []*bool{nil}, state: CircuitOpen},
+				{advance: 30 * time.Second, outcomes: []*bool{success}, state: CircuitClosed},
+			},
+			transitions: []string{"cards: closed -> open", "cards: open -> half-open", "cards: half-open -> open",
+				"cards: open -> half-open", "cards: half-open -> closed"},
+		},
+		{
+			name:   "success threshold",
+			config: CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute, SuccessThreshold: 2},
+			steps: []step{
+				{outcomes: []*bool{failure}, state: CircuitOpen},
+				{advance: time.Minute, outcomes: []*bool{success}, state: CircuitHalfOpen},
+				{outcomes: []*bool{success}, state: CircuitClosed},
+			},
+			transitions: []string{"cards: closed -> open", "cards: open -> half-open", "cards: half-open -> closed"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			breaker, clock, transitions := newTestCircuitBreaker(tt.config)
+			for i, step := range tt.steps {
+				clock.Advance(step.advance)
+				for j, outcome := range step.outcomes {
+					generation, err := breaker.allow("cards")
+					if outcome == nil {
+						assert.True(t, errors.Is(err, ErrCircuitOpen), "step %d request %d is rejected", i, j)
+						continue
+					}
+					require.NoError(t, err, "step %d request %d is allowed", i, j)
+					breaker.done("cards", generation, *outcome)
+				}
+				assert.Equal(t, step.state, breaker.state("cards"), "step %d", i)
+			}
+			assert.Equal(t, tt.transitions, *transitions)
+			assert.Equal(t, CircuitClosed, breaker.state("budgets"), "groups have their own circuit")
+		})
+	}
+}
+
+func TestCircuitBreakerRetryAfter(t *testing.T) {
+	breaker, clock, _ := newTestCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute})
+	generation, err := breaker.allow("cards")
+	require.NoError(t, err)
+	breaker.done("cards", generation, false)
+
+	clock.Advance(20 * time.Second)
+	_, err = breaker.allow("cards")
+	var openErr *CircuitOpenError
+	require.ErrorAs(t, err, &openErr)
+	assert.Equal(t, "cards", openErr.Group)
+	assert.Equal(t, 40*time.Second, openErr.RetryAfter)
+}
+
+func TestCircuitBreakerGenerations(t *testing.T) {
+	breaker, clock, _ := newTestCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 2, OpenTimeout: time.Minute,
+		HalfOpenMaxProbes: 1})
+
+	// Requests in flight when the circuit opens report their outcome late
+	slow, err := breaker.allow("cards")
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		generation, err := breaker.allow("cards")
+		require.NoError(t, err)
+		breaker.done("cards", generation, false)
+	}
+	require.Equal(t, CircuitOpen, breaker.state("cards"))
+
+	clock.Advance(time.Minute)
+	probe, err := breaker.allow("cards")
+	require.NoError(t, err)
+	require.Equal(t, CircuitHalfOpen, breaker.state("cards"))
+
+	// The late success of the closed circuit does not close the half-open one nor free its probe
+	breaker.done("cards", slow, true)
+	assert.Equal(t, CircuitHalfOpen, breaker.state("cards"))
+	_, err = breaker.allow("cards")
+	assert.True(t, errors.Is(err, ErrCircuitOpen), "the probe slot is taken")
+
+	// A cancelled probe gives its slot back without an outcome
+	breaker.release("cards", probe)
+	assert.Equal(t, CircuitHalfOpen, breaker.state("cards"))
+	probe, err = breaker.allow("cards")
+	require.NoError(t, err)
+	breaker.done("cards", probe, true)
+	assert.Equal(t, CircuitClosed, breaker.state("cards"))
+
+	// An outcome of the half-open generation is ignored once the circuit closed
+	breaker.done("cards", probe, false)
+	breaker.release("cards", probe)
+	assert.Equal(t, CircuitClosed, breaker.state("cards"))
+}
+
+func TestHTTPClientCircuitBreaker(t *testing.T) {
+	server := &recordingServer{statuses: []int{400, 500, 503}}
+	client := newTestHTTPClient(t, server, func(cfg *Config) {
+		cfg.CircuitBreaker = &CircuitBreakerConfig{FailureThreshold: 2}
+	})
+	get := func(endpoint string) error {
+		return client.DoRequest(context.Background(), &RequestOptions{Method: http.MethodGet, Endpoint: endpoint}, nil)
+	}
+
+	// A validation error shows the API is up
+	require.Error(t, get("/open-api/v3/cards/1"))
+	assert.Equal(t, CircuitClosed, client.CircuitState("cards"))
+
+	require.Error(t, get("/open-api/v3/cards/1"))
+	require.Error(t, get("/open-api/v3/cards/2"))
+	assert.Equal(t, CircuitOpen, client.CircuitState("cards"))
+
+	err := get("/open-api/v3/cards/3")
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.Len(t, server.keys, 3, "no request is sent while the circuit is open")
+	assert.NoError(t, get("/open-api/v3/budgets"))
+}
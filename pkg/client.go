@@ -33,6 +33,7 @@ type Client struct {
 	Sweeping          *SweepingClient
 	Testing           *TestingClient
 	BusinessAccount   *BusinessAccountClient
+	Approvals         *ApprovalClient
 }
 
 // NewClient creates a new Interlace SDK client
@@ -73,6 +74,7 @@ func NewClient(config *Config) *Client {
 	client.Sweeping = NewSweepingClient(httpClient)
 	client.Testing = NewTestingClient(httpClient)
 	client.BusinessAccount = NewBusinessAccountClient(httpClient)
+	client.Approvals = NewApprovalClient(httpClient)
 
 	return client
 }
@@ -146,6 +148,7 @@ func (c *Client) SetConfig(config *Config) {
 	c.Sweeping = NewSweepingClient(c.httpClient)
 	c.Testing = NewTestingClient(c.httpClient)
 	c.BusinessAccount = NewBusinessAccountClient(c.httpClient)
+	c.Approvals = NewApprovalClient(c.httpClient)
 }
 
 // SetBaseURL is a convenience method to update just the base URL
@@ -68,7 +68,7 @@ func TestError(t *testing.T) {
 // Example test for OAuth client
 func TestOAuthClient(t *testing.T) {
 	config := DefaultConfig()
-	oauthClient := NewOAuthClient(config)
+	oauthClient := NewOAuthClient(NewHTTPClient(config, ""))
 	
 	assert.NotNil(t, oauthClient)
 	assert.NotNil(t, oauthClient.httpClient)
@@ -78,14 +78,14 @@ func TestOAuthClient(t *testing.T) {
 func TestAccountClient(t *testing.T) {
 	config := DefaultConfig()
 	token := "test-token"
-	accountClient := NewAccountClient(config, token)
+	accountClient := NewAccountClient(NewHTTPClient(config, token))
 	
 	assert.NotNil(t, accountClient)
 	assert.Equal(t, token, accountClient.httpClient.GetAccessToken())
 	
 	// Test SetAccessToken
 	newToken := "new-test-token"
-	accountClient.SetAccessToken(newToken)
+	accountClient.httpClient.SetAccessToken(newToken)
 	assert.Equal(t, newToken, accountClient.httpClient.GetAccessToken())
 }
 
@@ -93,7 +93,7 @@ func TestAccountClient(t *testing.T) {
 func TestFileClient(t *testing.T) {
 	config := DefaultConfig()
 	token := "test-token"
-	fileClient := NewFileClient(config, token)
+	fileClient := NewFileClient(NewHTTPClient(config, token))
 	
 	assert.NotNil(t, fileClient)
 	assert.Equal(t, token, fileClient.httpClient.GetAccessToken())
@@ -116,7 +116,7 @@ func TestHTTPClient(t *testing.T) {
 func TestAccountRegisterWithDetails(t *testing.T) {
 	config := DefaultConfig()
 	token := "test-token"
-	accountClient := NewAccountClient(config, token)
+	accountClient := NewAccountClient(NewHTTPClient(config, token))
 	
 	assert.NotNil(t, accountClient)
 	
@@ -127,7 +127,7 @@ func TestAccountRegisterWithDetails(t *testing.T) {
 func TestRegisterGolangTest(t *testing.T) {
 	config := DefaultConfig()
 	token := "test-token"
-	accountClient := NewAccountClient(config, token)
+	accountClient := NewAccountClient(NewHTTPClient(config, token))
 	
 	assert.NotNil(t, accountClient)
 	
@@ -234,13 +234,13 @@ func TestKYCBuilderValidation(t *testing.T) {
 func TestKYCClient(t *testing.T) {
 	config := DefaultConfig()
 	token := "test-token"
-	kycClient := NewKYCClient(config, token)
+	kycClient := NewKYCClient(NewHTTPClient(config, token))
 	
 	assert.NotNil(t, kycClient)
 	assert.Equal(t, token, kycClient.httpClient.GetAccessToken())
 	
 	// Test SetAccessToken
 	newToken := "new-test-token"
-	kycClient.SetAccessToken(newToken)
+	kycClient.httpClient.SetAccessToken(newToken)
 	assert.Equal(t, newToken, kycClient.httpClient.GetAccessToken())
 }
\ No newline at end of file
@@ -53,7 +53,10 @@ type SetConsumptionScenarioResponse struct {
 
 // ListConsumptionScenarios retrieves a list of all Infinity Card transaction scenarios
 // GET /open-api/v3/card/sys/consumption-scenarios
-func (c *CommonClient) ListConsumptionScenarios(ctx context.Context, accountID string) (*ConsumptionScenarioListResponse, error) {
+// The response is cached when Config.Cache is set
+func (c *CommonClient) ListConsumptionScenarios(ctx context.Context, accountID string, callOpts ...CallOption) (*ConsumptionScenarioListResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if accountID == "" {
 		return nil, fmt.Errorf("accountId cannot be empty")
 	}
@@ -65,6 +68,7 @@ func (c *CommonClient) ListConsumptionScenarios(ctx context.Context, accountID s
 		Method:      "GET",
 		Endpoint:    "/open-api/v3/card/sys/consumption-scenarios?" + params.Encode(),
 		RequireAuth: true,
+		Cacheable:   true,
 	}
 
 	var response ConsumptionScenarioListResponse
@@ -78,7 +82,9 @@ func (c *CommonClient) ListConsumptionScenarios(ctx context.Context, accountID s
 
 // ListWallets retrieves wallet balances for an account
 // GET /open-api/v3/wallets
-func (c *CommonClient) ListWallets(ctx context.Context, accountID string) ([]WalletBalance, error) {
+func (c *CommonClient) ListWallets(ctx context.Context, accountID string, callOpts ...CallOption) ([]WalletBalance, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if accountID == "" {
 		return nil, fmt.Errorf("accountId cannot be empty")
 	}
@@ -100,7 +106,10 @@ func (c *CommonClient) ListWallets(ctx context.Context, accountID string) ([]Wal
 
 // GetCardBinRecommendation queries card BIN for high success rate
 // GET /open-api/v3/card/bin/recommendation
-func (c *CommonClient) GetCardBinRecommendation(ctx context.Context, currency, region string) ([]CardBinRecommendation, error) {
+// The response is cached when Config.Cache is set
+func (c *CommonClient) GetCardBinRecommendation(ctx context.Context, currency, region string, callOpts ...CallOption) ([]CardBinRecommendation, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if currency == "" {
 		return nil, fmt.Errorf("currency cannot be empty")
 	}
@@ -116,6 +125,7 @@ func (c *CommonClient) GetCardBinRecommendation(ctx context.Context, currency, r
 		Endpoint:    "/open-api/v3/card/bin/recommendation",
 		QueryParams: params,
 		RequireAuth: true,
+		Cacheable:   true,
 	}
 
 	var recommendations []CardBinRecommendation
@@ -129,7 +139,9 @@ func (c *CommonClient) GetCardBinRecommendation(ctx context.Context, currency, r
 
 // SetConsumptionScenario sets transaction scenarios for a card
 // POST /open-api/v3/card/consumption-scenario
-func (c *CommonClient) SetConsumptionScenario(ctx context.Context, req *SetConsumptionScenarioRequest) (*SetConsumptionScenarioResponse, error) {
+func (c *CommonClient) SetConsumptionScenario(ctx context.Context, req *SetConsumptionScenarioRequest, callOpts ...CallOption) (*SetConsumptionScenarioResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if req == nil {
 		return nil, fmt.Errorf("request is required")
 	}
@@ -94,11 +94,15 @@ type ConvertTradeListResponse struct {
 }
 
 // GetCurrencyPairs retrieves all available trading currency pairs
-func (c *ConvertClient) GetCurrencyPairs(ctx context.Context) ([]CurrencyPair, error) {
+// The response is cached when Config.Cache is set
+func (c *ConvertClient) GetCurrencyPairs(ctx context.Context, callOpts ...CallOption) ([]CurrencyPair, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	opts := &RequestOptions{
 		Method:      "GET",
 		Endpoint:    "/open-api/v3/crypto/convert/currency-pairs",
 		RequireAuth: true,
+		Cacheable:   true,
 	}
 
 	var pairs []CurrencyPair
@@ -110,7 +114,9 @@ func (c *ConvertClient) GetCurrencyPairs(ctx context.Context) ([]CurrencyPair, e
 }
 
 // GetConvertQuote retrieves an estimate quote for conversion
-func (c *ConvertClient) GetConvertQuote(ctx context.Context, req *GetConvertQuoteRequest) (*ConvertQuote, error) {
+func (c *ConvertClient) GetConvertQuote(ctx context.Context, req *GetConvertQuoteRequest, callOpts ...CallOption) (*ConvertQuote, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if req == nil {
 		return nil, fmt.Errorf("convert quote request is required")
 	}
@@ -140,7 +146,9 @@ func (c *ConvertClient) GetConvertQuote(ctx context.Context, req *GetConvertQuot
 }
 
 // CreateConvertTrade creates a conversion trade
-func (c *ConvertClient) CreateConvertTrade(ctx context.Context, req *CreateConvertTradeRequest) (*ConvertTrade, error) {
+func (c *ConvertClient) CreateConvertTrade(ctx context.Context, req *CreateConvertTradeRequest, callOpts ...CallOption) (*ConvertTrade, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if req == nil {
 		return nil, fmt.Errorf("create trade request is required")
 	}
@@ -173,7 +181,9 @@ func (c *ConvertClient) CreateConvertTrade(ctx context.Context, req *CreateConve
 }
 
 // ListConvertTrades retrieves all conversion trades
-func (c *ConvertClient) ListConvertTrades(ctx context.Context, options *ListConvertTradesOptions) (*ConvertTradeListResponse, error) {
+func (c *ConvertClient) ListConvertTrades(ctx context.Context, options *ListConvertTradesOptions, callOpts ...CallOption) (*ConvertTradeListResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	var queryParams url.Values
 	if options != nil {
 		queryParams = url.Values{}
@@ -217,3 +227,55 @@ func (c *ConvertClient) ListConvertTrades(ctx context.Context, options *ListConv
 	}
 	return &resp, nil
 }
+
+// CreateConvertTradeOperation creates a conversion trade and returns an operation that tracks
+// it until it completes or fails
+func (c *ConvertClient) CreateConvertTradeOperation(ctx context.Context, req *CreateConvertTradeRequest, callOpts ...CallOption) (*Operation[ConvertTrade], error) {
+	trade, err := c.CreateConvertTrade(ctx, req, callOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return newOperation(trade.TradeID, trade, c.convertTradeOperationSpec(req.WalletID, trade.TradeID)), nil
+}
+
+// ConvertTradeOperation returns an operation that tracks an existing conversion trade of the
+// given wallet
+func (c *ConvertClient) ConvertTradeOperation(walletID, tradeID string) *Operation[ConvertTrade] {
+	return newOperation(tradeID, nil, c.convertTradeOperationSpec(walletID, tradeID))
+}
+
+func (c *ConvertClient) convertTradeOperationSpec(walletID, tradeID string) operationSpec[ConvertTrade] {
+	return operationSpec[ConvertTrade]{
+		poll: func(ctx context.Context) (*ConvertTrade, error) {
+			return c.findConvertTrade(ctx, walletID, tradeID)
+		},
+		state: func(t *ConvertTrade) (OperationState, string, string) {
+			return statusState(t.Status), t.Status, ""
+		},
+		eventKeys: []string{"tradeId", "id"},
+	}
+}
+
+// findConvertTrade looks up a trade in the trade list, since the API has no endpoint to get a
+// single trade
+func (c *ConvertClient) findConvertTrade(ctx context.Context, walletID, tradeID string) (*ConvertTrade, error) {
+	options := &ListConvertTradesOptions{WalletID: walletID, Limit: 100}
+	for page := 1; ; page++ {
+		options.Page = page
+		resp, err := c.ListConvertTrades(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range resp.Trades {
+			if resp.Trades[i].TradeID == tradeID {
+				return &resp.Trades[i], nil
+			}
+		}
+
+		if len(resp.Trades) == 0 || page*options.Limit >= resp.TotalCount {
+			return nil, fmt.Errorf("convert trade %s not found", tradeID)
+		}
+	}
+}
@@ -25,7 +25,9 @@ func NewFileClient(httpClient *HTTPClient) *FileClient {
 
 
 // UploadFile uploads a file to the Interlace API
-func (c *FileClient) UploadFile(ctx context.Context, filePath, accountID string) (*FileUploadResponse, error) {
+func (c *FileClient) UploadFile(ctx context.Context, filePath, accountID string, callOpts ...CallOption) (*FileUploadResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	// Open the file
 	file, err := os.Open(filePath)
 	if err != nil {
@@ -38,7 +40,9 @@ func (c *FileClient) UploadFile(ctx context.Context, filePath, accountID string)
 }
 
 // UploadFileFromReader uploads a file from an io.Reader
-func (c *FileClient) UploadFileFromReader(ctx context.Context, reader io.Reader, fileName, accountID string) (*FileUploadResponse, error) {
+func (c *FileClient) UploadFileFromReader(ctx context.Context, reader io.Reader, fileName, accountID string, callOpts ...CallOption) (*FileUploadResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	// Create a buffer to store the multipart form data
 	var requestBody bytes.Buffer
 	writer := multipart.NewWriter(&requestBody)
@@ -94,7 +98,9 @@ func (c *FileClient) UploadFileFromReader(ctx context.Context, reader io.Reader,
 }
 
 // UploadMultipleFiles uploads multiple files to the Interlace API
-func (c *FileClient) UploadMultipleFiles(ctx context.Context, filePaths []string, accountID string) (*FileUploadResponse, error) {
+func (c *FileClient) UploadMultipleFiles(ctx context.Context, filePaths []string, accountID string, callOpts ...CallOption) (*FileUploadResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	// Create a buffer to store the multipart form data
 	var requestBody bytes.Buffer
 	writer := multipart.NewWriter(&requestBody)
@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"time"
 )
 
 // HTTPClient is a wrapper around http.Client that handles common operations
@@ -15,6 +18,9 @@ type HTTPClient struct {
 	config      *Config
 	httpClient  *http.Client
 	accessToken string
+	breaker     *circuitBreaker
+	cache       *responseCache
+	approvals   *approvalGate
 }
 
 // NewHTTPClient creates a new HTTP client wrapper
@@ -23,13 +29,24 @@ func NewHTTPClient(config *Config, accessToken string) *HTTPClient {
 		config = DefaultConfig()
 	}
 
-	return &HTTPClient{
+	client := &HTTPClient{
 		config:      config,
 		accessToken: accessToken,
 		httpClient: &http.Client{
 			Timeout: config.Timeout,
 		},
 	}
+	if config.CircuitBreaker != nil {
+		client.breaker = newCircuitBreaker(*config.CircuitBreaker)
+	}
+	if config.Cache != nil {
+		client.cache = newResponseCache(*config.Cache)
+	}
+	if config.Approval != nil {
+		client.approvals = newApprovalGate(*config.Approval)
+	}
+
+	return client
 }
 
 // SetAccessToken updates the access token
@@ -51,38 +68,141 @@ type RequestOptions struct {
 	Headers      map[string]string
 	RequireAuth  bool
 	ContentType  string
+
+	// IdempotencyKey overrides the idempotency key of a mutating request
+	IdempotencyKey string
+
+	// Cacheable marks a GET request whose response is served from the cache when Config.Cache is set
+	Cacheable bool
 }
 
 // DoRequest performs an HTTP request with common handling
 func (c *HTTPClient) DoRequest(ctx context.Context, opts *RequestOptions, result interface{}) error {
+	if opts.Cacheable && c.cache != nil && opts.Method == http.MethodGet {
+		return c.doCachedRequest(ctx, opts, callOptionsFromContext(ctx), result)
+	}
+	return c.doRequest(ctx, opts, result)
+}
+
+// doRequest sends the request to the API, retrying it as configured
+func (c *HTTPClient) doRequest(ctx context.Context, opts *RequestOptions, result interface{}) error {
+	callOpts := callOptionsFromContext(ctx)
+	if callOpts.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, callOpts.timeout)
+		defer cancel()
+	}
+
+	maxRetries := c.config.MaxRetries
+	if callOpts.maxRetries != nil {
+		maxRetries = *callOpts.maxRetries
+	}
+
 	// Build URL
 	fullURL := fmt.Sprintf("%s%s", c.config.BaseURL, opts.Endpoint)
 	if opts.QueryParams != nil && len(opts.QueryParams) > 0 {
 		fullURL = fmt.Sprintf("%s?%s", fullURL, opts.QueryParams.Encode())
 	}
 
-	// Prepare request body
-	var bodyReader io.Reader
+	// Resolve the idempotency key before the body is encoded, so that it can be written
+	// into the request's merchant reference field
+	var idempotencyKey string
+	if isMutatingMethod(opts.Method) && !c.config.DisableIdempotencyKeys {
+		withKey := *opts
+		idempotencyKey, withKey.Body = resolveIdempotencyKey(ctx, opts)
+		opts = &withKey
+	}
+
+	// Prepare request body. It is buffered so that it can be replayed on retries.
+	var bodyBytes []byte
+	_, isReader := opts.Body.(io.Reader)
 	if opts.Body != nil {
-		if bodyBytes, ok := opts.Body.([]byte); ok {
-			bodyReader = bytes.NewReader(bodyBytes)
+		if raw, ok := opts.Body.([]byte); ok {
+			bodyBytes = raw
 		} else if reader, ok := opts.Body.(io.Reader); ok {
 			// Body is already a reader (e.g., for file uploads)
-			bodyReader = reader
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				return fmt.Errorf("failed to read request body: %w", err)
+			}
+			bodyBytes = data
 		} else {
 			// JSON marshal the body
 			jsonData, err := json.Marshal(opts.Body)
 			if err != nil {
 				return fmt.Errorf("failed to marshal request body: %w", err)
 			}
-			bodyReader = bytes.NewBuffer(jsonData)
+			bodyBytes = jsonData
+		}
+	}
+
+	// Money movements above the approval thresholds are held instead of sent
+	if c.approvals != nil {
+		if err := c.approvals.capture(ctx, opts, callOpts, bodyBytes, idempotencyKey); err != nil {
+			return err
 		}
 	}
 
+	meta := callOpts.responseMeta
+	start := time.Now()
+
+	for attempt := 0; ; attempt++ {
+		respBody, resp, err := c.callCircuit(ctx, opts.Endpoint, func() ([]byte, *http.Response, error) {
+			return c.send(ctx, opts, callOpts, fullURL, bodyBytes, isReader, idempotencyKey)
+		})
+		if meta != nil {
+			value := ResponseMeta{IdempotencyKey: idempotencyKey, Attempts: attempt + 1}
+			if resp != nil {
+				value.record(resp, respBody)
+			}
+			value.Latency = time.Since(start)
+			*meta = value
+		}
+		if attempt < maxRetries && shouldRetry(ctx, resp, err) && (idempotencyKey != "" || safeToResend(opts.Method, resp)) {
+			if waitErr := sleepContext(ctx, c.retryDelay(attempt, resp)); waitErr != nil {
+				err = waitErr
+			} else {
+				continue
+			}
+		}
+
+		if err != nil {
+			// Nothing was sent when the circuit was open on the first attempt
+			if idempotencyKey != "" && !(attempt == 0 && errors.Is(err, ErrCircuitOpen)) {
+				return &RequestError{IdempotencyKey: idempotencyKey, Err: err}
+			}
+			return err
+		}
+
+		// Check for HTTP errors
+		if resp.StatusCode >= 400 {
+			apiError := ParseError(respBody)
+			apiError.IdempotencyKey = idempotencyKey
+			return apiError
+		}
+
+		// Parse JSON response if result is provided
+		if result != nil {
+			if err := json.Unmarshal(respBody, result); err != nil {
+				return fmt.Errorf("failed to decode response: %w", err)
+			}
+		}
+
+		return nil
+	}
+}
+
+// send performs a single HTTP round trip and returns the response body
+func (c *HTTPClient) send(ctx context.Context, opts *RequestOptions, callOpts *callOptions, fullURL string, bodyBytes []byte, isReader bool, idempotencyKey string) ([]byte, *http.Response, error) {
+	var bodyReader io.Reader
+	if bodyBytes != nil {
+		bodyReader = bytes.NewReader(bodyBytes)
+	}
+
 	// Create HTTP request
 	req, err := http.NewRequestWithContext(ctx, opts.Method, fullURL, bodyReader)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set default headers
@@ -94,7 +214,7 @@ func (c *HTTPClient) DoRequest(ctx context.Context, opts *RequestOptions, result
 		req.Header.Set("Content-Type", opts.ContentType)
 	} else if opts.Body != nil && opts.Method != "GET" {
 		// Default to JSON for non-GET requests with body
-		if _, ok := opts.Body.(io.Reader); !ok {
+		if !isReader {
 			req.Header.Set("Content-Type", "application/json")
 		}
 	}
@@ -104,38 +224,93 @@ func (c *HTTPClient) DoRequest(ctx context.Context, opts *RequestOptions, result
 		req.Header.Set("x-access-token", c.accessToken)
 	}
 
-	// Set custom headers
+	if idempotencyKey != "" {
+		req.Header.Set(IdempotencyKeyHeader, idempotencyKey)
+	}
+
+	if callOpts.onBehalfOf != "" {
+		req.Header.Set(OnBehalfOfHeader, callOpts.onBehalfOf)
+	}
+
+	// Set custom headers, per-call headers last
 	for key, value := range opts.Headers {
 		req.Header.Set(key, value)
 	}
+	for key, value := range callOpts.headers {
+		req.Header.Set(key, value)
+	}
 
 	// Execute request
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to execute request: %w", err)
+		return nil, nil, fmt.Errorf("failed to execute request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Read response body
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+		return nil, resp, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	// Check for HTTP errors
-	if resp.StatusCode >= 400 {
-		apiError := ParseError(respBody)
-		return apiError
+	return respBody, resp, nil
+}
+
+// shouldRetry reports whether a request that ended with the given response or error is retried
+func shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return !errors.Is(err, ErrCircuitOpen)
 	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// safeToResend reports whether a request without idempotency key can be sent again. Mutating
+// requests are only resent when rate limited, as a network error or a 5xx response may come after
+// the server already executed them.
+func safeToResend(method string, resp *http.Response) bool {
+	return !isMutatingMethod(method) || (resp != nil && resp.StatusCode == http.StatusTooManyRequests)
+}
 
-	// Parse JSON response if result is provided
-	if result != nil {
-		if err := json.Unmarshal(respBody, result); err != nil {
-			return fmt.Errorf("failed to decode response: %w", err)
+// retryDelay returns how long to wait before the given retry attempt. A Retry-After header sent
+// with the previous response takes precedence over the exponential backoff, up to RetryWaitMax so
+// that a server cannot stall the caller for longer than configured.
+func (c *HTTPClient) retryDelay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			delay := time.Duration(seconds) * time.Second
+			if c.config.RetryWaitMax > 0 && delay > c.config.RetryWaitMax {
+				return c.config.RetryWaitMax
+			}
+			return delay
 		}
 	}
 
-	return nil
+	delay := c.config.RetryWaitMin
+	for i := 0; i < attempt; i++ {
+		delay *= 2
+		if c.config.RetryWaitMax > 0 && delay >= c.config.RetryWaitMax {
+			return c.config.RetryWaitMax
+		}
+	}
+	return delay
+}
+
+// sleepContext waits for the given duration or until the context is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
 
 // DoJSONRequest is a convenience method for JSON requests
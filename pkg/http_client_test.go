@@ -0,0 +1,207 @@
+package interlace
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingServer answers requests with the given statuses in turn, then 200, and records the
+// idempotency keys and bodies it received
+type recordingServer struct {
+	mu       sync.Mutex
+	statuses []int
+	keys     []string
+	bodies   []map[string]interface{}
+}
+
+func (s *recordingServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys = append(s.keys, r.Header.Get(IdempotencyKeyHeader))
+	var body map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&body)
+	s.bodies = append(s.bodies, body)
+
+	status := http.StatusOK
+	if len(s.statuses) > 0 {
+		status, s.statuses = s.statuses[0], s.statuses[1:]
+	}
+	w.WriteHeader(status)
+	w.Write([]byte(`{}`))
+}
+
+func newTestHTTPClient(t *testing.T, handler http.Handler, configure func(cfg *Config)) *HTTPClient {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	cfg := DefaultConfig()
+	cfg.BaseURL = srv.URL
+	cfg.MaxRetries = 0
+	cfg.RetryWaitMin = time.Millisecond
+	cfg.RetryWaitMax = time.Millisecond
+	if configure != nil {
+		configure(cfg)
+	}
+	return NewHTTPClient(cfg, "token")
+}
+
+func TestResolveIdempotencyKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		opts    RequestOptions
+		key     string
+		bodyKey string
+	}{
+		{
+			name:    "request options win",
+			ctx:     ContextWithIdempotencyKey(context.Background(), "ctx-key"),
+			opts:    RequestOptions{IdempotencyKey: "opts-key", Body: &CardTransferInRequest{MerchantTradeNo: "body-key"}},
+			key:     "opts-key",
+			bodyKey: "body-key",
+		},
+		{
+			name:    "then the body",
+			ctx:     ContextWithIdempotencyKey(context.Background(), "ctx-key"),
+			opts:    RequestOptions{Body: &CardTransferInRequest{MerchantTradeNo: "body-key"}},
+			key:     "body-key",
+			bodyKey: "body-key",
+		},
+		{
+			name:    "then the context, written into an empty body field",
+			ctx:     ContextWithIdempotencyKey(context.Background(), "ctx-key"),
+			opts:    RequestOptions{Body: &CardTransferInRequest{}},
+			key:     "ctx-key",
+			bodyKey: "ctx-key",
+		},
+		{
+			name:    "explicit key written into an empty body field",
+			ctx:     context.Background(),
+			opts:    RequestOptions{IdempotencyKey: "opts-key", Body: &CardTransferInRequest{}},
+			key:     "opts-key",
+			bodyKey: "opts-key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			caller := *tt.opts.Body.(*CardTransferInRequest)
+			key, body := resolveIdempotencyKey(tt.ctx, &tt.opts)
+			assert.Equal(t, tt.key, key)
+			assert.Equal(t, tt.bodyKey, body.(*CardTransferInRequest).MerchantTradeNo)
+			assert.Equal(t, caller, *tt.opts.Body.(*CardTransferInRequest), "the caller's request is not modified")
+		})
+	}
+
+	key, body := resolveIdempotencyKey(context.Background(), &RequestOptions{Body: map[string]string{"a": "b"}})
+	assert.Len(t, key, 32, "a key is generated")
+	assert.Equal(t, map[string]string{"a": "b"}, body)
+}
+
+func TestDoRequestIdempotencyKey(t *testing.T) {
+	server := &recordingServer{}
+	client := newTestHTTPClient(t, server, nil)
+
+	req := &CardTransferInRequest{CardID: "card-1", Amount: 10}
+	var meta ResponseMeta
+	ctx := ContextWithCallOptions(context.Background(), WithResponseMeta(&meta))
+	require.NoError(t, client.DoRequest(ctx, &RequestOptions{Method: http.MethodPost, Endpoint: "/transfer", Body: req}, nil))
+	require.NoError(t, client.DoRequest(ctx, &RequestOptions{Method: http.MethodPost, Endpoint: "/transfer", Body: req}, nil))
+	require.NoError(t, client.DoRequest(context.Background(), &RequestOptions{Method: http.MethodGet, Endpoint: "/cards"}, nil))
+
+	require.Len(t, server.keys, 3)
+	assert.NotEmpty(t, server.keys[0])
+	assert.Equal(t, server.keys[0], server.bodies[0]["merchantTradeNo"], "the key is sent in the header and the body")
+	assert.NotEqual(t, server.keys[0], server.keys[1], "a reused request gets a new key")
+	assert.Equal(t, server.keys[1], meta.IdempotencyKey)
+	assert.Empty(t, req.MerchantTradeNo, "the caller's request is not modified")
+	assert.Empty(t, server.keys[2], "GET requests have no key")
+}
+
+func TestDoRequestDisabledIdempotencyKeys(t *testing.T) {
+	server := &recordingServer{}
+	client := newTestHTTPClient(t, server, func(cfg *Config) { cfg.DisableIdempotencyKeys = true })
+
+	require.NoError(t, client.DoRequest(context.Background(),
+		&RequestOptions{Method: http.MethodPost, Endpoint: "/transfer", Body: &CardTransferInRequest{CardID: "card-1"}}, nil))
+	assert.Equal(t, []string{""}, server.keys)
+	assert.NotContains(t, server.bodies[0], "merchantTradeNo")
+}
+
+func TestDoRequestRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		statuses []int
+		noKeys   bool
+		attempts int
+		err      bool
+	}{
+		{name: "GET retried on 5xx", method: http.MethodGet, statuses: []int{503, 502}, attempts: 3},
+		{name: "GET not retried on 4xx", method: http.MethodGet, statuses: []int{404}, attempts: 1, err: true},
+		{name: "POST retried on 5xx with its key", method: http.MethodPost, statuses: []int{500}, attempts: 2},
+		{name: "POST without key not retried on 5xx", method: http.MethodPost, statuses: []int{500}, noKeys: true, attempts: 1, err: true},
+		{name: "POST without key retried when rate limited", method: http.MethodPost, statuses: []int{429}, noKeys: true, attempts: 2},
+		{name: "retries exhausted", method: http.MethodGet, statuses: []int{500, 500, 500}, attempts: 3, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &recordingServer{statuses: tt.statuses}
+			client := newTestHTTPClient(t, server, func(cfg *Config) {
+				cfg.MaxRetries = 2
+				cfg.DisableIdempotencyKeys = tt.noKeys
+			})
+
+			var meta ResponseMeta
+			err := client.DoRequest(ContextWithCallOptions(context.Background(), WithResponseMeta(&meta)),
+				&RequestOptions{Method: tt.method, Endpoint: "/resource"}, nil)
+			if tt.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			require.Len(t, server.keys, tt.attempts)
+			assert.Equal(t, tt.attempts, meta.Attempts)
+			for _, key := range server.keys {
+				assert.Equal(t, server.keys[0], key, "retries resend the same key")
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	client := NewHTTPClient(&Config{RetryWaitMin: 100 * time.Millisecond, RetryWaitMax: time.Second}, "")
+	retryAfter := func(value string) *http.Response {
+		return &http.Response{Header: http.Header{"Retry-After": []string{value}}}
+	}
+
+	tests := []struct {
+		name    string
+		attempt int
+		resp    *http.Response
+		want    time.Duration
+	}{
+		{name: "first retry", attempt: 0, want: 100 * time.Millisecond},
+		{name: "backoff doubles", attempt: 2, want: 400 * time.Millisecond},
+		{name: "backoff capped", attempt: 10, want: time.Second},
+		{name: "Retry-After", attempt: 0, resp: retryAfter("0"), want: 0},
+		{name: "Retry-After capped", attempt: 0, resp: retryAfter("3600"), want: time.Second},
+		{name: "invalid Retry-After", attempt: 1, resp: retryAfter("soon"), want: 200 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, client.retryDelay(tt.attempt, tt.resp))
+		})
+	}
+}
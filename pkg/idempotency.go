@@ -0,0 +1,158 @@
+package interlace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+// IdempotencyKeyHeader is the HTTP header that carries the idempotency key of a mutating request
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// NewIdempotencyKey generates a random idempotency key
+func NewIdempotencyKey() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic(fmt.Sprintf("interlace: failed to generate idempotency key: %v", err))
+	}
+	return hex.EncodeToString(buf)
+}
+
+// ContextWithIdempotencyKey returns a context that makes mutating requests use the given key instead
+// of a generated one. Every request made with the returned context shares the key, so use one
+// context per operation. It is a shorthand for ContextWithCallOptions(ctx, WithIdempotencyKey(key)).
+func ContextWithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return ContextWithCallOptions(ctx, WithIdempotencyKey(key))
+}
+
+// IdempotencyKeyFromContext returns the idempotency key stored in the context, if any
+func IdempotencyKeyFromContext(ctx context.Context) string {
+	return callOptionsFromContext(ctx).idempotencyKey
+}
+
+// IdempotencyKeyFromError returns the idempotency key of the request that produced err.
+// Retrying the operation with the same key (see ContextWithIdempotencyKey) is safe.
+func IdempotencyKeyFromError(err error) string {
+	var apiErr *Error
+	if errors.As(err, &apiErr) && apiErr.IdempotencyKey != "" {
+		return apiErr.IdempotencyKey
+	}
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.IdempotencyKey
+	}
+	return ""
+}
+
+// RequestError is returned when a mutating request could not be completed, for example because
+// of a network failure. The outcome on the server is unknown, so the request should be retried
+// with the same idempotency key.
+type RequestError struct {
+	IdempotencyKey string
+	Err            error
+}
+
+func (e *RequestError) Error() string {
+	return fmt.Sprintf("%v (idempotency key: %s)", e.Err, e.IdempotencyKey)
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// idempotentRequest is implemented by request bodies that carry their own idempotency field
+// (idempotencyKey, merchantTradeNo, merchantTransferNo or merchantRefundNo). When the caller left
+// the field empty, the SDK sends the request's idempotency key in it. The key is set on a copy of
+// the request, so that a request reused for another call gets a new key; read it back with
+// WithResponseMeta or IdempotencyKeyFromError.
+type idempotentRequest interface {
+	idempotencyKey() string
+	setIdempotencyKey(key string)
+}
+
+// isMutatingMethod reports whether requests with the given method get an idempotency key
+func isMutatingMethod(method string) bool {
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// resolveIdempotencyKey picks the idempotency key for a request. An explicit key in the request
+// options wins, followed by the key already set on the request body, the key stored in the
+// context and finally a newly generated one. It returns the body to send: a copy carrying the
+// key when the body has an empty idempotency field, the body itself otherwise.
+func resolveIdempotencyKey(ctx context.Context, opts *RequestOptions) (string, interface{}) {
+	body, _ := opts.Body.(idempotentRequest)
+
+	key := opts.IdempotencyKey
+	if key == "" && body != nil {
+		key = body.idempotencyKey()
+	}
+	if key == "" {
+		key = IdempotencyKeyFromContext(ctx)
+	}
+	if key == "" {
+		key = NewIdempotencyKey()
+	}
+
+	if body != nil && body.idempotencyKey() == "" {
+		if copied, ok := shallowCopy(opts.Body).(idempotentRequest); ok {
+			copied.setIdempotencyKey(key)
+			return key, copied
+		}
+	}
+	return key, opts.Body
+}
+
+// shallowCopy returns a copy of the struct a pointer points to
+func shallowCopy(v interface{}) interface{} {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return v
+	}
+	copied := reflect.New(value.Elem().Type())
+	copied.Elem().Set(value.Elem())
+	return copied.Interface()
+}
+
+func (r *CreateWalletRequest) idempotencyKey() string           { return r.IdempotencyKey }
+func (r *CreateWalletRequest) setIdempotencyKey(key string)     { r.IdempotencyKey = key }
+func (r *CreateTransferRequest) idempotencyKey() string         { return r.IdempotencyKey }
+func (r *CreateTransferRequest) setIdempotencyKey(key string)   { r.IdempotencyKey = key }
+func (r *CreateCardholderRequest) idempotencyKey() string       { return r.IdempotencyKey }
+func (r *CreateCardholderRequest) setIdempotencyKey(key string) { r.IdempotencyKey = key }
+
+func (r *CardTransferInRequest) idempotencyKey() string              { return r.MerchantTradeNo }
+func (r *CardTransferInRequest) setIdempotencyKey(key string)        { r.MerchantTradeNo = key }
+func (r *CardTransferOutRequest) idempotencyKey() string             { return r.MerchantTradeNo }
+func (r *CardTransferOutRequest) setIdempotencyKey(key string)       { r.MerchantTradeNo = key }
+func (r *IncreaseBudgetBalanceRequest) idempotencyKey() string       { return r.MerchantTradeNo }
+func (r *IncreaseBudgetBalanceRequest) setIdempotencyKey(key string) { r.MerchantTradeNo = key }
+func (r *DecreaseBudgetBalanceRequest) idempotencyKey() string       { return r.MerchantTradeNo }
+func (r *DecreaseBudgetBalanceRequest) setIdempotencyKey(key string) { r.MerchantTradeNo = key }
+func (r *CreatePayoutRequest) idempotencyKey() string                { return r.MerchantTradeNo }
+func (r *CreatePayoutRequest) setIdempotencyKey(key string)          { r.MerchantTradeNo = key }
+func (r *AcceptQuotationRequest) idempotencyKey() string             { return r.MerchantTradeNo }
+func (r *AcceptQuotationRequest) setIdempotencyKey(key string)       { r.MerchantTradeNo = key }
+func (r *CreateConvertTradeRequest) idempotencyKey() string          { return r.MerchantTradeNo }
+func (r *CreateConvertTradeRequest) setIdempotencyKey(key string)    { r.MerchantTradeNo = key }
+
+func (r *IntraAccountTransferRequest) idempotencyKey() string           { return r.MerchantTransferNo }
+func (r *IntraAccountTransferRequest) setIdempotencyKey(key string)     { r.MerchantTransferNo = key }
+func (r *DifferentAccountTransferRequest) idempotencyKey() string       { return r.MerchantTransferNo }
+func (r *DifferentAccountTransferRequest) setIdempotencyKey(key string) { r.MerchantTransferNo = key }
+func (r *CreateBlockchainRefundRequest) idempotencyKey() string         { return r.MerchantRefundNo }
+func (r *CreateBlockchainRefundRequest) setIdempotencyKey(key string)   { r.MerchantRefundNo = key }
+
+// Payments and refunds require a caller-supplied merchant trade number, which doubles as their
+// idempotency key.
+func (r *CreatePaymentRequest) idempotencyKey() string       { return r.MerchantTradeNo }
+func (r *CreatePaymentRequest) setIdempotencyKey(key string) { r.MerchantTradeNo = key }
+func (r *CreateRefundRequest) idempotencyKey() string        { return r.MerchantTradeNo }
+func (r *CreateRefundRequest) setIdempotencyKey(key string)  { r.MerchantTradeNo = key }
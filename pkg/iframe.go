@@ -30,7 +30,9 @@ type CardAccessTokenResponse struct {
 }
 
 // GetCardAccessToken retrieves an access token for iframe card information display
-func (c *IframeClient) GetCardAccessToken(ctx context.Context, cardID string) (*CardAccessTokenResponse, error) {
+func (c *IframeClient) GetCardAccessToken(ctx context.Context, cardID string, callOpts ...CallOption) (*CardAccessTokenResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if cardID == "" {
 		return nil, fmt.Errorf("card ID is required")
 	}
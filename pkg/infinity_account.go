@@ -61,7 +61,9 @@ type InfinityAccountTransactionListResponse struct {
 
 // ListInfinityAccountTransactions retrieves all Infinity Account transactions with optional filtering
 // GET /open-api/v3/infinity-account/transactions
-func (c *InfinityAccountClient) ListInfinityAccountTransactions(ctx context.Context, options *ListInfinityAccountTransactionsOptions) (*InfinityAccountTransactionListResponse, error) {
+func (c *InfinityAccountClient) ListInfinityAccountTransactions(ctx context.Context, options *ListInfinityAccountTransactionsOptions, callOpts ...CallOption) (*InfinityAccountTransactionListResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	var queryParams url.Values
 	if options != nil {
 		queryParams = url.Values{}
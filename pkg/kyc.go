@@ -2,6 +2,7 @@ package interlace
 
 import (
 	"context"
+	"errors"
 	"fmt"
 )
 
@@ -20,7 +21,9 @@ func NewKYCClient(httpClient *HTTPClient) *KYCClient {
 
 
 // SubmitKYC submits KYC information for the specified account
-func (c *KYCClient) SubmitKYC(ctx context.Context, accountID string, req *KYCSubmitRequest) (*KYCSubmitData, error) {
+func (c *KYCClient) SubmitKYC(ctx context.Context, accountID string, req *KYCSubmitRequest, callOpts ...CallOption) (*KYCSubmitData, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	endpoint := fmt.Sprintf("/open-api/v3/accounts/%s/kyc", accountID)
 	
 	var kycResp KYCSubmitResponse
@@ -41,7 +44,9 @@ func (c *KYCClient) SubmitKYC(ctx context.Context, accountID string, req *KYCSub
 }
 
 // GetKYCStatus retrieves the KYC status for the specified account
-func (c *KYCClient) GetKYCStatus(ctx context.Context, accountID string) (*KYCStatusData, error) {
+func (c *KYCClient) GetKYCStatus(ctx context.Context, accountID string, callOpts ...CallOption) (*KYCStatusData, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	endpoint := fmt.Sprintf("/open-api/v3/accounts/%s/kyc", accountID)
 	
 	var statusResp KYCStatusResponse
@@ -62,7 +67,9 @@ func (c *KYCClient) GetKYCStatus(ctx context.Context, accountID string) (*KYCSta
 }
 
 // IsKYCApproved checks if the account's KYC is approved
-func (c *KYCClient) IsKYCApproved(ctx context.Context, accountID string) (bool, error) {
+func (c *KYCClient) IsKYCApproved(ctx context.Context, accountID string, callOpts ...CallOption) (bool, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	status, err := c.GetKYCStatus(ctx, accountID)
 	if err != nil {
 		return false, err
@@ -72,7 +79,9 @@ func (c *KYCClient) IsKYCApproved(ctx context.Context, accountID string) (bool,
 }
 
 // IsKYCPending checks if the account's KYC is pending
-func (c *KYCClient) IsKYCPending(ctx context.Context, accountID string) (bool, error) {
+func (c *KYCClient) IsKYCPending(ctx context.Context, accountID string, callOpts ...CallOption) (bool, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	status, err := c.GetKYCStatus(ctx, accountID)
 	if err != nil {
 		return false, err
@@ -82,7 +91,9 @@ func (c *KYCClient) IsKYCPending(ctx context.Context, accountID string) (bool, e
 }
 
 // IsKYCRejected checks if the account's KYC is rejected
-func (c *KYCClient) IsKYCRejected(ctx context.Context, accountID string) (bool, error) {
+func (c *KYCClient) IsKYCRejected(ctx context.Context, accountID string, callOpts ...CallOption) (bool, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	status, err := c.GetKYCStatus(ctx, accountID)
 	if err != nil {
 		return false, err
@@ -91,35 +102,62 @@ func (c *KYCClient) IsKYCRejected(ctx context.Context, accountID string) (bool,
 	return status.Status == KYCStatusRejected, nil
 }
 
-// WaitForKYCApproval waits for KYC approval with polling
-// This is a convenience method for testing/development
-func (c *KYCClient) WaitForKYCApproval(ctx context.Context, accountID string, maxAttempts int) (*KYCStatusData, error) {
-	for i := 0; i < maxAttempts; i++ {
-		status, err := c.GetKYCStatus(ctx, accountID)
-		if err != nil {
-			return nil, err
-		}
-
-		switch status.Status {
-		case KYCStatusApproved:
-			return status, nil
-		case KYCStatusRejected:
-			return status, fmt.Errorf("KYC was rejected: %s", status.RejectionReason)
-		case KYCStatusExpired:
-			return status, fmt.Errorf("KYC has expired")
-		case KYCStatusPending:
-			// Continue polling
-			continue
-		default:
-			return status, fmt.Errorf("unknown KYC status: %s", status.Status)
-		}
-	}
-
-	return nil, fmt.Errorf("KYC approval timeout after %d attempts", maxAttempts)
+// WaitForKYCApproval waits for KYC approval, polling at most maxAttempts times with backoff.
+// A maxAttempts of zero or less polls until ctx is done. See WaitForKYC for more options.
+func (c *KYCClient) WaitForKYCApproval(ctx context.Context, accountID string, maxAttempts int, callOpts ...CallOption) (*KYCStatusData, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
+	status, err := c.WaitForKYC(ctx, accountID, &WaitOptions[KYCStatusData]{MaxPolls: maxAttempts})
+	if errors.Is(err, ErrMaxPollsReached) {
+		return status, fmt.Errorf("KYC approval timeout after %d attempts: %w", maxAttempts, err)
+	}
+	return status, err
+}
+
+// WaitForKYC waits until the KYC review of the account is approved, rejected or expired. It
+// returns an *OperationError carrying the rejection reason when the KYC is not approved.
+// Set opts.OnProgress to be notified of status transitions, and opts.Webhooks to re-check the
+// status as soon as an account.updated event arrives. opts may be nil.
+//
+//	status, err := client.KYC.WaitForKYC(ctx, accountID, &interlace.WaitOptions[interlace.KYCStatusData]{
+//		MaxPollInterval: time.Minute,
+//		OnProgress:      func(s *interlace.KYCStatusData) { log.Printf("KYC %s", s.Status) },
+//		Webhooks:        webhookServer,
+//	})
+func (c *KYCClient) WaitForKYC(ctx context.Context, accountID string, opts *WaitOptions[KYCStatusData]) (*KYCStatusData, error) {
+	if accountID == "" {
+		return nil, fmt.Errorf("account ID cannot be empty")
+	}
+
+	return c.KYCOperation(accountID).Wait(ctx, opts)
+}
+
+// KYCOperation returns an operation that tracks the KYC review of an account
+func (c *KYCClient) KYCOperation(accountID string) *Operation[KYCStatusData] {
+	return newOperation(accountID, nil, operationSpec[KYCStatusData]{
+		poll: func(ctx context.Context) (*KYCStatusData, error) {
+			return c.GetKYCStatus(ctx, accountID)
+		},
+		state: func(s *KYCStatusData) (OperationState, string, string) {
+			switch s.Status {
+			case KYCStatusApproved:
+				return OperationSucceeded, s.Status, ""
+			case KYCStatusRejected:
+				return OperationFailed, s.Status, s.RejectionReason
+			case KYCStatusExpired:
+				return OperationFailed, s.Status, "KYC has expired"
+			}
+			return OperationPending, s.Status, ""
+		},
+		eventKeys:  []string{"accountId", "id"},
+		eventTypes: []string{EventAccountUpdated},
+	})
 }
 
 // GetCDDDetail retrieves comprehensive CDD (Customer Due Diligence) details including KYC and KYB verification results
-func (c *KYCClient) GetCDDDetail(ctx context.Context, accountID string) (*CDDDetailData, error) {
+func (c *KYCClient) GetCDDDetail(ctx context.Context, accountID string, callOpts ...CallOption) (*CDDDetailData, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	endpoint := fmt.Sprintf("/open-api/v3/accounts/cdd/detail/%s", accountID)
 	
 	var cddResp CDDDetailResponse
@@ -140,7 +178,9 @@ func (c *KYCClient) GetCDDDetail(ctx context.Context, accountID string) (*CDDDet
 }
 
 // GetKYCVerificationDetail extracts KYC verification details from CDD data
-func (c *KYCClient) GetKYCVerificationDetail(ctx context.Context, accountID string) (*KYCVerificationDetail, error) {
+func (c *KYCClient) GetKYCVerificationDetail(ctx context.Context, accountID string, callOpts ...CallOption) (*KYCVerificationDetail, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	cddDetail, err := c.GetCDDDetail(ctx, accountID)
 	if err != nil {
 		return nil, err
@@ -154,7 +194,9 @@ func (c *KYCClient) GetKYCVerificationDetail(ctx context.Context, accountID stri
 }
 
 // GetKYBVerificationDetail extracts KYB verification details from CDD data
-func (c *KYCClient) GetKYBVerificationDetail(ctx context.Context, accountID string) (*KYBVerificationDetail, error) {
+func (c *KYCClient) GetKYBVerificationDetail(ctx context.Context, accountID string, callOpts ...CallOption) (*KYBVerificationDetail, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	cddDetail, err := c.GetCDDDetail(ctx, accountID)
 	if err != nil {
 		return nil, err
@@ -168,7 +210,9 @@ func (c *KYCClient) GetKYBVerificationDetail(ctx context.Context, accountID stri
 }
 
 // GetRiskAssessment extracts risk assessment from CDD data
-func (c *KYCClient) GetRiskAssessment(ctx context.Context, accountID string) (*RiskAssessment, error) {
+func (c *KYCClient) GetRiskAssessment(ctx context.Context, accountID string, callOpts ...CallOption) (*RiskAssessment, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	cddDetail, err := c.GetCDDDetail(ctx, accountID)
 	if err != nil {
 		return nil, err
@@ -188,7 +232,9 @@ func (c *KYCClient) GetRiskAssessment(ctx context.Context, accountID string) (*R
 }
 
 // IsHighRisk checks if the account is classified as high risk
-func (c *KYCClient) IsHighRisk(ctx context.Context, accountID string) (bool, error) {
+func (c *KYCClient) IsHighRisk(ctx context.Context, accountID string, callOpts ...CallOption) (bool, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	riskAssessment, err := c.GetRiskAssessment(ctx, accountID)
 	if err != nil {
 		return false, err
@@ -198,7 +244,9 @@ func (c *KYCClient) IsHighRisk(ctx context.Context, accountID string) (bool, err
 }
 
 // GetVerificationChecks extracts verification checks from KYC data
-func (c *KYCClient) GetVerificationChecks(ctx context.Context, accountID string) (*VerificationChecks, error) {
+func (c *KYCClient) GetVerificationChecks(ctx context.Context, accountID string, callOpts ...CallOption) (*VerificationChecks, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	kycDetail, err := c.GetKYCVerificationDetail(ctx, accountID)
 	if err != nil {
 		return nil, err
@@ -212,7 +260,9 @@ func (c *KYCClient) GetVerificationChecks(ctx context.Context, accountID string)
 }
 
 // GetComplianceChecks extracts compliance checks from KYB data
-func (c *KYCClient) GetComplianceChecks(ctx context.Context, accountID string) (*ComplianceChecks, error) {
+func (c *KYCClient) GetComplianceChecks(ctx context.Context, accountID string, callOpts ...CallOption) (*ComplianceChecks, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	kybDetail, err := c.GetKYBVerificationDetail(ctx, accountID)
 	if err != nil {
 		return nil, err
@@ -226,7 +276,9 @@ func (c *KYCClient) GetComplianceChecks(ctx context.Context, accountID string) (
 }
 
 // HasPassedAllChecks verifies if all required verification checks have passed
-func (c *KYCClient) HasPassedAllChecks(ctx context.Context, accountID string) (bool, []string, error) {
+func (c *KYCClient) HasPassedAllChecks(ctx context.Context, accountID string, callOpts ...CallOption) (bool, []string, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	var failedChecks []string
 
 	// Check KYC verification checks
@@ -0,0 +1,335 @@
+package interlace
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ledgerTolerance is the largest balance difference considered equal, absorbing float rounding
+const ledgerTolerance = 0.005
+
+// ledgerReorderWindow is how far apart in time two transactions may be recorded and still be
+// reported as out of order rather than as a gap
+const ledgerReorderWindow = time.Minute
+
+// LedgerIssueKind classifies the inconsistencies found in a transaction history
+type LedgerIssueKind string
+
+// Ledger issue kinds
+const (
+	// LedgerGap means a transaction's balance before does not continue the previous balance
+	// after, usually because transactions are missing in between
+	LedgerGap LedgerIssueKind = "gap"
+	// LedgerAmountMismatch means a transaction's balance change differs from its amount
+	LedgerAmountMismatch LedgerIssueKind = "amount_mismatch"
+	// LedgerDuplicate means a transaction ID was returned more than once
+	LedgerDuplicate LedgerIssueKind = "duplicate"
+	// LedgerOutOfOrder means a transaction is recorded after a transaction that continues its
+	// balance
+	LedgerOutOfOrder LedgerIssueKind = "out_of_order"
+)
+
+// LedgerEntry is a transaction as seen by the ledger check
+type LedgerEntry struct {
+	TransactionID string
+	Currency      string
+	// Amount is signed: positive for credits, negative for debits
+	Amount        float64
+	BalanceBefore float64
+	BalanceAfter  float64
+	Time          time.Time
+	Status        string
+}
+
+// LedgerIssue is an inconsistency found in a transaction history
+type LedgerIssue struct {
+	Kind          LedgerIssueKind
+	Currency      string
+	TransactionID string
+	// PreviousID is the transaction the issue is relative to: the one before a gap, the first
+	// occurrence of a duplicate or the transaction recorded before an out of order one that
+	// continues its balance
+	PreviousID string
+	// Expected and Actual are the compared values: the previous balance after and the balance
+	// before for gaps, the amount and the balance change for amount mismatches
+	Expected float64
+	Actual   float64
+	// Difference is Actual - Expected. For gaps, it is the net amount of the missing records.
+	Difference float64
+}
+
+// String describes the issue
+func (i LedgerIssue) String() string {
+	switch i.Kind {
+	case LedgerGap:
+		return fmt.Sprintf("%s %s: balance before %.2f does not follow balance after %.2f of %s (%+.2f missing)",
+			i.Currency, i.TransactionID, i.Actual, i.Expected, i.PreviousID, i.Difference)
+	case LedgerAmountMismatch:
+		return fmt.Sprintf("%s %s: balance changed by %.2f but amount is %.2f", i.Currency, i.TransactionID, i.Actual, i.Expected)
+	case LedgerDuplicate:
+		return fmt.Sprintf("%s %s: duplicate transaction", i.Currency, i.TransactionID)
+	case LedgerOutOfOrder:
+		return fmt.Sprintf("%s %s: recorded after %s but precedes it", i.Currency, i.TransactionID, i.PreviousID)
+	}
+	return fmt.Sprintf("%s %s: %s", i.Currency, i.TransactionID, i.Kind)
+}
+
+// LedgerSummary summarizes the history of one currency
+type LedgerSummary struct {
+	Currency       string
+	Transactions   int
+	OpeningBalance float64
+	ClosingBalance float64
+	FirstTime      time.Time
+	LastTime       time.Time
+}
+
+// LedgerReport is the result of a ledger check
+type LedgerReport struct {
+	AccountID    string
+	Transactions int
+	// Ledgers summarizes each currency of the account
+	Ledgers map[string]*LedgerSummary
+	Issues  []LedgerIssue
+}
+
+// OK reports whether the history is continuous
+func (r *LedgerReport) OK() bool {
+	return len(r.Issues) == 0
+}
+
+// IssuesOf returns the issues of a kind
+func (r *LedgerReport) IssuesOf(kind LedgerIssueKind) []LedgerIssue {
+	var issues []LedgerIssue
+	for _, issue := range r.Issues {
+		if issue.Kind == kind {
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
+
+// LedgerCheckRequest selects the history to check
+type LedgerCheckRequest struct {
+	AccountID string
+	// Currency restricts the check to one currency
+	Currency string
+	// From and To bound the history. The whole history is checked when they are zero.
+	From time.Time
+	To   time.Time
+}
+
+// CheckLedger verifies the continuity of entries. Entries are checked per currency in time
+// order, with the given order breaking ties: each balance before must equal the previous
+// balance after, and each balance change must equal the amount. Failed transactions and
+// pending ones that have not moved the balance yet are ignored.
+func CheckLedger(accountID string, entries []LedgerEntry) *LedgerReport {
+	report := &LedgerReport{AccountID: accountID, Ledgers: make(map[string]*LedgerSummary)}
+
+	seen := make(map[string]bool)
+	byCurrency := make(map[string][]LedgerEntry)
+	var currencies []string
+	for _, entry := range entries {
+		if isFailedStatus(entry.Status) || (statusState(entry.Status) == OperationPending &&
+			math.Abs(entry.BalanceAfter-entry.BalanceBefore) <= ledgerTolerance) {
+			continue
+		}
+		entry.Currency = strings.ToUpper(entry.Currency)
+		if entry.TransactionID != "" {
+			if seen[entry.TransactionID] {
+				report.Issues = append(report.Issues, LedgerIssue{
+					Kind:          LedgerDuplicate,
+					Currency:      entry.Currency,
+					TransactionID: entry.TransactionID,
+					PreviousID:    entry.TransactionID,
+				})
+				continue
+			}
+			seen[entry.TransactionID] = true
+		}
+		if _, ok := byCurrency[entry.Currency]; !ok {
+			currencies = append(currencies, entry.Currency)
+		}
+		byCurrency[entry.Currency] = append(byCurrency[entry.Currency], entry)
+	}
+	sort.Strings(currencies)
+
+	for _, currency := range currencies {
+		ledger := byCurrency[currency]
+		sort.SliceStable(ledger, func(i, j int) bool {
+			return ledger[i].Time.Before(ledger[j].Time)
+		})
+		report.Transactions += len(ledger)
+		report.Issues = append(report.Issues, checkLedgerChain(ledger)...)
+		report.Ledgers[currency] = &LedgerSummary{
+			Currency:       currency,
+			Transactions:   len(ledger),
+			OpeningBalance: ledger[0].BalanceBefore,
+			ClosingBalance: ledger[len(ledger)-1].BalanceAfter,
+			FirstTime:      ledger[0].Time,
+			LastTime:       ledger[len(ledger)-1].Time,
+		}
+	}
+	return report
+}
+
+// checkLedgerChain checks a single currency ledger sorted by time. When a transaction does not
+// continue the previous one, a later transaction recorded within ledgerReorderWindow that does
+// is moved before it and reported as out of order; otherwise the break is reported as a gap.
+func checkLedgerChain(ledger []LedgerEntry) []LedgerIssue {
+	var issues []LedgerIssue
+	for i := range ledger {
+		if i > 0 {
+			previous := &ledger[i-1]
+			if math.Abs(ledger[i].BalanceBefore-previous.BalanceAfter) > ledgerTolerance {
+				next := -1
+				for j := i + 1; j < len(ledger) && ledger[j].Time.Sub(ledger[i].Time) <= ledgerReorderWindow; j++ {
+					if math.Abs(ledger[j].BalanceBefore-previous.BalanceAfter) <= ledgerTolerance {
+						next = j
+						break
+					}
+				}
+				if next >= 0 {
+					moved := ledger[next]
+					copy(ledger[i+1:next+1], ledger[i:next])
+					ledger[i] = moved
+					issues = append(issues, LedgerIssue{
+						Kind:          LedgerOutOfOrder,
+						Currency:      moved.Currency,
+						TransactionID: moved.TransactionID,
+						PreviousID:    ledger[i+1].TransactionID,
+					})
+				} else {
+					issues = append(issues, LedgerIssue{
+						Kind:          LedgerGap,
+						Currency:      ledger[i].Currency,
+						TransactionID: ledger[i].TransactionID,
+						PreviousID:    previous.TransactionID,
+						Expected:      previous.BalanceAfter,
+						Actual:        ledger[i].BalanceBefore,
+						Difference:    roundCents(ledger[i].BalanceBefore - previous.BalanceAfter),
+					})
+				}
+			}
+		}
+
+		entry := &ledger[i]
+		if change := entry.BalanceAfter - entry.BalanceBefore; math.Abs(change-entry.Amount) > ledgerTolerance {
+			issues = append(issues, LedgerIssue{
+				Kind:          LedgerAmountMismatch,
+				Currency:      entry.Currency,
+				TransactionID: entry.TransactionID,
+				Expected:      entry.Amount,
+				Actual:        roundCents(change),
+				Difference:    roundCents(change - entry.Amount),
+			})
+		}
+	}
+	return issues
+}
+
+// ledgerAmount signs the amount of a DEBIT or CREDIT transaction. Transactions without a type
+// are signed by their balance change.
+func ledgerAmount(transactionType string, amount, before, after float64) float64 {
+	switch strings.ToUpper(transactionType) {
+	case "CREDIT":
+		return math.Abs(amount)
+	case "DEBIT":
+		return -math.Abs(amount)
+	}
+	if after < before {
+		return -math.Abs(amount)
+	}
+	return math.Abs(amount)
+}
+
+// ledgerPeriod formats the bounds of a ledger check request for the list endpoints
+func ledgerPeriod(req *LedgerCheckRequest) (string, string) {
+	var start, end string
+	if !req.From.IsZero() {
+		start = req.From.Format(time.RFC3339)
+	}
+	if !req.To.IsZero() {
+		end = req.To.Format(time.RFC3339)
+	}
+	return start, end
+}
+
+// CheckLedger walks the transaction history of a business account and verifies its continuity,
+// reporting gaps, amount mismatches, duplicates and out of order transactions
+func (c *BusinessAccountClient) CheckLedger(ctx context.Context, req *LedgerCheckRequest, callOpts ...CallOption) (*LedgerReport, error) {
+	if req == nil || req.AccountID == "" {
+		return nil, fmt.Errorf("account ID is required")
+	}
+	options := &ListBusinessAccountTransactionsOptions{AccountID: req.AccountID, Limit: selectPageSize}
+	options.StartTime, options.EndTime = ledgerPeriod(req)
+
+	var entries []LedgerEntry
+	err := walkPages(func(page int) (int, int, error) {
+		options.Page = page
+		list, err := c.GetAccountTransactions(ctx, options, callOpts...)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to list transactions (page %d): %w", page, err)
+		}
+		for _, tx := range list.Transactions {
+			if req.Currency != "" && !strings.EqualFold(tx.Currency, req.Currency) {
+				continue
+			}
+			created, _ := parseAPITime(tx.CreatedAt)
+			entries = append(entries, LedgerEntry{
+				TransactionID: tx.TransactionID,
+				Currency:      tx.Currency,
+				Amount:        ledgerAmount(tx.Type, tx.Amount, tx.BalanceBefore, tx.BalanceAfter),
+				BalanceBefore: tx.BalanceBefore,
+				BalanceAfter:  tx.BalanceAfter,
+				Time:          created,
+				Status:        tx.Status,
+			})
+		}
+		return len(list.Transactions), list.TotalCount, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return CheckLedger(req.AccountID, entries), nil
+}
+
+// CheckLedger walks the transaction history of an Infinity Account and verifies its continuity,
+// reporting gaps, amount mismatches, duplicates and out of order transactions
+func (c *InfinityAccountClient) CheckLedger(ctx context.Context, req *LedgerCheckRequest, callOpts ...CallOption) (*LedgerReport, error) {
+	if req == nil || req.AccountID == "" {
+		return nil, fmt.Errorf("account ID is required")
+	}
+	options := &ListInfinityAccountTransactionsOptions{AccountID: req.AccountID, Currency: req.Currency, Limit: selectPageSize}
+	options.StartTime, options.EndTime = ledgerPeriod(req)
+
+	var entries []LedgerEntry
+	err := walkPages(func(page int) (int, int, error) {
+		options.Page = page
+		list, err := c.ListInfinityAccountTransactions(ctx, options, callOpts...)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to list Infinity Account transactions (page %d): %w", page, err)
+		}
+		for _, tx := range list.Transactions {
+			created, _ := parseAPITime(tx.CreatedAt)
+			entries = append(entries, LedgerEntry{
+				TransactionID: tx.TransactionID,
+				Currency:      tx.Currency,
+				Amount:        ledgerAmount(tx.Type, tx.Amount, tx.BalanceBefore, tx.BalanceAfter),
+				BalanceBefore: tx.BalanceBefore,
+				BalanceAfter:  tx.BalanceAfter,
+				Time:          created,
+				Status:        tx.Status,
+			})
+		}
+		return len(list.Transactions), list.TotalCount, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return CheckLedger(req.AccountID, entries), nil
+}
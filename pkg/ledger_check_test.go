@@ -0,0 +1,100 @@
+package interlace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckLedger(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time {
+		return start.Add(time.Duration(minutes) * time.Minute)
+	}
+	entry := func(id string, minutes int, before, after float64) LedgerEntry {
+		return LedgerEntry{TransactionID: id, Currency: "usd", Amount: after - before, BalanceBefore: before,
+			BalanceAfter: after, Time: at(minutes), Status: "COMPLETED"}
+	}
+
+	tests := []struct {
+		name    string
+		entries []LedgerEntry
+		issues  []LedgerIssue
+	}{
+		{
+			name:    "continuous history",
+			entries: []LedgerEntry{entry("a", 0, 0, 100), entry("b", 10, 100, 70), entry("c", 20, 70, 90)},
+		},
+		{
+			name:    "gap",
+			entries: []LedgerEntry{entry("a", 0, 0, 100), entry("b", 10, 150, 120)},
+			issues: []LedgerIssue{{Kind: LedgerGap, Currency: "USD", TransactionID: "b", PreviousID: "a",
+				Expected: 100, Actual: 150, Difference: 50}},
+		},
+		{
+			name:    "out of order within the window",
+			entries: []LedgerEntry{entry("a", 0, 0, 100), entry("c", 0, 80, 90), entry("b", 0, 100, 80)},
+			issues:  []LedgerIssue{{Kind: LedgerOutOfOrder, Currency: "USD", TransactionID: "b", PreviousID: "c"}},
+		},
+		{
+			name:    "distant continuation is a gap",
+			entries: []LedgerEntry{entry("a", 0, 0, 100), entry("b", 10, 150, 120), entry("c", 600, 100, 130)},
+			issues: []LedgerIssue{
+				{Kind: LedgerGap, Currency: "USD", TransactionID: "b", PreviousID: "a", Expected: 100, Actual: 150, Difference: 50},
+				{Kind: LedgerGap, Currency: "USD", TransactionID: "c", PreviousID: "b", Expected: 120, Actual: 100, Difference: -20},
+			},
+		},
+		{
+			name: "amount mismatch",
+			entries: []LedgerEntry{entry("a", 0, 0, 100),
+				{TransactionID: "b", Currency: "USD", Amount: -20, BalanceBefore: 100, BalanceAfter: 75, Time: at(10)}},
+			issues: []LedgerIssue{{Kind: LedgerAmountMismatch, Currency: "USD", TransactionID: "b",
+				Expected: -20, Actual: -25, Difference: -5}},
+		},
+		{
+			name:    "duplicate",
+			entries: []LedgerEntry{entry("a", 0, 0, 100), entry("a", 0, 0, 100), entry("b", 10, 100, 50)},
+			issues:  []LedgerIssue{{Kind: LedgerDuplicate, Currency: "USD", TransactionID: "a", PreviousID: "a"}},
+		},
+		{
+			name: "failed and unmoved pending entries are ignored",
+			entries: []LedgerEntry{entry("a", 0, 0, 100),
+				{TransactionID: "f", Currency: "USD", Amount: -500, BalanceBefore: 100, BalanceAfter: 100, Time: at(5), Status: "FAILED"},
+				{TransactionID: "p", Currency: "USD", Amount: -30, BalanceBefore: 100, BalanceAfter: 100, Time: at(6), Status: "PENDING"},
+				entry("b", 10, 100, 40)},
+		},
+		{
+			name: "pending entries that moved the balance are checked",
+			entries: []LedgerEntry{entry("a", 0, 0, 100),
+				{TransactionID: "p", Currency: "USD", Amount: -30, BalanceBefore: 100, BalanceAfter: 60, Time: at(6), Status: "PENDING"}},
+			issues: []LedgerIssue{{Kind: LedgerAmountMismatch, Currency: "USD", TransactionID: "p",
+				Expected: -30, Actual: -40, Difference: -10}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := CheckLedger("acc", tt.entries)
+			assert.Equal(t, tt.issues, report.Issues)
+			assert.Equal(t, len(tt.issues) == 0, report.OK())
+		})
+	}
+}
+
+func TestCheckLedgerSummaries(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	report := CheckLedger("acc", []LedgerEntry{
+		{TransactionID: "e2", Currency: "EUR", Amount: -5, BalanceBefore: 50, BalanceAfter: 45, Time: start.Add(time.Hour)},
+		{TransactionID: "u1", Currency: "USD", Amount: 10, BalanceBefore: 0, BalanceAfter: 10, Time: start},
+		{TransactionID: "e1", Currency: "eur", Amount: 50, BalanceBefore: 0, BalanceAfter: 50, Time: start},
+	})
+
+	require.True(t, report.OK(), report.Issues)
+	assert.Equal(t, 3, report.Transactions)
+	require.Len(t, report.Ledgers, 2)
+	assert.Equal(t, &LedgerSummary{Currency: "EUR", Transactions: 2, OpeningBalance: 0, ClosingBalance: 45,
+		FirstTime: start, LastTime: start.Add(time.Hour)}, report.Ledgers["EUR"])
+	assert.Equal(t, 10.0, report.Ledgers["USD"].ClosingBalance)
+}
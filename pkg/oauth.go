@@ -20,7 +20,9 @@ func NewOAuthClient(httpClient *HTTPClient) *OAuthClient {
 
 // Authorize initiates the OAuth authorization flow
 // Returns the authorization code that can be used to obtain access token
-func (c *OAuthClient) Authorize(ctx context.Context, clientID string) (*OAuthAuthorizeData, error) {
+func (c *OAuthClient) Authorize(ctx context.Context, clientID string, callOpts ...CallOption) (*OAuthAuthorizeData, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	// Add query parameters
 	params := url.Values{}
 	params.Add("clientId", clientID)
@@ -43,7 +45,9 @@ func (c *OAuthClient) Authorize(ctx context.Context, clientID string) (*OAuthAut
 }
 
 // GetAccessToken exchanges authorization code for access token
-func (c *OAuthClient) GetAccessToken(ctx context.Context, code, clientID string) (*OAuthTokenData, error) {
+func (c *OAuthClient) GetAccessToken(ctx context.Context, code, clientID string, callOpts ...CallOption) (*OAuthTokenData, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	// Prepare request body
 	tokenReq := OAuthTokenRequest{
 		Code:     code,
@@ -68,7 +72,9 @@ func (c *OAuthClient) GetAccessToken(ctx context.Context, code, clientID string)
 }
 
 // AuthorizeAndGetToken is a convenience method that combines authorize and token retrieval
-func (c *OAuthClient) AuthorizeAndGetToken(ctx context.Context, clientID string) (*OAuthTokenData, error) {
+func (c *OAuthClient) AuthorizeAndGetToken(ctx context.Context, clientID string, callOpts ...CallOption) (*OAuthTokenData, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	// Step 1: Get authorization code
 	authData, err := c.Authorize(ctx, clientID)
 	if err != nil {
@@ -85,7 +91,9 @@ func (c *OAuthClient) AuthorizeAndGetToken(ctx context.Context, clientID string)
 }
 
 // RefreshToken refreshes the access token using a refresh token
-func (c *OAuthClient) RefreshToken(ctx context.Context, clientID, refreshToken string) (*OAuthRefreshTokenData, error) {
+func (c *OAuthClient) RefreshToken(ctx context.Context, clientID, refreshToken string, callOpts ...CallOption) (*OAuthRefreshTokenData, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	// Prepare request body
 	refreshReq := OAuthRefreshTokenRequest{
 		ClientID:     clientID,
@@ -0,0 +1,469 @@
+package interlace
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// OnboardingStep identifies a step of the customer onboarding flow
+type OnboardingStep string
+
+// Onboarding steps, in the order they run
+const (
+	OnboardingStepRegister         OnboardingStep = "register"
+	OnboardingStepUploadDocuments  OnboardingStep = "upload_documents"
+	OnboardingStepSubmitKYC        OnboardingStep = "submit_kyc"
+	OnboardingStepAwaitKYC         OnboardingStep = "await_kyc"
+	OnboardingStepCreateCardholder OnboardingStep = "create_cardholder"
+	OnboardingStepCreateCard       OnboardingStep = "create_card"
+	OnboardingStepCompleted        OnboardingStep = "completed"
+)
+
+// ErrKYCRejected is wrapped by the OnboardingError returned when the KYC review is rejected or
+// expires. Running the onboarding again waits for the same review; use Onboarder.ResubmitKYC to
+// submit new documents.
+var ErrKYCRejected = errors.New("interlace: KYC rejected")
+
+// OnboardingRequest describes a customer to onboard. Fields filled in by earlier steps, such as
+// the account ID of the cardholder or the file IDs of the KYC submission, are set by the
+// Onboarder.
+type OnboardingRequest struct {
+	// ID identifies the onboarding in the state store, e.g. your customer ID. Running the same
+	// ID again resumes the onboarding.
+	ID string
+
+	Account AccountRegisterRequest
+
+	// Paths of the KYC documents. The uploaded files are referenced by the KYC submission.
+	IDFrontImagePath string
+	IDBackImagePath  string
+	SelfieImagePath  string
+
+	KYC        KYCSubmitRequest
+	Cardholder CreateCardholderRequest
+	// Card is the prepaid card to issue. BinID defaults to Cardholder.BinID.
+	Card CreatePrepaidCardRequest
+}
+
+// OnboardingState is the persisted progress of an onboarding
+type OnboardingState struct {
+	ID string `json:"id"`
+	// Step is the next step to run
+	Step OnboardingStep `json:"step"`
+
+	AccountID          string `json:"accountId,omitempty"`
+	IDFrontImageFileID string `json:"idFrontImageFileId,omitempty"`
+	IDBackImageFileID  string `json:"idBackImageFileId,omitempty"`
+	SelfieImageFileID  string `json:"selfieImageFileId,omitempty"`
+	KYCApplicationID   string `json:"kycApplicationId,omitempty"`
+	KYCStatus          string `json:"kycStatus,omitempty"`
+	CardholderID       string `json:"cardholderId,omitempty"`
+	CardID             string `json:"cardId,omitempty"`
+	// KYCAttempt counts the KYC resubmissions made with ResubmitKYC
+	KYCAttempt int `json:"kycAttempt,omitempty"`
+
+	// LastError describes why the last run stopped, empty once a step succeeds
+	LastError string    `json:"lastError,omitempty"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Completed reports whether every step has run
+func (s *OnboardingState) Completed() bool {
+	return s.Step == OnboardingStepCompleted
+}
+
+// OnboardingError is returned when an onboarding step fails. The state is kept at the failed
+// step, so running the onboarding again retries it.
+type OnboardingError struct {
+	ID   string
+	Step OnboardingStep
+	// Reason is a human readable failure reason, e.g. the KYC rejection reason
+	Reason string
+	Err    error
+}
+
+func (e *OnboardingError) Error() string {
+	return fmt.Sprintf("onboarding %s failed at step %s: %s", e.ID, e.Step, e.Reason)
+}
+
+func (e *OnboardingError) Unwrap() error {
+	return e.Err
+}
+
+// OnboardingStore persists onboarding states. Implementations must be safe for concurrent use.
+type OnboardingStore interface {
+	// Load returns the state with the given ID, or nil when there is none
+	Load(ctx context.Context, id string) (*OnboardingState, error)
+	Save(ctx context.Context, state *OnboardingState) error
+}
+
+// MemoryOnboardingStore is an in-memory OnboardingStore, useful for tests. States are lost when
+// the process exits.
+type MemoryOnboardingStore struct {
+	mu     sync.Mutex
+	states map[string]OnboardingState
+}
+
+// NewMemoryOnboardingStore creates an empty in-memory store
+func NewMemoryOnboardingStore() *MemoryOnboardingStore {
+	return &MemoryOnboardingStore{
+		states: make(map[string]OnboardingState),
+	}
+}
+
+// Load implements OnboardingStore
+func (s *MemoryOnboardingStore) Load(ctx context.Context, id string) (*OnboardingState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.states[id]
+	if !ok {
+		return nil, nil
+	}
+	return &state, nil
+}
+
+// Save implements OnboardingStore
+func (s *MemoryOnboardingStore) Save(ctx context.Context, state *OnboardingState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.states[state.ID] = *state
+	return nil
+}
+
+// FileOnboardingStore stores each onboarding state as a JSON file in a directory. Files are
+// named after a hash of the onboarding ID, so any ID can be used.
+type FileOnboardingStore struct {
+	dir string
+}
+
+// NewFileOnboardingStore creates a store that keeps its files in dir, creating it if needed
+func NewFileOnboardingStore(dir string) (*FileOnboardingStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create onboarding state directory: %w", err)
+	}
+	return &FileOnboardingStore{dir: dir}, nil
+}
+
+func (s *FileOnboardingStore) path(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Load implements OnboardingStore
+func (s *FileOnboardingStore) Load(ctx context.Context, id string) (*OnboardingState, error) {
+	data, err := os.ReadFile(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read onboarding state %s: %w", id, err)
+	}
+
+	var state OnboardingState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to decode onboarding state %s: %w", id, err)
+	}
+	return &state, nil
+}
+
+// Save implements OnboardingStore. The file is replaced atomically, so a crash never leaves a
+// partially written state behind.
+func (s *FileOnboardingStore) Save(ctx context.Context, state *OnboardingState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode onboarding state %s: %w", state.ID, err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".onboarding-*")
+	if err != nil {
+		return fmt.Errorf("failed to save onboarding state %s: %w", state.ID, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save onboarding state %s: %w", state.ID, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save onboarding state %s: %w", state.ID, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save onboarding state %s: %w", state.ID, err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path(state.ID)); err != nil {
+		return fmt.Errorf("failed to save onboarding state %s: %w", state.ID, err)
+	}
+	return nil
+}
+
+// Onboarder runs customer onboarding as a persisted state machine: account registration,
+// KYC document upload, KYC submission, KYC review, cardholder creation and card issuance. The
+// state is saved after every step, so an onboarding interrupted by a crash or a failure resumes
+// from the last completed step when run again. Mutating steps use idempotency keys derived from
+// the onboarding ID, so a step whose outcome was not saved is not performed twice.
+//
+//	onboarder := interlace.NewOnboarder(client, store)
+//	onboarder.OnProgress = func(s *interlace.OnboardingState) { log.Printf("%s: %s", s.ID, s.Step) }
+//	state, err := onboarder.Run(ctx, req)
+//	if errors.Is(err, interlace.ErrKYCRejected) {
+//		var onboardingErr *interlace.OnboardingError
+//		errors.As(err, &onboardingErr)
+//		log.Printf("KYC rejected: %s", onboardingErr.Reason)
+//		// later, with new documents in req
+//		state, err = onboarder.ResubmitKYC(ctx, req)
+//	}
+type Onboarder struct {
+	client *Client
+	store  OnboardingStore
+
+	// KYCWait configures how the KYC review is awaited. A nil value polls with the defaults of
+	// WaitOptions; bound the wait with the context passed to Run.
+	KYCWait *WaitOptions[KYCStatusData]
+
+	// OnProgress is called after every completed step with the saved state
+	OnProgress func(state *OnboardingState)
+}
+
+// NewOnboarder creates an onboarder that persists its progress in store
+func NewOnboarder(client *Client, store OnboardingStore) *Onboarder {
+	return &Onboarder{
+		client: client,
+		store:  store,
+	}
+}
+
+// State returns the saved state of an onboarding, or nil when it has not started
+func (o *Onboarder) State(ctx context.Context, id string) (*OnboardingState, error) {
+	return o.store.Load(ctx, id)
+}
+
+// Run starts the onboarding or resumes it from the last completed step. It returns the state
+// reached along with an *OnboardingError when a step fails.
+func (o *Onboarder) Run(ctx context.Context, req *OnboardingRequest) (*OnboardingState, error) {
+	if req == nil || req.ID == "" {
+		return nil, fmt.Errorf("onboarding ID is required")
+	}
+
+	state, err := o.store.Load(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil {
+		state = &OnboardingState{ID: req.ID, Step: OnboardingStepRegister}
+	}
+
+	for !state.Completed() {
+		step := state.Step
+		// Derive the idempotency key of the step's requests from the onboarding ID and, for the
+		// KYC steps, the attempt
+		key := fmt.Sprintf("onboarding-%s-%s", req.ID, step)
+		if state.KYCAttempt > 0 && (step == OnboardingStepUploadDocuments || step == OnboardingStepSubmitKYC) {
+			key += fmt.Sprintf("-%d", state.KYCAttempt)
+		}
+		stepCtx := ContextWithIdempotencyKey(ctx, key)
+
+		next, reason, err := o.runStep(stepCtx, req, state)
+		if err != nil {
+			if reason == "" {
+				reason = err.Error()
+			}
+			state.LastError = reason
+			state.UpdatedAt = time.Now()
+			if saveErr := o.store.Save(ctx, state); saveErr != nil {
+				err = errors.Join(err, saveErr)
+			}
+			return state, &OnboardingError{ID: req.ID, Step: step, Reason: reason, Err: err}
+		}
+
+		state.Step = next
+		state.LastError = ""
+		state.UpdatedAt = time.Now()
+		if err := o.store.Save(ctx, state); err != nil {
+			return state, &OnboardingError{ID: req.ID, Step: step, Reason: "failed to save state", Err: err}
+		}
+		if o.OnProgress != nil {
+			o.OnProgress(state)
+		}
+	}
+
+	return state, nil
+}
+
+// ResubmitKYC resumes an onboarding whose KYC review was rejected or expired: the documents of
+// req are uploaded and submitted again, then the onboarding continues as Run does. It fails when
+// the onboarding is not awaiting KYC or its review did not fail.
+func (o *Onboarder) ResubmitKYC(ctx context.Context, req *OnboardingRequest) (*OnboardingState, error) {
+	if req == nil || req.ID == "" {
+		return nil, fmt.Errorf("onboarding ID is required")
+	}
+
+	state, err := o.store.Load(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if state == nil || state.Step != OnboardingStepAwaitKYC {
+		return state, fmt.Errorf("onboarding %s is not awaiting KYC", req.ID)
+	}
+
+	kyc := o.client.KYC.KYCOperation(state.AccountID)
+	if _, err := kyc.Poll(ctx); err != nil {
+		return state, err
+	}
+	if kyc.State() != OperationFailed {
+		return state, fmt.Errorf("KYC of onboarding %s was not rejected", req.ID)
+	}
+
+	state.Step = OnboardingStepUploadDocuments
+	state.KYCAttempt++
+	state.IDFrontImageFileID = ""
+	state.IDBackImageFileID = ""
+	state.SelfieImageFileID = ""
+	state.KYCApplicationID = ""
+	state.KYCStatus = kyc.Result().Status
+	state.LastError = ""
+	state.UpdatedAt = time.Now()
+	if err := o.store.Save(ctx, state); err != nil {
+		return state, err
+	}
+	return o.Run(ctx, req)
+}
+
+// runStep runs the current step, records its results in state and returns the next step. The
+// returned reason, when set, describes a failure better than the error.
+func (o *Onboarder) runStep(ctx context.Context, req *OnboardingRequest, state *OnboardingState) (OnboardingStep, string, error) {
+	switch state.Step {
+	case OnboardingStepRegister:
+		account, err := o.client.Account.Register(ctx, &req.Account)
+		if err != nil {
+			return "", "", err
+		}
+		state.AccountID = account.ID
+		return OnboardingStepUploadDocuments, "", nil
+
+	case OnboardingStepUploadDocuments:
+		documents := []struct {
+			name   string
+			path   string
+			fileID *string
+		}{
+			{"id_front", req.IDFrontImagePath, &state.IDFrontImageFileID},
+			{"id_back", req.IDBackImagePath, &state.IDBackImageFileID},
+			{"selfie", req.SelfieImagePath, &state.SelfieImageFileID},
+		}
+		for _, doc := range documents {
+			if doc.path == "" || *doc.fileID != "" {
+				continue
+			}
+			// Each document needs its own idempotency key
+			docCtx := ContextWithIdempotencyKey(ctx, IdempotencyKeyFromContext(ctx)+"-"+doc.name)
+			resp, err := o.client.File.UploadFile(docCtx, doc.path, state.AccountID)
+			if err != nil {
+				return "", "", fmt.Errorf("failed to upload %s: %w", doc.path, err)
+			}
+			fileID := uploadedFileID(resp.Data)
+			if fileID == "" {
+				return "", "", fmt.Errorf("upload of %s returned no file ID", doc.path)
+			}
+			*doc.fileID = fileID
+			// Save after each upload, so a crash does not upload the document again
+			if err := o.store.Save(ctx, state); err != nil {
+				return "", "", err
+			}
+		}
+		return OnboardingStepSubmitKYC, "", nil
+
+	case OnboardingStepSubmitKYC:
+		kyc := req.KYC
+		if state.IDFrontImageFileID != "" {
+			kyc.IDFrontImageFileID = state.IDFrontImageFileID
+		}
+		if state.IDBackImageFileID != "" {
+			kyc.IDBackImageFileID = state.IDBackImageFileID
+		}
+		if state.SelfieImageFileID != "" {
+			kyc.SelfieImageFileID = state.SelfieImageFileID
+		}
+		submitted, err := o.client.KYC.SubmitKYC(ctx, state.AccountID, &kyc)
+		if err != nil {
+			return "", "", err
+		}
+		state.KYCApplicationID = submitted.KYCApplicationID
+		state.KYCStatus = submitted.Status
+		return OnboardingStepAwaitKYC, "", nil
+
+	case OnboardingStepAwaitKYC:
+		status, err := o.client.KYC.WaitForKYC(ctx, state.AccountID, o.KYCWait)
+		if status != nil {
+			state.KYCStatus = status.Status
+		}
+		var opErr *OperationError
+		if errors.As(err, &opErr) {
+			reason := "KYC " + opErr.Status
+			if opErr.Reason != "" {
+				reason += ": " + opErr.Reason
+			}
+			return "", reason, fmt.Errorf("%w: %w", ErrKYCRejected, err)
+		}
+		if err != nil {
+			return "", "", err
+		}
+		return OnboardingStepCreateCardholder, "", nil
+
+	case OnboardingStepCreateCardholder:
+		cardholderReq := req.Cardholder
+		cardholderReq.AccountID = state.AccountID
+		cardholder, err := o.client.Cardholder.CreateCardholder(ctx, &cardholderReq)
+		if err != nil {
+			return "", "", err
+		}
+		state.CardholderID = cardholder.ID
+		return OnboardingStepCreateCard, "", nil
+
+	case OnboardingStepCreateCard:
+		cardReq := req.Card
+		cardReq.CardholderID = state.CardholderID
+		if cardReq.BinID == "" {
+			cardReq.BinID = req.Cardholder.BinID
+		}
+		card, err := o.client.Card.CreatePrepaidCard(ctx, &cardReq)
+		if err != nil {
+			return "", "", err
+		}
+		state.CardID = card.ID
+		return OnboardingStepCompleted, "", nil
+	}
+
+	return "", "", fmt.Errorf("unknown onboarding step %q", state.Step)
+}
+
+// uploadedFileID extracts the file ID from the data of a file upload response, which is either
+// the ID itself, an object with an ID or a list of such objects
+func uploadedFileID(data interface{}) string {
+	switch v := data.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		for _, key := range []string{"fileId", "id"} {
+			if id, ok := v[key].(string); ok && id != "" {
+				return id
+			}
+		}
+	case []interface{}:
+		if len(v) > 0 {
+			return uploadedFileID(v[0])
+		}
+	}
+	return ""
+}
@@ -0,0 +1,323 @@
+package interlace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrOperationNotPollable is returned when an operation has no status endpoint to poll and no
+// webhook server to learn its outcome from
+var ErrOperationNotPollable = errors.New("interlace: operation cannot be polled")
+
+// ErrMaxPollsReached is returned by Wait when the operation is still pending after
+// WaitOptions.MaxPolls polls
+var ErrMaxPollsReached = errors.New("interlace: operation still pending after the maximum number of polls")
+
+// OperationState is the lifecycle state of an asynchronous operation
+type OperationState int
+
+// Operation states
+const (
+	OperationPending OperationState = iota
+	OperationSucceeded
+	OperationFailed
+)
+
+func (s OperationState) String() string {
+	switch s {
+	case OperationPending:
+		return "pending"
+	case OperationSucceeded:
+		return "succeeded"
+	case OperationFailed:
+		return "failed"
+	}
+	return fmt.Sprintf("OperationState(%d)", int(s))
+}
+
+// OperationError is returned by Wait when an operation ends in a failed state
+type OperationError struct {
+	ID     string
+	Status string
+	Reason string
+}
+
+func (e *OperationError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("operation %s failed with status %s: %s", e.ID, e.Status, e.Reason)
+	}
+	return fmt.Sprintf("operation %s failed with status %s", e.ID, e.Status)
+}
+
+// WaitOptions configures Operation.Wait
+type WaitOptions[T any] struct {
+	// PollInterval is the delay before the first poll. It doubles after every poll up to
+	// MaxPollInterval. Defaults to 1s.
+	PollInterval time.Duration
+	// MaxPollInterval caps the delay between polls. Defaults to 30s.
+	MaxPollInterval time.Duration
+	// MaxPolls limits the number of polls. Zero means no limit.
+	MaxPolls int
+
+	// OnProgress is called whenever the resource changes, e.g. on a status transition or a new
+	// blockchain confirmation
+	OnProgress func(resource *T)
+
+	// Webhooks completes the operation from webhook events instead of polling. Polling continues
+	// every MaxPollInterval as a fallback for missed events.
+	Webhooks *WebhookServer
+}
+
+// Operation tracks an asynchronous resource, such as a transfer or payout, until it reaches a
+// terminal state
+//
+//	op, err := client.Transfer.CreateTransferOperation(ctx, req)
+//	if err != nil {
+//		return err
+//	}
+//	transfer, err := op.Wait(ctx, &interlace.WaitOptions[interlace.BlockchainTransfer]{
+//		OnProgress: func(t *interlace.BlockchainTransfer) {
+//			log.Printf("transfer %s: %s (%d confirmations)", t.ID, t.Status, t.Confirmations)
+//		},
+//	})
+type Operation[T any] struct {
+	id   string
+	spec operationSpec[T]
+
+	mu      sync.Mutex
+	current *T
+}
+
+// operationSpec describes how to track a resource type
+type operationSpec[T any] struct {
+	// poll fetches the resource. It is nil for resources without a status endpoint.
+	poll func(ctx context.Context) (*T, error)
+	// state returns the state of the resource along with its status and failure reason
+	state func(resource *T) (state OperationState, status, reason string)
+	// eventKeys are the webhook event data fields that may carry the resource ID
+	eventKeys []string
+	// eventTypes restricts the webhook events that refer to the resource. Any event type
+	// matches when empty.
+	eventTypes []string
+}
+
+func newOperation[T any](id string, current *T, spec operationSpec[T]) *Operation[T] {
+	return &Operation[T]{
+		id:      id,
+		spec:    spec,
+		current: current,
+	}
+}
+
+// ID returns the ID of the tracked resource
+func (op *Operation[T]) ID() string {
+	return op.id
+}
+
+// Result returns the latest known version of the resource. It is nil when the operation was
+// resumed by ID and has not been polled yet.
+func (op *Operation[T]) Result() *T {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	return op.current
+}
+
+// State returns the state of the latest known version of the resource
+func (op *Operation[T]) State() OperationState {
+	state, _ := op.stateOf(op.Result())
+	return state
+}
+
+// Done reports whether the operation has reached a terminal state
+func (op *Operation[T]) Done() bool {
+	return op.State() != OperationPending
+}
+
+// Poll fetches the current version of the resource
+func (op *Operation[T]) Poll(ctx context.Context) (*T, error) {
+	if op.spec.poll == nil {
+		return nil, ErrOperationNotPollable
+	}
+
+	resource, err := op.spec.poll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to poll operation %s: %w", op.id, err)
+	}
+
+	op.update(resource)
+	return resource, nil
+}
+
+// Wait blocks until the operation reaches a terminal state or ctx is done. It returns the final
+// resource, along with an *OperationError when the operation failed. opts may be nil.
+func (op *Operation[T]) Wait(ctx context.Context, opts *WaitOptions[T]) (*T, error) {
+	if opts == nil {
+		opts = &WaitOptions[T]{}
+	}
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	maxInterval := opts.MaxPollInterval
+	if maxInterval <= 0 {
+		maxInterval = 30 * time.Second
+	}
+
+	var events <-chan *WebhookEvent
+	if opts.Webhooks != nil {
+		ch, unsubscribe := opts.Webhooks.Subscribe()
+		defer unsubscribe()
+		events = ch
+		interval = maxInterval
+	} else if op.spec.poll == nil {
+		return op.Result(), ErrOperationNotPollable
+	}
+
+	polls := 0
+	poll := func() error {
+		polls++
+		_, err := op.Poll(ctx)
+		return err
+	}
+
+	last := op.Result()
+
+	// An operation resumed by ID is polled right away
+	if last == nil && op.spec.poll != nil {
+		if err := poll(); err != nil {
+			return nil, err
+		}
+	}
+
+	var timer *time.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for {
+		resource := op.Result()
+		if resource != nil && !reflect.DeepEqual(resource, last) && opts.OnProgress != nil {
+			opts.OnProgress(resource)
+		}
+		last = resource
+
+		if state, err := op.stateOf(resource); state != OperationPending {
+			return resource, err
+		}
+		if op.spec.poll != nil && opts.MaxPolls > 0 && polls >= opts.MaxPolls {
+			return resource, ErrMaxPollsReached
+		}
+
+		// The timer is kept across unrelated events, so that they do not delay the next poll
+		if timer == nil {
+			timer = time.NewTimer(interval)
+		}
+		select {
+		case <-ctx.Done():
+			return resource, ctx.Err()
+		case event := <-events:
+			if !op.matches(event) {
+				continue
+			}
+			timer.Stop()
+			timer = nil
+			if op.spec.poll == nil {
+				if err := op.updateFromEvent(event); err != nil {
+					return resource, err
+				}
+				continue
+			}
+		case <-timer.C:
+			timer = nil
+			interval *= 2
+			if interval > maxInterval {
+				interval = maxInterval
+			}
+		}
+
+		if op.spec.poll != nil {
+			if err := poll(); err != nil {
+				return op.Result(), err
+			}
+		}
+	}
+}
+
+func (op *Operation[T]) stateOf(resource *T) (OperationState, error) {
+	if resource == nil {
+		return OperationPending, nil
+	}
+
+	state, status, reason := op.spec.state(resource)
+	if state == OperationFailed {
+		return state, &OperationError{ID: op.id, Status: status, Reason: reason}
+	}
+	return state, nil
+}
+
+func (op *Operation[T]) update(resource *T) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	op.current = resource
+}
+
+// matches reports whether a webhook event refers to the tracked resource
+func (op *Operation[T]) matches(event *WebhookEvent) bool {
+	if len(op.spec.eventTypes) > 0 && !containsString(op.spec.eventTypes, event.EventType) {
+		return false
+	}
+	for _, key := range op.spec.eventKeys {
+		if id, ok := event.Data[key].(string); ok && id == op.id {
+			return true
+		}
+	}
+	return false
+}
+
+// updateFromEvent applies the fields carried by a webhook event to the latest known resource
+func (op *Operation[T]) updateFromEvent(event *WebhookEvent) error {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook event %s: %w", event.EventID, err)
+	}
+
+	resource := new(T)
+	if current := op.Result(); current != nil {
+		*resource = *current
+	}
+	if err := json.Unmarshal(data, resource); err != nil {
+		return fmt.Errorf("failed to decode webhook event %s: %w", event.EventID, err)
+	}
+
+	op.update(resource)
+	return nil
+}
+
+// statusState maps the status values shared by the asynchronous resources of the API to an
+// operation state
+func statusState(status string) OperationState {
+	switch strings.ToUpper(status) {
+	case "COMPLETED", "SUCCESS", "SUCCEEDED", "CONFIRMED":
+		return OperationSucceeded
+	case "FAILED", "REJECTED", "CANCELLED", "CANCELED", "EXPIRED", "CLOSED":
+		return OperationFailed
+	}
+	return OperationPending
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,251 @@
+package interlace
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testResource struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// testPoller returns the given statuses in turn, the last one repeatedly, and records when it
+// was polled
+type testPoller struct {
+	mu       sync.Mutex
+	statuses []string
+	times    []time.Time
+}
+
+func (p *testPoller) poll(ctx context.Context) (*testResource, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.times = append(p.times, time.Now())
+	status := p.statuses[0]
+	if len(p.statuses) > 1 {
+		p.statuses = p.statuses[1:]
+	}
+	return &testResource{ID: "op-1", Status: status, Reason: "reason of " + status}, nil
+}
+
+func (p *testPoller) polls() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.times)
+}
+
+func testOperationSpec(poll func(ctx context.Context) (*testResource, error)) operationSpec[testResource] {
+	return operationSpec[testResource]{
+		poll: poll,
+		state: func(r *testResource) (OperationState, string, string) {
+			return statusState(r.Status), r.Status, r.Reason
+		},
+		eventKeys:  []string{"id"},
+		eventTypes: []string{"transfer.updated"},
+	}
+}
+
+// subscribed waits until the webhook server has a subscriber, so that published events are not
+// dropped
+func subscribed(t *testing.T, webhooks *WebhookServer) {
+	assert.Eventually(t, func() bool {
+		webhooks.mu.Lock()
+		defer webhooks.mu.Unlock()
+		return len(webhooks.subscribers) > 0
+	}, time.Second, time.Millisecond)
+}
+
+func TestStatusState(t *testing.T) {
+	tests := map[string]OperationState{
+		"COMPLETED": OperationSucceeded,
+		"success":   OperationSucceeded,
+		"SUCCEEDED": OperationSucceeded,
+		"CONFIRMED": OperationSucceeded,
+		"FAILED":    OperationFailed,
+		"Rejected":  OperationFailed,
+		"CANCELLED": OperationFailed,
+		"CANCELED":  OperationFailed,
+		"EXPIRED":   OperationFailed,
+		"CLOSED":    OperationFailed,
+		"PENDING":   OperationPending,
+		"":          OperationPending,
+	}
+	for status, want := range tests {
+		assert.Equal(t, want, statusState(status), status)
+	}
+}
+
+func TestOperationWaitTerminalStates(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []string
+		status   string
+		err      error
+	}{
+		{name: "succeeded", statuses: []string{"PENDING", "COMPLETED"}, status: "COMPLETED"},
+		{
+			name:     "failed",
+			statuses: []string{"PROCESSING", "REJECTED"},
+			status:   "REJECTED",
+			err:      &OperationError{ID: "op-1", Status: "REJECTED", Reason: "reason of REJECTED"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			poller := &testPoller{statuses: tt.statuses}
+			op := newOperation[testResource]("op-1", nil, testOperationSpec(poller.poll))
+
+			resource, err := op.Wait(context.Background(), &WaitOptions[testResource]{PollInterval: time.Millisecond})
+			assert.Equal(t, tt.err, err)
+			require.NotNil(t, resource)
+			assert.Equal(t, tt.status, resource.Status)
+			assert.True(t, op.Done())
+			assert.Equal(t, len(tt.statuses), poller.polls())
+		})
+	}
+}
+
+func TestOperationWaitBackoff(t *testing.T) {
+	poller := &testPoller{statuses: []string{"PENDING", "PENDING", "PENDING", "PENDING", "PENDING", "COMPLETED"}}
+	op := newOperation("op-1", &testResource{ID: "op-1", Status: "PENDING"}, testOperationSpec(poller.poll))
+
+	start := time.Now()
+	_, err := op.Wait(context.Background(), &WaitOptions[testResource]{
+		PollInterval:    10 * time.Millisecond,
+		MaxPollInterval: 40 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	// The delay doubles from PollInterval up to MaxPollInterval
+	require.Len(t, poller.times, 6)
+	want := []time.Duration{10, 20, 40, 40, 40, 40}
+	previous := start
+	for i, at := range poller.times {
+		assert.GreaterOrEqual(t, at.Sub(previous), want[i]*time.Millisecond, "delay before poll %d", i+1)
+		previous = at
+	}
+}
+
+func TestOperationWaitMaxPolls(t *testing.T) {
+	poller := &testPoller{statuses: []string{"PENDING"}}
+	op := newOperation[testResource]("op-1", nil, testOperationSpec(poller.poll))
+
+	resource, err := op.Wait(context.Background(), &WaitOptions[testResource]{PollInterval: time.Millisecond, MaxPolls: 3})
+	assert.True(t, errors.Is(err, ErrMaxPollsReached))
+	assert.Equal(t, "PENDING", resource.Status)
+	assert.Equal(t, 3, poller.polls())
+}
+
+func TestOperationWaitProgress(t *testing.T) {
+	poller := &testPoller{statuses: []string{"PENDING", "PENDING", "PROCESSING", "PROCESSING", "COMPLETED"}}
+	op := newOperation[testResource]("op-1", nil, testOperationSpec(poller.poll))
+
+	var progress []string
+	_, err := op.Wait(context.Background(), &WaitOptions[testResource]{
+		PollInterval: time.Millisecond,
+		OnProgress:   func(r *testResource) { progress = append(progress, r.Status) },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"PENDING", "PROCESSING", "COMPLETED"}, progress)
+}
+
+func TestOperationWaitContext(t *testing.T) {
+	poller := &testPoller{statuses: []string{"PENDING"}}
+	op := newOperation("op-1", &testResource{ID: "op-1", Status: "PENDING"}, testOperationSpec(poller.poll))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	resource, err := op.Wait(ctx, &WaitOptions[testResource]{PollInterval: time.Hour})
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, "PENDING", resource.Status)
+	assert.Zero(t, poller.polls())
+}
+
+func TestOperationWaitWebhookWakeUp(t *testing.T) {
+	poller := &testPoller{statuses: []string{"PENDING", "COMPLETED"}}
+	op := newOperation[testResource]("op-1", nil, testOperationSpec(poller.poll))
+	webhooks := NewWebhookServer("")
+
+	go func() {
+		subscribed(t, webhooks)
+		assert.Eventually(t, func() bool { return poller.polls() == 1 }, time.Second, time.Millisecond)
+		webhooks.publish(&WebhookEvent{EventType: "transfer.created", Data: map[string]interface{}{"id": "op-1"}})
+		webhooks.publish(&WebhookEvent{EventType: "transfer.updated", Data: map[string]interface{}{"id": "op-2"}})
+		webhooks.publish(&WebhookEvent{EventType: "transfer.updated", Data: map[string]interface{}{"id": "op-1"}})
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resource, err := op.Wait(ctx, &WaitOptions[testResource]{Webhooks: webhooks, MaxPollInterval: time.Hour})
+	require.NoError(t, err)
+	assert.Equal(t, "COMPLETED", resource.Status)
+	assert.Equal(t, 2, poller.polls(), "only the matching event triggers a poll")
+}
+
+func TestOperationWaitTimerSurvivesUnrelatedEvents(t *testing.T) {
+	poller := &testPoller{statuses: []string{"COMPLETED"}}
+	op := newOperation("op-1", &testResource{ID: "op-1", Status: "PENDING"}, testOperationSpec(poller.poll))
+	webhooks := NewWebhookServer("")
+
+	// Unrelated events arrive more often than the fallback poll interval
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		subscribed(t, webhooks)
+		ticker := time.NewTicker(5 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				webhooks.publish(&WebhookEvent{EventType: "transfer.updated", Data: map[string]interface{}{"id": "other"}})
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resource, err := op.Wait(ctx, &WaitOptions[testResource]{Webhooks: webhooks, MaxPollInterval: 50 * time.Millisecond})
+	require.NoError(t, err, "the fallback poll is not postponed by unrelated events")
+	assert.Equal(t, "COMPLETED", resource.Status)
+	assert.Equal(t, 1, poller.polls())
+}
+
+func TestOperationWaitWebhookOnly(t *testing.T) {
+	op := newOperation("op-1", &testResource{ID: "op-1", Status: "PENDING"}, testOperationSpec(nil))
+
+	_, err := op.Wait(context.Background(), nil)
+	assert.True(t, errors.Is(err, ErrOperationNotPollable))
+
+	webhooks := NewWebhookServer("")
+	go func() {
+		subscribed(t, webhooks)
+		webhooks.publish(&WebhookEvent{EventType: "transfer.updated", Data: map[string]interface{}{"id": "op-1", "status": "PROCESSING"}})
+		webhooks.publish(&WebhookEvent{EventType: "transfer.updated",
+			Data: map[string]interface{}{"id": "op-1", "status": "FAILED", "reason": "insufficient funds"}})
+	}()
+
+	var progress []string
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resource, err := op.Wait(ctx, &WaitOptions[testResource]{
+		Webhooks:   webhooks,
+		OnProgress: func(r *testResource) { progress = append(progress, r.Status) },
+	})
+	var opErr *OperationError
+	require.ErrorAs(t, err, &opErr)
+	assert.Equal(t, "insufficient funds", opErr.Reason)
+	assert.Equal(t, "FAILED", resource.Status)
+	assert.Equal(t, []string{"PROCESSING", "FAILED"}, progress)
+}
@@ -60,7 +60,9 @@ func NewPaymentClient(httpClient *HTTPClient) *PaymentClient {
 
 // CreatePayment creates a new payment order
 // POST /open-api/v3/acquiring/payments
-func (c *PaymentClient) CreatePayment(ctx context.Context, req *CreatePaymentRequest) (*Payment, error) {
+func (c *PaymentClient) CreatePayment(ctx context.Context, req *CreatePaymentRequest, callOpts ...CallOption) (*Payment, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -86,7 +88,9 @@ func (c *PaymentClient) CreatePayment(ctx context.Context, req *CreatePaymentReq
 
 // CancelPayment cancels an existing payment order
 // POST /open-api/v3/acquiring/payments/cancel
-func (c *PaymentClient) CancelPayment(ctx context.Context, req *CancelPaymentRequest) (*Payment, error) {
+func (c *PaymentClient) CancelPayment(ctx context.Context, req *CancelPaymentRequest, callOpts ...CallOption) (*Payment, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if req == nil || req.OrderNo == "" {
 		return nil, fmt.Errorf("orderNo is required")
 	}
@@ -109,7 +113,9 @@ func (c *PaymentClient) CancelPayment(ctx context.Context, req *CancelPaymentReq
 
 // CreateRefund creates a new refund for a payment
 // POST /open-api/v3/acquiring/refunds
-func (c *PaymentClient) CreateRefund(ctx context.Context, req *CreateRefundRequest) (*Refund, error) {
+func (c *PaymentClient) CreateRefund(ctx context.Context, req *CreateRefundRequest, callOpts ...CallOption) (*Refund, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -135,7 +141,9 @@ func (c *PaymentClient) CreateRefund(ctx context.Context, req *CreateRefundReque
 
 // QueryPayment queries a single payment order
 // GET /open-api/v3/acquiring/payments
-func (c *PaymentClient) QueryPayment(ctx context.Context, orderNo string) (*Payment, error) {
+func (c *PaymentClient) QueryPayment(ctx context.Context, orderNo string, callOpts ...CallOption) (*Payment, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if orderNo == "" {
 		return nil, fmt.Errorf("orderNo cannot be empty")
 	}
@@ -161,7 +169,9 @@ func (c *PaymentClient) QueryPayment(ctx context.Context, orderNo string) (*Paym
 
 // QueryRefund queries a single refund order
 // GET /open-api/v3/acquiring/refunds
-func (c *PaymentClient) QueryRefund(ctx context.Context, orderNo string) (*Refund, error) {
+func (c *PaymentClient) QueryRefund(ctx context.Context, orderNo string, callOpts ...CallOption) (*Refund, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if orderNo == "" {
 		return nil, fmt.Errorf("orderNo cannot be empty")
 	}
@@ -187,7 +197,9 @@ func (c *PaymentClient) QueryRefund(ctx context.Context, orderNo string) (*Refun
 
 // Search searches for multiple payments and refunds by order numbers
 // POST /open-api/v3/acquiring/search
-func (c *PaymentClient) Search(ctx context.Context, orderNos []string) (*SearchResult, error) {
+func (c *PaymentClient) Search(ctx context.Context, orderNos []string, callOpts ...CallOption) (*SearchResult, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if len(orderNos) == 0 {
 		return nil, fmt.Errorf("orderNos cannot be empty")
 	}
@@ -0,0 +1,315 @@
+package interlace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxNotificationSize bounds the body of payment notifications
+const maxNotificationSize = 1 << 20
+
+// ErrInvalidNotificationSignature is returned for payment notifications whose signature is
+// missing or does not match the webhook secret
+var ErrInvalidNotificationSignature = errors.New("interlace: invalid notification signature")
+
+// NotificationStore records the payment notifications already processed, so that redeliveries
+// are acknowledged without running the callbacks again. Implementations must be safe for
+// concurrent use.
+type NotificationStore interface {
+	// MarkProcessed records a notification and reports whether it was not recorded yet
+	MarkProcessed(ctx context.Context, key string) (bool, error)
+	// Forget removes a notification, so that its redelivery is processed again
+	Forget(ctx context.Context, key string) error
+}
+
+// MemoryNotificationStore is an in-memory NotificationStore that remembers notifications for a
+// limited time
+type MemoryNotificationStore struct {
+	ttl time.Duration
+
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// NewMemoryNotificationStore creates a store remembering notifications for ttl, 24 hours when
+// ttl is zero
+func NewMemoryNotificationStore(ttl time.Duration) *MemoryNotificationStore {
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	return &MemoryNotificationStore{ttl: ttl, seen: make(map[string]time.Time)}
+}
+
+// MarkProcessed implements NotificationStore
+func (s *MemoryNotificationStore) MarkProcessed(ctx context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for seenKey, expires := range s.seen {
+		if now.After(expires) {
+			delete(s.seen, seenKey)
+		}
+	}
+	if _, ok := s.seen[key]; ok {
+		return false, nil
+	}
+	s.seen[key] = now.Add(s.ttl)
+	return true, nil
+}
+
+// Forget implements NotificationStore
+func (s *MemoryNotificationStore) Forget(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.seen, key)
+	return nil
+}
+
+// PaymentNotification is a verified payment or refund status callback
+type PaymentNotification struct {
+	// Type is OrderTypePayment or OrderTypeRefund
+	Type    string
+	Payment *Payment
+	Refund  *Refund
+	// Confirmed reports whether the order was re-queried from the API. The order then holds the
+	// API response rather than the notified one.
+	Confirmed bool
+	// Raw is the notification body
+	Raw json.RawMessage
+}
+
+// key identifies a notification for deduplication by its order and the state it reports, so
+// that the statuses of a same state (SUCCESS, PAID) are processed once. A state change is a new
+// notification. The merchant trade number is preferred, as the one order number both a
+// notification and a queried order carry.
+func (n *PaymentNotification) key() string {
+	var status, orderNo string
+	if n.Type == OrderTypeRefund {
+		status, orderNo = n.Refund.Status, n.Refund.MerchantTradeNo
+	} else {
+		status, orderNo = n.Payment.Status, n.Payment.MerchantTradeNo
+	}
+	if orderNo == "" {
+		orderNo = n.orderNo()
+	}
+	return n.Type + ":" + orderNo + ":" + paymentState(status).String()
+}
+
+// PaymentNotifyHandler is an http.Handler for the payment and refund callbacks sent to the
+// NotifyURL of payments. It verifies their signature, optionally confirms the status with the
+// API, deduplicates redeliveries and dispatches them to typed callbacks.
+//
+//	handler := interlace.NewPaymentNotifyHandler(webhookSecret)
+//	handler.Confirm = client.Payment
+//	handler.OnPayment = func(ctx context.Context, payment *interlace.Payment) error { ... }
+//	http.Handle("/interlace/notify", handler)
+//
+// A callback error answers 500 so that the notification is delivered again.
+type PaymentNotifyHandler struct {
+	client *WebhookClient
+
+	// OnPayment is called for payment notifications
+	OnPayment func(ctx context.Context, payment *Payment) error
+	// OnRefund is called for refund notifications
+	OnRefund func(ctx context.Context, refund *Refund) error
+	// Confirm, when set, re-queries each notified order and dispatches the API response instead
+	// of the notification
+	Confirm *PaymentClient
+	// Store deduplicates notifications on their order and state, the confirmed state when Confirm
+	// is set. Defaults to a MemoryNotificationStore; set it to a shared store when several
+	// instances receive notifications.
+	Store NotificationStore
+}
+
+// NewPaymentNotifyHandler creates a handler verifying notifications with the webhook secret.
+// Without a secret the notifications cannot be trusted, so the handler refuses them unless
+// Confirm is set to re-query every notified order from the API.
+func NewPaymentNotifyHandler(webhookSecret string) *PaymentNotifyHandler {
+	return &PaymentNotifyHandler{
+		client: NewWebhookClient(webhookSecret),
+		Store:  NewMemoryNotificationStore(0),
+	}
+}
+
+// errNotificationsUnverified is returned when a handler has neither a webhook secret nor Confirm
+var errNotificationsUnverified = errors.New("notifications cannot be verified: set a webhook secret or Confirm")
+
+// ParseNotification verifies and parses a notification request. Notifications are either a
+// payment or refund object, or an event envelope carrying one in its data. Refunds are
+// recognized by their paymentId or a refund event type.
+func (h *PaymentNotifyHandler) ParseNotification(r *http.Request) (*PaymentNotification, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxNotificationSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	defer r.Body.Close()
+
+	if h.client.webhookSecret == "" && h.Confirm == nil {
+		return nil, errNotificationsUnverified
+	}
+	if h.client.webhookSecret != "" {
+		signature := r.Header.Get("X-Interlace-Signature")
+		if signature == "" {
+			return nil, fmt.Errorf("%w: missing signature", ErrInvalidNotificationSignature)
+		}
+		if !h.client.VerifyWebhookSignature(body, signature) {
+			return nil, ErrInvalidNotificationSignature
+		}
+	}
+
+	var envelope struct {
+		EventType string          `json:"eventType"`
+		Data      json.RawMessage `json:"data"`
+		PaymentID string          `json:"paymentId"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("failed to parse notification: %w", err)
+	}
+	order := json.RawMessage(body)
+	if len(envelope.Data) > 0 && envelope.Data[0] == '{' {
+		order = envelope.Data
+		var data struct {
+			PaymentID string `json:"paymentId"`
+		}
+		if err := json.Unmarshal(order, &data); err != nil {
+			return nil, fmt.Errorf("failed to parse notification: %w", err)
+		}
+		envelope.PaymentID = data.PaymentID
+	}
+
+	notification := &PaymentNotification{Raw: body}
+	if envelope.PaymentID != "" || strings.HasPrefix(envelope.EventType, "refund.") {
+		notification.Type = OrderTypeRefund
+		notification.Refund = &Refund{}
+		err = json.Unmarshal(order, notification.Refund)
+	} else {
+		notification.Type = OrderTypePayment
+		notification.Payment = &Payment{}
+		err = json.Unmarshal(order, notification.Payment)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s notification: %w", notification.Type, err)
+	}
+	if notification.orderNo() == "" {
+		return nil, fmt.Errorf("%s notification has no order ID", notification.Type)
+	}
+	return notification, nil
+}
+
+// orderNo returns the order number the notified order can be queried with
+func (n *PaymentNotification) orderNo() string {
+	if n.Type == OrderTypeRefund {
+		if n.Refund.ID != "" {
+			return n.Refund.ID
+		}
+		return n.Refund.MerchantTradeNo
+	}
+	if n.Payment.ID != "" {
+		return n.Payment.ID
+	}
+	return n.Payment.MerchantTradeNo
+}
+
+// confirm replaces the notified order with the API response
+func (h *PaymentNotifyHandler) confirm(ctx context.Context, notification *PaymentNotification) error {
+	if notification.Type == OrderTypeRefund {
+		refund, err := h.Confirm.QueryRefund(ctx, notification.orderNo())
+		if err != nil {
+			return err
+		}
+		notification.Refund = refund
+	} else {
+		payment, err := h.Confirm.QueryPayment(ctx, notification.orderNo())
+		if err != nil {
+			return err
+		}
+		notification.Payment = payment
+	}
+	notification.Confirmed = true
+	return nil
+}
+
+// dispatch runs the callback of a notification
+func (h *PaymentNotifyHandler) dispatch(ctx context.Context, notification *PaymentNotification) (bool, error) {
+	if notification.Type == OrderTypeRefund {
+		if h.OnRefund == nil {
+			return false, nil
+		}
+		return true, h.OnRefund(ctx, notification.Refund)
+	}
+	if h.OnPayment == nil {
+		return false, nil
+	}
+	return true, h.OnPayment(ctx, notification.Payment)
+}
+
+// ServeHTTP handles payment notification requests
+func (h *PaymentNotifyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.client.webhookSecret == "" && h.Confirm == nil {
+		http.Error(w, errNotificationsUnverified.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	notification, err := h.ParseNotification(r)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrInvalidNotificationSignature) {
+			status = http.StatusUnauthorized
+		}
+		http.Error(w, fmt.Sprintf("Failed to parse notification: %v", err), status)
+		return
+	}
+
+	ctx := r.Context()
+	// The order is confirmed first, so that it is deduplicated on the state the API reports
+	// rather than on the notified one
+	if h.Confirm != nil {
+		if err := h.confirm(ctx, notification); err != nil {
+			http.Error(w, fmt.Sprintf("Failed to confirm notification: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	key := notification.key()
+	if h.Store != nil {
+		fresh, err := h.Store.MarkProcessed(ctx, key)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Failed to deduplicate notification: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if !fresh {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"status":"duplicate"}`))
+			return
+		}
+	}
+
+	handled, err := h.dispatch(ctx, notification)
+	if err != nil {
+		// A notification that was not processed must be processed when delivered again
+		if h.Store != nil {
+			h.Store.Forget(context.WithoutCancel(ctx), key)
+		}
+		http.Error(w, fmt.Sprintf("Handler error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	if !handled {
+		w.Write([]byte(`{"status":"ignored","message":"No handler registered for notification type"}`))
+		return
+	}
+	w.Write([]byte(`{"status":"success"}`))
+}
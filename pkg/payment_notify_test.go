@@ -0,0 +1,195 @@
+package interlace
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testWebhookSecret = "whsec"
+
+func signNotification(body string) string {
+	mac := hmac.New(sha256.New, []byte(testWebhookSecret))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func postNotification(handler http.Handler, body, signature string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/notify", strings.NewReader(body))
+	if signature != "" {
+		req.Header.Set("X-Interlace-Signature", signature)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+// paymentQueryServer answers payment queries with the given statuses in turn
+type paymentQueryServer struct {
+	mu       sync.Mutex
+	statuses []string
+	queries  int
+}
+
+func (s *paymentQueryServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.URL.Path != "/open-api/v3/acquiring/payments" {
+		http.NotFound(w, r)
+		return
+	}
+	status := s.statuses[s.queries]
+	s.queries++
+	json.NewEncoder(w).Encode(Payment{ID: r.URL.Query().Get("orderNo"), MerchantTradeNo: "order-1", Status: status})
+}
+
+func newTestPaymentClient(t *testing.T, handler http.Handler) *PaymentClient {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	cfg := DefaultConfig()
+	cfg.BaseURL = srv.URL
+	cfg.MaxRetries = 0
+	return NewClientWithToken(cfg, "token").Payment
+}
+
+func TestPaymentNotifyHandlerSignature(t *testing.T) {
+	body := `{"id":"pay-1","merchantTradeNo":"order-1","status":"SUCCESS"}`
+	tests := []struct {
+		name      string
+		signature string
+		status    int
+		payments  int
+	}{
+		{name: "valid", signature: signNotification(body), status: http.StatusOK, payments: 1},
+		{name: "missing", status: http.StatusUnauthorized},
+		{name: "invalid", signature: signNotification(body + " "), status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var payments []*Payment
+			handler := NewPaymentNotifyHandler(testWebhookSecret)
+			handler.OnPayment = func(ctx context.Context, payment *Payment) error {
+				payments = append(payments, payment)
+				return nil
+			}
+
+			rec := postNotification(handler, body, tt.signature)
+			assert.Equal(t, tt.status, rec.Code, rec.Body.String())
+			require.Len(t, payments, tt.payments)
+			if tt.payments > 0 {
+				assert.Equal(t, "order-1", payments[0].MerchantTradeNo)
+			}
+		})
+	}
+}
+
+func TestPaymentNotifyHandlerUnverified(t *testing.T) {
+	handler := NewPaymentNotifyHandler("")
+	handler.OnPayment = func(ctx context.Context, payment *Payment) error {
+		t.Fatal("unverified notification dispatched")
+		return nil
+	}
+
+	rec := postNotification(handler, `{"id":"pay-1","status":"SUCCESS"}`, "")
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+func TestPaymentNotifyHandlerDeduplicates(t *testing.T) {
+	var calls int
+	fail := true
+	handler := NewPaymentNotifyHandler(testWebhookSecret)
+	handler.OnPayment = func(ctx context.Context, payment *Payment) error {
+		calls++
+		if fail {
+			return errors.New("database down")
+		}
+		return nil
+	}
+	post := func(body string) *httptest.ResponseRecorder {
+		return postNotification(handler, body, signNotification(body))
+	}
+
+	paid := `{"id":"pay-1","merchantTradeNo":"order-1","status":"SUCCESS"}`
+	rec := post(paid)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code, "a callback error asks for a redelivery")
+
+	fail = false
+	rec = post(paid)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"success"}`, rec.Body.String())
+
+	// The same state under another status or order number is a redelivery
+	rec = post(`{"eventType":"payment.paid","data":{"merchantTradeNo":"order-1","status":"PAID"}}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"duplicate"}`, rec.Body.String())
+	assert.Equal(t, 2, calls)
+
+	// A refund of the order is a notification of its own
+	rec = post(`{"id":"ref-1","paymentId":"pay-1","merchantTradeNo":"order-1","status":"SUCCESS"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"ignored","message":"No handler registered for notification type"}`, rec.Body.String())
+}
+
+func TestPaymentNotifyHandlerConfirm(t *testing.T) {
+	// The first notification claims a payment the API still reports pending
+	server := &paymentQueryServer{statuses: []string{"PENDING", "SUCCESS", "SUCCESS"}}
+	handler := NewPaymentNotifyHandler(testWebhookSecret)
+	handler.Confirm = newTestPaymentClient(t, server)
+	var statuses []string
+	handler.OnPayment = func(ctx context.Context, payment *Payment) error {
+		statuses = append(statuses, payment.Status)
+		return nil
+	}
+
+	body := `{"id":"pay-1","merchantTradeNo":"order-1","status":"SUCCESS"}`
+	for i := 0; i < 3; i++ {
+		rec := postNotification(handler, body, signNotification(body))
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	}
+
+	assert.Equal(t, 3, server.queries)
+	assert.Equal(t, []string{"PENDING", "SUCCESS"}, statuses,
+		"the pending confirmation does not use up the paid notification")
+}
+
+func TestPaymentNotifyHandlerConfirmError(t *testing.T) {
+	var calls int
+	handler := NewPaymentNotifyHandler(testWebhookSecret)
+	handler.Confirm = newTestPaymentClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(Payment{ID: "pay-1", MerchantTradeNo: "order-1", Status: "SUCCESS"})
+	}))
+	var dispatched int
+	handler.OnPayment = func(ctx context.Context, payment *Payment) error {
+		dispatched++
+		return nil
+	}
+
+	body := `{"id":"pay-1","merchantTradeNo":"order-1","status":"SUCCESS"}`
+	rec := postNotification(handler, body, signNotification(body))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Zero(t, dispatched)
+
+	rec = postNotification(handler, body, signNotification(body))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"success"}`, rec.Body.String())
+	assert.Equal(t, 1, dispatched)
+}
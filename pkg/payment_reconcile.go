@@ -0,0 +1,352 @@
+package interlace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PaymentDiscrepancyKind classifies the discrepancies found by a payment reconciliation
+type PaymentDiscrepancyKind string
+
+// Payment discrepancy kinds
+const (
+	// DiscrepancyNotFound means an order number is unknown to the API
+	DiscrepancyNotFound PaymentDiscrepancyKind = "not_found"
+	// DiscrepancyStuckPending means an order is still pending after the stuck threshold
+	DiscrepancyStuckPending PaymentDiscrepancyKind = "stuck_pending"
+	// DiscrepancyOverRefund means the refunds of a payment exceed its amount
+	DiscrepancyOverRefund PaymentDiscrepancyKind = "over_refund"
+	// DiscrepancyRefundCurrency means a refund is in another currency than its payment
+	DiscrepancyRefundCurrency PaymentDiscrepancyKind = "refund_currency_mismatch"
+	// DiscrepancyUnpaidRefund means a refund went through for a payment that did not succeed
+	DiscrepancyUnpaidRefund PaymentDiscrepancyKind = "refund_of_unpaid_payment"
+	// DiscrepancyWebhookMismatch means the last webhook event of an order disagrees with its
+	// status in the API
+	DiscrepancyWebhookMismatch PaymentDiscrepancyKind = "webhook_mismatch"
+)
+
+// Reconciled order types
+const (
+	OrderTypePayment = "payment"
+	OrderTypeRefund  = "refund"
+)
+
+// PaymentDiscrepancy is a discrepancy found by a payment reconciliation
+type PaymentDiscrepancy struct {
+	Kind            PaymentDiscrepancyKind `json:"kind"`
+	OrderType       string                 `json:"orderType,omitempty"`
+	MerchantTradeNo string                 `json:"merchantTradeNo"`
+	OrderID         string                 `json:"orderId,omitempty"`
+	Status          string                 `json:"status,omitempty"`
+	// Amount and Limit are the refunded and paid amounts of over-refunds
+	Amount   float64 `json:"amount,omitempty"`
+	Limit    float64 `json:"limit,omitempty"`
+	Currency string  `json:"currency,omitempty"`
+	Detail   string  `json:"detail"`
+}
+
+// ReconciledOrder is the state of a payment or refund after reconciliation
+type ReconciledOrder struct {
+	Type            string         `json:"type"`
+	MerchantTradeNo string         `json:"merchantTradeNo"`
+	ID              string         `json:"id"`
+	Amount          float64        `json:"amount"`
+	Currency        string         `json:"currency"`
+	Status          string         `json:"status"`
+	State           OperationState `json:"-"`
+	Final           bool           `json:"final"`
+	CreatedAt       string         `json:"createdAt,omitempty"`
+	// PaymentID is the payment of a refund
+	PaymentID string `json:"paymentId,omitempty"`
+	// RefundedAmount is the total of the refunds of a payment that did not fail
+	RefundedAmount float64 `json:"refundedAmount,omitempty"`
+	// Refunds are the IDs of the refunds of a payment
+	Refunds []string `json:"refunds,omitempty"`
+}
+
+// PaymentReconcileReport is the result of a payment reconciliation
+type PaymentReconcileReport struct {
+	GeneratedAt time.Time `json:"generatedAt"`
+	// Requested is the number of order numbers reconciled
+	Requested     int                  `json:"requested"`
+	Final         int                  `json:"final"`
+	Pending       int                  `json:"pending"`
+	Orders        []ReconciledOrder    `json:"orders"`
+	Discrepancies []PaymentDiscrepancy `json:"discrepancies"`
+}
+
+// OK reports whether every order reached a final state without discrepancies
+func (r *PaymentReconcileReport) OK() bool {
+	return r.Pending == 0 && len(r.Discrepancies) == 0
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *PaymentReconcileReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// PaymentReconcileRequest selects the orders to reconcile
+type PaymentReconcileRequest struct {
+	// MerchantTradeNos are the merchant trade numbers of the payments and refunds to reconcile.
+	// Refunded totals only account for the refunds listed here.
+	MerchantTradeNos []string
+	// Events are webhook events received for the orders. The last event of each order is checked
+	// against its status in the API.
+	Events []*WebhookEvent
+	// StuckAfter is how long an order may stay pending before it is reported as stuck. Defaults
+	// to 24 hours.
+	StuckAfter time.Duration
+	// BatchSize is the number of order numbers searched per request. Defaults to 50.
+	BatchSize int
+}
+
+// paymentState maps payment and refund statuses, including the acquiring statuses of paid
+// orders, to operation states
+func paymentState(status string) OperationState {
+	switch strings.ToUpper(status) {
+	case "PAID", "SETTLED", "REFUNDED", "PARTIALLY_REFUNDED":
+		return OperationSucceeded
+	}
+	return statusState(status)
+}
+
+func parseOrderAmount(amount string) float64 {
+	value, _ := strconv.ParseFloat(strings.TrimSpace(amount), 64)
+	return value
+}
+
+// Reconcile proves that the given payments and refunds reached a final state. It searches the
+// orders in batches, checks the refunds of each payment against its amount and reports the
+// orders that are unknown, stuck pending, over-refunded or inconsistent with the webhook events
+// received for them.
+func (c *PaymentClient) Reconcile(ctx context.Context, req *PaymentReconcileRequest, callOpts ...CallOption) (*PaymentReconcileReport, error) {
+	if req == nil || len(req.MerchantTradeNos) == 0 {
+		return nil, fmt.Errorf("merchantTradeNos are required")
+	}
+	stuckAfter := req.StuckAfter
+	if stuckAfter <= 0 {
+		stuckAfter = 24 * time.Hour
+	}
+	batchSize := req.BatchSize
+	if batchSize <= 0 {
+		batchSize = 50
+	}
+
+	var orderNos []string
+	requested := make(map[string]bool)
+	for _, orderNo := range req.MerchantTradeNos {
+		if orderNo = strings.TrimSpace(orderNo); orderNo != "" && !requested[orderNo] {
+			requested[orderNo] = true
+			orderNos = append(orderNos, orderNo)
+		}
+	}
+
+	payments := make(map[string]*Payment)
+	refunds := make(map[string]*Refund)
+	search := func(orderNos []string) error {
+		for start := 0; start < len(orderNos); start += batchSize {
+			end := start + batchSize
+			if end > len(orderNos) {
+				end = len(orderNos)
+			}
+			result, err := c.Search(ctx, orderNos[start:end], callOpts...)
+			if err != nil {
+				return fmt.Errorf("failed to search orders %d-%d: %w", start+1, end, err)
+			}
+			for i := range result.Payments {
+				payments[result.Payments[i].ID] = &result.Payments[i]
+			}
+			for i := range result.Refunds {
+				refunds[result.Refunds[i].ID] = &result.Refunds[i]
+			}
+		}
+		return nil
+	}
+	if err := search(orderNos); err != nil {
+		return nil, err
+	}
+
+	// The payments of the refunds are needed to check the refunded totals
+	var parents []string
+	missing := make(map[string]bool)
+	for _, refund := range refunds {
+		if _, ok := payments[refund.PaymentID]; !ok && refund.PaymentID != "" && !missing[refund.PaymentID] {
+			missing[refund.PaymentID] = true
+			parents = append(parents, refund.PaymentID)
+		}
+	}
+	sort.Strings(parents)
+	if err := search(parents); err != nil {
+		return nil, err
+	}
+
+	report := &PaymentReconcileReport{GeneratedAt: time.Now(), Requested: len(orderNos)}
+	discrepancy := func(order *ReconciledOrder, kind PaymentDiscrepancyKind, detail string) *PaymentDiscrepancy {
+		report.Discrepancies = append(report.Discrepancies, PaymentDiscrepancy{
+			Kind:            kind,
+			OrderType:       order.Type,
+			MerchantTradeNo: order.MerchantTradeNo,
+			OrderID:         order.ID,
+			Status:          order.Status,
+			Currency:        order.Currency,
+			Detail:          detail,
+		})
+		return &report.Discrepancies[len(report.Discrepancies)-1]
+	}
+
+	// Orders are indexed by both their merchant trade number and their ID
+	orders := make(map[string]*ReconciledOrder)
+	paymentOrders := make(map[string]*ReconciledOrder)
+	for _, payment := range payments {
+		order := &ReconciledOrder{
+			Type:            OrderTypePayment,
+			MerchantTradeNo: payment.MerchantTradeNo,
+			ID:              payment.ID,
+			Amount:          parseOrderAmount(payment.Amount),
+			Currency:        strings.ToUpper(payment.Currency),
+			Status:          payment.Status,
+			State:           paymentState(payment.Status),
+			CreatedAt:       payment.CreatedAt,
+		}
+		paymentOrders[payment.ID] = order
+		orders[payment.ID], orders[payment.MerchantTradeNo] = order, order
+	}
+	var refundIDs []string
+	for id := range refunds {
+		refundIDs = append(refundIDs, id)
+	}
+	sort.Strings(refundIDs)
+	for _, id := range refundIDs {
+		refund := refunds[id]
+		order := &ReconciledOrder{
+			Type:            OrderTypeRefund,
+			MerchantTradeNo: refund.MerchantTradeNo,
+			ID:              refund.ID,
+			Amount:          parseOrderAmount(refund.Amount),
+			Currency:        strings.ToUpper(refund.Currency),
+			Status:          refund.Status,
+			State:           paymentState(refund.Status),
+			CreatedAt:       refund.CreatedAt,
+			PaymentID:       refund.PaymentID,
+		}
+		orders[refund.ID], orders[refund.MerchantTradeNo] = order, order
+
+		payment, ok := paymentOrders[refund.PaymentID]
+		if !ok || order.State == OperationFailed {
+			continue
+		}
+		payment.Refunds = append(payment.Refunds, refund.ID)
+		payment.RefundedAmount = roundCents(payment.RefundedAmount + order.Amount)
+		if order.Currency != "" && payment.Currency != "" && order.Currency != payment.Currency {
+			discrepancy(order, DiscrepancyRefundCurrency,
+				fmt.Sprintf("refund in %s of payment %s in %s", order.Currency, payment.MerchantTradeNo, payment.Currency))
+		}
+		if order.State == OperationSucceeded && payment.State != OperationSucceeded {
+			discrepancy(order, DiscrepancyUnpaidRefund,
+				fmt.Sprintf("refund completed for payment %s with status %s", payment.MerchantTradeNo, payment.Status))
+		}
+	}
+
+	for _, orderNo := range orderNos {
+		order, ok := orders[orderNo]
+		if !ok {
+			report.Discrepancies = append(report.Discrepancies, PaymentDiscrepancy{
+				Kind:            DiscrepancyNotFound,
+				MerchantTradeNo: orderNo,
+				Detail:          "order not found",
+			})
+			continue
+		}
+
+		order.Final = order.State != OperationPending
+		if order.Final {
+			report.Final++
+		} else {
+			report.Pending++
+			if created, ok := parseAPITime(order.CreatedAt); ok && time.Since(created) > stuckAfter {
+				discrepancy(order, DiscrepancyStuckPending,
+					fmt.Sprintf("pending for %s", time.Since(created).Round(time.Minute)))
+			}
+		}
+
+		if order.Type == OrderTypePayment && order.RefundedAmount > order.Amount+ledgerTolerance {
+			d := discrepancy(order, DiscrepancyOverRefund,
+				fmt.Sprintf("refunded %.2f of %.2f", order.RefundedAmount, order.Amount))
+			d.Amount, d.Limit = order.RefundedAmount, order.Amount
+		}
+		report.Orders = append(report.Orders, *order)
+	}
+
+	// Over-refunds of payments reached only through their refunds are reported as well
+	for _, id := range parents {
+		if order, ok := paymentOrders[id]; ok && !requested[order.MerchantTradeNo] && !requested[order.ID] &&
+			order.RefundedAmount > order.Amount+ledgerTolerance {
+			d := discrepancy(order, DiscrepancyOverRefund,
+				fmt.Sprintf("refunded %.2f of %.2f", order.RefundedAmount, order.Amount))
+			d.Amount, d.Limit = order.RefundedAmount, order.Amount
+		}
+	}
+
+	reconcileWebhookEvents(req.Events, orders, discrepancy)
+	return report, nil
+}
+
+// reconcileWebhookEvents checks the last completed or failed event of each order against its
+// status in the API. Events reference orders by merchantTradeNo, orderNo or id in their data.
+func reconcileWebhookEvents(events []*WebhookEvent, orders map[string]*ReconciledOrder, discrepancy func(*ReconciledOrder, PaymentDiscrepancyKind, string) *PaymentDiscrepancy) {
+	type lastEvent struct {
+		event *WebhookEvent
+		state OperationState
+		time  time.Time
+	}
+	last := make(map[*ReconciledOrder]*lastEvent)
+	var reconciled []*ReconciledOrder
+	for _, event := range events {
+		if event == nil {
+			continue
+		}
+		var state OperationState
+		switch {
+		case strings.HasSuffix(event.EventType, ".completed"):
+			state = OperationSucceeded
+		case strings.HasSuffix(event.EventType, ".failed"):
+			state = OperationFailed
+		default:
+			continue
+		}
+
+		var order *ReconciledOrder
+		for _, key := range []string{"merchantTradeNo", "orderNo", "id"} {
+			if value, ok := event.Data[key].(string); ok && orders[value] != nil {
+				order = orders[value]
+				break
+			}
+		}
+		if order == nil {
+			continue
+		}
+		received, _ := parseAPITime(event.Timestamp)
+		if previous, ok := last[order]; ok && received.Before(previous.time) {
+			continue
+		}
+		if _, ok := last[order]; !ok {
+			reconciled = append(reconciled, order)
+		}
+		last[order] = &lastEvent{event: event, state: state, time: received}
+	}
+
+	for _, order := range reconciled {
+		if event := last[order]; event.state != order.State {
+			discrepancy(order, DiscrepancyWebhookMismatch,
+				fmt.Sprintf("webhook event %s %s but status is %s", event.event.EventID, event.event.EventType, order.Status))
+		}
+	}
+}
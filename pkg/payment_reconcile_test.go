@@ -0,0 +1,187 @@
+package interlace
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// searchServer finds its payments and refunds by ID or merchant trade number and records the
+// order numbers of each search
+type searchServer struct {
+	mu       sync.Mutex
+	payments []Payment
+	refunds  []Refund
+	searches [][]string
+}
+
+func (s *searchServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var req SearchRequest
+	json.NewDecoder(r.Body).Decode(&req)
+	s.searches = append(s.searches, req.OrderNos)
+	result := SearchResult{}
+	for _, orderNo := range req.OrderNos {
+		for _, payment := range s.payments {
+			if payment.ID == orderNo || payment.MerchantTradeNo == orderNo {
+				result.Payments = append(result.Payments, payment)
+			}
+		}
+		for _, refund := range s.refunds {
+			if refund.ID == orderNo || refund.MerchantTradeNo == orderNo {
+				result.Refunds = append(result.Refunds, refund)
+			}
+		}
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+func testPayment(id, amount, currency, status string) Payment {
+	return Payment{ID: id, MerchantTradeNo: "order-" + id, Amount: amount, Currency: currency, Status: status,
+		CreatedAt: time.Now().Add(-time.Hour).Format(time.RFC3339)}
+}
+
+func testPaymentRefund(id, paymentID, amount, currency, status string) Refund {
+	return Refund{ID: id, PaymentID: paymentID, MerchantTradeNo: "order-" + id, Amount: amount, Currency: currency,
+		Status: status, CreatedAt: time.Now().Add(-time.Hour).Format(time.RFC3339)}
+}
+
+// discrepancyKinds returns the kind of each discrepancy by merchant trade number
+func discrepancyKinds(report *PaymentReconcileReport) map[string][]PaymentDiscrepancyKind {
+	kinds := make(map[string][]PaymentDiscrepancyKind)
+	for _, d := range report.Discrepancies {
+		kinds[d.MerchantTradeNo] = append(kinds[d.MerchantTradeNo], d.Kind)
+	}
+	return kinds
+}
+
+func TestReconcileRefunds(t *testing.T) {
+	server := &searchServer{
+		payments: []Payment{
+			testPayment("p1", "100.00", "usd", "SUCCESS"),
+			testPayment("p2", "50.00", "USD", "FAILED"),
+		},
+		refunds: []Refund{
+			testPaymentRefund("r1", "p1", "60.00", "USD", "SUCCESS"),
+			testPaymentRefund("r2", "p1", "50.00", "USD", "PENDING"),
+			testPaymentRefund("r3", "p1", "30.00", "USD", "FAILED"),
+			testPaymentRefund("r4", "p2", "10.00", "EUR", "SUCCESS"),
+		},
+	}
+	payments := newTestPaymentClient(t, server)
+
+	report, err := payments.Reconcile(context.Background(), &PaymentReconcileRequest{
+		MerchantTradeNos: []string{"order-p1", "order-r1", "order-r2", "order-r3", "order-p2", "order-r4"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 6, report.Requested)
+	require.Len(t, report.Orders, 6)
+	payment := report.Orders[0]
+	assert.Equal(t, 110.0, payment.RefundedAmount, "failed refunds are not counted")
+	assert.Equal(t, []string{"r1", "r2"}, payment.Refunds)
+
+	assert.Equal(t, map[string][]PaymentDiscrepancyKind{
+		"order-r4": {DiscrepancyRefundCurrency, DiscrepancyUnpaidRefund},
+		"order-p1": {DiscrepancyOverRefund},
+	}, discrepancyKinds(report))
+	overRefund := report.Discrepancies[2]
+	assert.Equal(t, 110.0, overRefund.Amount)
+	assert.Equal(t, 100.0, overRefund.Limit)
+	assert.Equal(t, "USD", overRefund.Currency)
+	assert.False(t, report.OK())
+}
+
+func TestReconcileOverRefundOfUnrequestedPayment(t *testing.T) {
+	server := &searchServer{
+		payments: []Payment{testPayment("p1", "100.00", "USD", "SUCCESS")},
+		refunds: []Refund{
+			testPaymentRefund("r1", "p1", "60.00", "USD", "SUCCESS"),
+			testPaymentRefund("r2", "p1", "60.00", "USD", "SUCCESS"),
+		},
+	}
+	payments := newTestPaymentClient(t, server)
+
+	report, err := payments.Reconcile(context.Background(), &PaymentReconcileRequest{MerchantTradeNos: []string{"order-r1", "order-r2"}})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"order-r1", "order-r2"}, {"p1"}}, server.searches, "the payment is fetched by ID")
+	assert.Len(t, report.Orders, 2, "the payment is not reported as an order")
+	assert.Equal(t, map[string][]PaymentDiscrepancyKind{"order-p1": {DiscrepancyOverRefund}}, discrepancyKinds(report))
+	assert.Equal(t, 120.0, report.Discrepancies[0].Amount)
+}
+
+func TestReconcileStuckPending(t *testing.T) {
+	stuck := testPayment("p1", "10.00", "USD", "PENDING")
+	stuck.CreatedAt = time.Now().Add(-48 * time.Hour).Format(time.RFC3339)
+	server := &searchServer{payments: []Payment{
+		stuck,
+		testPayment("p2", "10.00", "USD", "PROCESSING"),
+		testPayment("p3", "10.00", "USD", "PAID"),
+	}}
+	payments := newTestPaymentClient(t, server)
+
+	report, err := payments.Reconcile(context.Background(), &PaymentReconcileRequest{
+		MerchantTradeNos: []string{"order-p1", " order-p2 ", "order-p1", "", "order-p3", "order-unknown"},
+		BatchSize:        2,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"order-p1", "order-p2"}, {"order-p3", "order-unknown"}}, server.searches)
+	assert.Equal(t, 4, report.Requested)
+	assert.Equal(t, 1, report.Final)
+	assert.Equal(t, 2, report.Pending)
+	assert.Equal(t, map[string][]PaymentDiscrepancyKind{
+		"order-p1":      {DiscrepancyStuckPending},
+		"order-unknown": {DiscrepancyNotFound},
+	}, discrepancyKinds(report), "orders pending for less than a day are not stuck")
+
+	report, err = payments.Reconcile(context.Background(), &PaymentReconcileRequest{
+		MerchantTradeNos: []string{"order-p2"},
+		StuckAfter:       time.Minute,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]PaymentDiscrepancyKind{"order-p2": {DiscrepancyStuckPending}}, discrepancyKinds(report))
+}
+
+func TestReconcileWebhookMismatch(t *testing.T) {
+	server := &searchServer{
+		payments: []Payment{
+			testPayment("p1", "10.00", "USD", "SUCCESS"),
+			testPayment("p2", "10.00", "USD", "PENDING"),
+			testPayment("p3", "10.00", "USD", "SUCCESS"),
+		},
+		refunds: []Refund{testPaymentRefund("r1", "p3", "5.00", "USD", "FAILED")},
+	}
+	payments := newTestPaymentClient(t, server)
+	at := func(minute int) string {
+		return time.Date(2024, 3, 1, 12, minute, 0, 0, time.UTC).Format(time.RFC3339)
+	}
+
+	report, err := payments.Reconcile(context.Background(), &PaymentReconcileRequest{
+		MerchantTradeNos: []string{"order-p1", "order-p2", "order-r1"},
+		Events: []*WebhookEvent{
+			// The last event is the latest one, not the last one received
+			{EventID: "e1", EventType: "payment.completed", Timestamp: at(2), Data: map[string]interface{}{"merchantTradeNo": "order-p1"}},
+			{EventID: "e2", EventType: "payment.failed", Timestamp: at(1), Data: map[string]interface{}{"merchantTradeNo": "order-p1"}},
+			{EventID: "e3", EventType: "payment.completed", Timestamp: at(3), Data: map[string]interface{}{"id": "p2"}},
+			{EventID: "e4", EventType: "refund.completed", Timestamp: at(4), Data: map[string]interface{}{"orderNo": "order-r1"}},
+			// Events that are not final or of other orders are ignored
+			{EventID: "e5", EventType: "payment.created", Timestamp: at(5), Data: map[string]interface{}{"id": "p1"}},
+			{EventID: "e6", EventType: "payment.failed", Timestamp: at(6), Data: map[string]interface{}{"id": "p9"}},
+			nil,
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]PaymentDiscrepancyKind{
+		"order-p2": {DiscrepancyWebhookMismatch},
+		"order-r1": {DiscrepancyWebhookMismatch},
+	}, discrepancyKinds(report))
+	assert.Equal(t, "webhook event e3 payment.completed but status is PENDING", report.Discrepancies[0].Detail)
+	assert.Equal(t, OrderTypeRefund, report.Discrepancies[1].OrderType)
+}
@@ -0,0 +1,246 @@
+package interlace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrRefundExceedsPayment is returned, wrapped in a *RefundLimitError, when a refund would exceed
+// the refundable remainder of its payment
+var ErrRefundExceedsPayment = errors.New("interlace: refund exceeds refundable amount")
+
+// ErrPaymentNotRefundable is returned when a refund is requested for a payment that did not
+// succeed
+var ErrPaymentNotRefundable = errors.New("interlace: payment is not refundable")
+
+// RefundLimitError reports a refund blocked because it exceeds what remains refundable
+type RefundLimitError struct {
+	MerchantTradeNo string
+	Requested       float64
+	Refundable      float64
+	Currency        string
+}
+
+func (e *RefundLimitError) Error() string {
+	return fmt.Sprintf("%v: %.2f %s requested for payment %s but only %.2f %s is refundable",
+		ErrRefundExceedsPayment, e.Requested, e.Currency, e.MerchantTradeNo, e.Refundable, e.Currency)
+}
+
+// Is makes errors.Is(err, ErrRefundExceedsPayment) match
+func (e *RefundLimitError) Is(target error) bool {
+	return target == ErrRefundExceedsPayment
+}
+
+// refundProbeSize is the number of refund merchant trade numbers searched at a time when
+// discovering the refunds of a payment
+const refundProbeSize = 10
+
+// RefundMerchantTradeNo returns the merchant trade number of the seq-th refund of a payment,
+// starting at 1. RefundPayment numbers refunds this way so that RefundHistory can find them.
+func RefundMerchantTradeNo(paymentMerchantTradeNo string, seq int) string {
+	return fmt.Sprintf("%s-R%d", paymentMerchantTradeNo, seq)
+}
+
+// refundSeq returns the sequence number of a refund merchant trade number, or 0 when it was not
+// generated by RefundMerchantTradeNo for the payment
+func refundSeq(paymentMerchantTradeNo, refundMerchantTradeNo string) int {
+	suffix, ok := strings.CutPrefix(refundMerchantTradeNo, paymentMerchantTradeNo+"-R")
+	if !ok {
+		return 0
+	}
+	seq, err := strconv.Atoi(suffix)
+	if err != nil || seq <= 0 {
+		return 0
+	}
+	return seq
+}
+
+// RefundHistory is the consolidated refund state of a payment
+type RefundHistory struct {
+	Payment  *Payment
+	Currency string
+	// PaidAmount is the amount of the payment
+	PaidAmount float64
+	// Refunds are the refunds of the payment, oldest first
+	Refunds []Refund
+	// RefundedAmount is the total of the completed refunds
+	RefundedAmount float64
+	// PendingAmount is the total of the refunds still in progress, which are reserved against
+	// the refundable amount
+	PendingAmount float64
+	// RefundableAmount is what remains refundable
+	RefundableAmount float64
+	// NextMerchantTradeNo is the merchant trade number of the next refund
+	NextMerchantTradeNo string
+}
+
+// FullyRefunded reports whether nothing remains refundable
+func (h *RefundHistory) FullyRefunded() bool {
+	return h.RefundableAmount <= 0
+}
+
+// RefundHistory fetches a payment and its refunds. Refunds are found among the search results of
+// the payment and by probing the merchant trade numbers generated by RefundMerchantTradeNo.
+//
+// Refunds created with other merchant trade numbers, such as refunds made in the dashboard or
+// with a plain CreateRefund, are NOT found unless they are passed in knownRefunds. The history
+// then overstates the refundable amount and RefundPayment may attempt an over-refund.
+func (c *PaymentClient) RefundHistory(ctx context.Context, paymentOrderNo string, knownRefunds []string, callOpts ...CallOption) (*RefundHistory, error) {
+	if paymentOrderNo == "" {
+		return nil, fmt.Errorf("payment orderNo is required")
+	}
+	payment, err := c.QueryPayment(ctx, paymentOrderNo, callOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	refunds := make(map[string]Refund)
+	collect := func(result *SearchResult) {
+		for _, refund := range result.Refunds {
+			if refund.PaymentID == payment.ID || refundSeq(payment.MerchantTradeNo, refund.MerchantTradeNo) > 0 {
+				refunds[refund.ID] = refund
+			}
+		}
+	}
+
+	orderNos := append(nonEmpty(payment.ID, payment.MerchantTradeNo), knownRefunds...)
+	result, err := c.Search(ctx, orderNos, callOpts...)
+	if err != nil {
+		return nil, err
+	}
+	collect(result)
+
+	// Generated numbers are sequential, so probing continues only while batches are used up
+	lastSeq := 0
+	for start := 1; ; start += refundProbeSize {
+		probe := make([]string, refundProbeSize)
+		for i := range probe {
+			probe[i] = RefundMerchantTradeNo(payment.MerchantTradeNo, start+i)
+		}
+		result, err := c.Search(ctx, probe, callOpts...)
+		if err != nil {
+			return nil, err
+		}
+		collect(result)
+
+		for _, refund := range result.Refunds {
+			if seq := refundSeq(payment.MerchantTradeNo, refund.MerchantTradeNo); seq > lastSeq {
+				lastSeq = seq
+			}
+		}
+		if lastSeq < start+refundProbeSize-1 {
+			break
+		}
+	}
+
+	history := &RefundHistory{
+		Payment:    payment,
+		Currency:   strings.ToUpper(payment.Currency),
+		PaidAmount: parseOrderAmount(payment.Amount),
+	}
+	for _, refund := range refunds {
+		history.Refunds = append(history.Refunds, refund)
+		switch paymentState(refund.Status) {
+		case OperationSucceeded:
+			history.RefundedAmount = roundCents(history.RefundedAmount + parseOrderAmount(refund.Amount))
+		case OperationPending:
+			history.PendingAmount = roundCents(history.PendingAmount + parseOrderAmount(refund.Amount))
+		}
+		if seq := refundSeq(payment.MerchantTradeNo, refund.MerchantTradeNo); seq > lastSeq {
+			lastSeq = seq
+		}
+	}
+	sort.Slice(history.Refunds, func(i, j int) bool {
+		a, b := &history.Refunds[i], &history.Refunds[j]
+		if a.CreatedAt != b.CreatedAt {
+			return a.CreatedAt < b.CreatedAt
+		}
+		return refundSeq(payment.MerchantTradeNo, a.MerchantTradeNo) < refundSeq(payment.MerchantTradeNo, b.MerchantTradeNo)
+	})
+	history.RefundableAmount = roundCents(history.PaidAmount - history.RefundedAmount - history.PendingAmount)
+	if history.RefundableAmount < 0 {
+		history.RefundableAmount = 0
+	}
+	history.NextMerchantTradeNo = RefundMerchantTradeNo(payment.MerchantTradeNo, lastSeq+1)
+	return history, nil
+}
+
+// RefundPaymentRequest describes a refund of a payment
+type RefundPaymentRequest struct {
+	// PaymentOrderNo is the system order ID or merchant trade number of the payment
+	PaymentOrderNo string
+	// Amount to refund. Zero refunds the whole refundable remainder.
+	Amount float64
+	Reason string
+	// KnownRefunds are merchant trade numbers of refunds not created by RefundPayment
+	KnownRefunds []string
+}
+
+// RefundPayment refunds a payment after checking its refund history. Refunds that would exceed
+// the refundable remainder, pending refunds included, fail with a *RefundLimitError. The refund
+// gets the next merchant trade number of the payment, so that two concurrent refunds computed
+// from the same history share their idempotency key and only one of them is created. When the
+// refund returned for that key is for another amount, the refund and an error are returned.
+// The refund history has the same blind spots as RefundHistory: pass the refunds not created
+// by RefundPayment in KnownRefunds.
+func (c *PaymentClient) RefundPayment(ctx context.Context, req *RefundPaymentRequest, callOpts ...CallOption) (*Refund, *RefundHistory, error) {
+	if req == nil || req.PaymentOrderNo == "" {
+		return nil, nil, fmt.Errorf("payment orderNo is required")
+	}
+	if req.Amount < 0 || (req.Amount > 0 && roundCents(req.Amount) == 0) {
+		return nil, nil, fmt.Errorf("refund amount must be at least 0.01")
+	}
+
+	history, err := c.RefundHistory(ctx, req.PaymentOrderNo, req.KnownRefunds, callOpts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get refund history: %w", err)
+	}
+	if paymentState(history.Payment.Status) != OperationSucceeded {
+		return nil, history, fmt.Errorf("%w: payment %s is %s", ErrPaymentNotRefundable, history.Payment.MerchantTradeNo, history.Payment.Status)
+	}
+
+	amount := history.RefundableAmount
+	if req.Amount != 0 {
+		amount = roundCents(req.Amount)
+	}
+	if amount <= 0 || amount > history.RefundableAmount {
+		return nil, history, &RefundLimitError{
+			MerchantTradeNo: history.Payment.MerchantTradeNo,
+			Requested:       amount,
+			Refundable:      history.RefundableAmount,
+			Currency:        history.Currency,
+		}
+	}
+
+	refund, err := c.CreateRefund(ctx, &CreateRefundRequest{
+		SourceMerchantTradeNo: history.Payment.MerchantTradeNo,
+		MerchantTradeNo:       history.NextMerchantTradeNo,
+		Amount:                strconv.FormatFloat(amount, 'f', 2, 64),
+		Reason:                req.Reason,
+	}, callOpts...)
+	if err != nil {
+		return nil, history, err
+	}
+	if refund.Amount != "" && math.Abs(parseOrderAmount(refund.Amount)-amount) > ledgerTolerance {
+		return refund, history, fmt.Errorf("refund %s is for %s %s instead of %.2f: it was created by a concurrent refund of the same payment",
+			history.NextMerchantTradeNo, refund.Amount, history.Currency, amount)
+	}
+
+	history.Refunds = append(history.Refunds, *refund)
+	switch paymentState(refund.Status) {
+	case OperationSucceeded:
+		history.RefundedAmount = roundCents(history.RefundedAmount + amount)
+		history.RefundableAmount = roundCents(history.RefundableAmount - amount)
+	case OperationPending:
+		history.PendingAmount = roundCents(history.PendingAmount + amount)
+		history.RefundableAmount = roundCents(history.RefundableAmount - amount)
+	}
+	history.NextMerchantTradeNo = RefundMerchantTradeNo(history.Payment.MerchantTradeNo,
+		refundSeq(history.Payment.MerchantTradeNo, history.NextMerchantTradeNo)+1)
+	return refund, history, nil
+}
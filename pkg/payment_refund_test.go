@@ -0,0 +1,231 @@
+package interlace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// refundServer fakes the payment query, search and refund endpoints for one payment. Refunds are
+// created idempotently on their merchant trade number.
+type refundServer struct {
+	mu      sync.Mutex
+	payment Payment
+	refunds []Refund
+	// status is the status of created refunds
+	status  string
+	created []CreateRefundRequest
+}
+
+func (s *refundServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case r.URL.Path == "/open-api/v3/acquiring/payments":
+		json.NewEncoder(w).Encode(s.payment)
+	case r.URL.Path == "/open-api/v3/acquiring/search":
+		var req SearchRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		result := SearchResult{}
+		for _, orderNo := range req.OrderNos {
+			for _, refund := range s.refunds {
+				if refund.ID == orderNo || refund.MerchantTradeNo == orderNo {
+					result.Refunds = append(result.Refunds, refund)
+				}
+			}
+		}
+		json.NewEncoder(w).Encode(result)
+	case r.URL.Path == "/open-api/v3/acquiring/refunds" && r.Method == http.MethodPost:
+		var req CreateRefundRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		s.created = append(s.created, req)
+		for _, refund := range s.refunds {
+			if refund.MerchantTradeNo == req.MerchantTradeNo {
+				json.NewEncoder(w).Encode(refund)
+				return
+			}
+		}
+		refund := Refund{ID: fmt.Sprintf("ref-%d", len(s.refunds)+1), PaymentID: s.payment.ID,
+			MerchantTradeNo: req.MerchantTradeNo, Amount: req.Amount, Currency: s.payment.Currency, Status: s.status}
+		s.refunds = append(s.refunds, refund)
+		json.NewEncoder(w).Encode(refund)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newTestRefundServer(refunds ...Refund) *refundServer {
+	return &refundServer{
+		payment: Payment{ID: "pay-1", MerchantTradeNo: "order-1", Amount: "100.00", Currency: "usd", Status: "SUCCESS"},
+		refunds: refunds,
+		status:  "PENDING",
+	}
+}
+
+func testRefund(seq int, amount, status string) Refund {
+	return Refund{ID: fmt.Sprintf("old-%d", seq), PaymentID: "pay-1", MerchantTradeNo: RefundMerchantTradeNo("order-1", seq),
+		Amount: amount, Status: status, CreatedAt: fmt.Sprintf("2024-03-01T00:00:%02dZ", seq)}
+}
+
+func TestRefundHistory(t *testing.T) {
+	dashboard := Refund{ID: "dash-1", PaymentID: "pay-1", MerchantTradeNo: "manual-1", Amount: "10.00", Status: "SUCCESS",
+		CreatedAt: "2024-03-01T00:00:30Z"}
+	server := newTestRefundServer(testRefund(1, "30.00", "SUCCESS"), testRefund(2, "20.00", "PENDING"),
+		testRefund(3, "5.00", "FAILED"), dashboard)
+	payments := newTestPaymentClient(t, server)
+
+	history, err := payments.RefundHistory(context.Background(), "order-1", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "USD", history.Currency)
+	assert.Equal(t, 100.0, history.PaidAmount)
+	assert.Len(t, history.Refunds, 3)
+	assert.Equal(t, 30.0, history.RefundedAmount)
+	assert.Equal(t, 20.0, history.PendingAmount)
+	assert.Equal(t, 50.0, history.RefundableAmount, "the dashboard refund is not found")
+	assert.Equal(t, "order-1-R4", history.NextMerchantTradeNo)
+
+	history, err = payments.RefundHistory(context.Background(), "order-1", []string{"manual-1"})
+	require.NoError(t, err)
+	require.Len(t, history.Refunds, 4)
+	assert.Equal(t, "manual-1", history.Refunds[3].MerchantTradeNo)
+	assert.Equal(t, 40.0, history.RefundedAmount)
+	assert.Equal(t, 40.0, history.RefundableAmount)
+	assert.Equal(t, "order-1-R4", history.NextMerchantTradeNo)
+}
+
+func TestRefundHistoryProbesPastFullBatches(t *testing.T) {
+	var refunds []Refund
+	for seq := 1; seq <= refundProbeSize+2; seq++ {
+		refunds = append(refunds, testRefund(seq, "1.00", "SUCCESS"))
+	}
+	payments := newTestPaymentClient(t, newTestRefundServer(refunds...))
+
+	history, err := payments.RefundHistory(context.Background(), "order-1", nil)
+	require.NoError(t, err)
+	assert.Len(t, history.Refunds, refundProbeSize+2)
+	assert.Equal(t, 12.0, history.RefundedAmount)
+	assert.Equal(t, "order-1-R13", history.NextMerchantTradeNo)
+}
+
+func TestRefundPayment(t *testing.T) {
+	tests := []struct {
+		name    string
+		payment string
+		amount  float64
+		// created is the amount of the refund created, empty when none is
+		created string
+		err     error
+	}{
+		{name: "partial refund", amount: 25, created: "25.00"},
+		{name: "zero refunds the remainder", amount: 0, created: "50.00"},
+		{name: "the remainder", amount: 50.004, created: "50.00"},
+		{name: "over-refund", amount: 50.01, err: ErrRefundExceedsPayment},
+		{name: "payment not paid", payment: "PENDING", amount: 10, err: ErrPaymentNotRefundable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestRefundServer(testRefund(1, "30.00", "SUCCESS"), testRefund(2, "20.00", "PENDING"))
+			if tt.payment != "" {
+				server.payment.Status = tt.payment
+			}
+			payments := newTestPaymentClient(t, server)
+
+			refund, history, err := payments.RefundPayment(context.Background(), &RefundPaymentRequest{
+				PaymentOrderNo: "order-1",
+				Amount:         tt.amount,
+			})
+			if tt.err != nil {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, tt.err), err.Error())
+				assert.Nil(t, refund)
+				assert.Empty(t, server.created)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, server.created, 1)
+			assert.Equal(t, CreateRefundRequest{SourceMerchantTradeNo: "order-1", MerchantTradeNo: "order-1-R3",
+				Amount: tt.created}, server.created[0])
+			assert.Equal(t, tt.created, refund.Amount)
+			assert.Equal(t, 20+parseOrderAmount(tt.created), history.PendingAmount)
+			assert.Equal(t, roundCents(50-parseOrderAmount(tt.created)), history.RefundableAmount)
+			assert.Equal(t, "order-1-R4", history.NextMerchantTradeNo)
+		})
+	}
+}
+
+func TestRefundPaymentRejectsAmountsBelowOneCent(t *testing.T) {
+	for _, amount := range []float64{0.004, -1} {
+		server := newTestRefundServer()
+		payments := newTestPaymentClient(t, server)
+
+		_, history, err := payments.RefundPayment(context.Background(), &RefundPaymentRequest{PaymentOrderNo: "order-1", Amount: amount})
+		require.Error(t, err, "amount %v", amount)
+		assert.Nil(t, history, "no history is fetched for amount %v", amount)
+		assert.Empty(t, server.created)
+	}
+}
+
+func TestRefundPaymentFullyRefunded(t *testing.T) {
+	server := newTestRefundServer(testRefund(1, "100.00", "SUCCESS"))
+	payments := newTestPaymentClient(t, server)
+
+	_, history, err := payments.RefundPayment(context.Background(), &RefundPaymentRequest{PaymentOrderNo: "order-1"})
+	var limitErr *RefundLimitError
+	require.ErrorAs(t, err, &limitErr)
+	assert.Equal(t, 0.0, limitErr.Refundable)
+	assert.True(t, history.FullyRefunded())
+	assert.Empty(t, server.created)
+}
+
+func TestRefundPaymentSequencesRefunds(t *testing.T) {
+	server := newTestRefundServer()
+	server.status = "SUCCESS"
+	payments := newTestPaymentClient(t, server)
+
+	for i, want := range []string{"order-1-R1", "order-1-R2", "order-1-R3"} {
+		refund, history, err := payments.RefundPayment(context.Background(), &RefundPaymentRequest{PaymentOrderNo: "order-1", Amount: 10})
+		require.NoError(t, err)
+		assert.Equal(t, want, refund.MerchantTradeNo)
+		assert.Equal(t, float64(10*(i+1)), history.RefundedAmount)
+		assert.Equal(t, RefundMerchantTradeNo("order-1", i+2), history.NextMerchantTradeNo)
+	}
+}
+
+func TestRefundPaymentConcurrentAmountMismatch(t *testing.T) {
+	// A concurrent refund computed from the same history creates R1 first
+	payments := newTestPaymentClient(t, &racingRefundServer{refundServer: newTestRefundServer(), concurrent: Refund{
+		ID: "ref-other", PaymentID: "pay-1", MerchantTradeNo: "order-1-R1", Amount: "40.00", Status: "PENDING"}})
+
+	refund, history, err := payments.RefundPayment(context.Background(), &RefundPaymentRequest{PaymentOrderNo: "order-1", Amount: 25})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "refund order-1-R1 is for 40.00 USD instead of 25.00")
+	require.NotNil(t, refund)
+	assert.Equal(t, "ref-other", refund.ID)
+	assert.Equal(t, 100.0, history.RefundableAmount, "the mismatched refund is not counted as requested")
+}
+
+// racingRefundServer creates a concurrent refund right before the first refund request
+type racingRefundServer struct {
+	*refundServer
+	concurrent Refund
+}
+
+func (s *racingRefundServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/open-api/v3/acquiring/refunds" && r.Method == http.MethodPost {
+		s.mu.Lock()
+		if len(s.created) == 0 {
+			s.refunds = append(s.refunds, s.concurrent)
+		}
+		s.mu.Unlock()
+	}
+	s.refundServer.ServeHTTP(w, r)
+}
@@ -39,7 +39,9 @@ type ExchangeRateResponse struct {
 
 // GetExchangeRate retrieves the current exchange rate between two currencies
 // GET /open-api/v3/payment/rate
-func (c *PayoutClient) GetExchangeRate(ctx context.Context, sourceCurrency, targetCurrency string, amount float64) (*ExchangeRateResponse, error) {
+func (c *PayoutClient) GetExchangeRate(ctx context.Context, sourceCurrency, targetCurrency string, amount float64, callOpts ...CallOption) (*ExchangeRateResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if sourceCurrency == "" {
 		return nil, fmt.Errorf("sourceCurrency is required")
 	}
@@ -106,20 +108,18 @@ type CreatePayeeRequest struct {
 	BeneficiaryAddress string `json:"beneficiaryAddress,omitempty"`
 }
 
-// CreatePayee creates a new payee bank account
+// CreatePayee creates a new payee bank account. The request is checked with
+// CreatePayeeRequest.Validate first, so that malformed bank details fail with ValidationErrors
+// instead of being sent. Unusual currencies are not blocked; see CreatePayeeRequest.Warnings.
 // POST /open-api/v3/payee
-func (c *PayoutClient) CreatePayee(ctx context.Context, req *CreatePayeeRequest) (*Payee, error) {
-	if req.AccountID == "" {
-		return nil, fmt.Errorf("accountId is required")
-	}
-	if req.BeneficiaryName == "" {
-		return nil, fmt.Errorf("beneficiaryName is required")
-	}
-	if req.BankCountry == "" {
-		return nil, fmt.Errorf("bankCountry is required")
+func (c *PayoutClient) CreatePayee(ctx context.Context, req *CreatePayeeRequest, callOpts ...CallOption) (*Payee, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
 	}
-	if req.Currency == "" {
-		return nil, fmt.Errorf("currency is required")
+	if err := req.Validate(); err != nil {
+		return nil, err
 	}
 
 	opts := &RequestOptions{
@@ -140,7 +140,9 @@ func (c *PayoutClient) CreatePayee(ctx context.Context, req *CreatePayeeRequest)
 
 // GetPayee retrieves details of a specific payee
 // GET /open-api/v3/payee/{id}/detail
-func (c *PayoutClient) GetPayee(ctx context.Context, payeeID string) (*Payee, error) {
+func (c *PayoutClient) GetPayee(ctx context.Context, payeeID string, callOpts ...CallOption) (*Payee, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if payeeID == "" {
 		return nil, fmt.Errorf("payee ID cannot be empty")
 	}
@@ -179,7 +181,9 @@ type PayeeListResponse struct {
 
 // ListPayees retrieves a list of payees
 // GET /open-api/v3/payees
-func (c *PayoutClient) ListPayees(ctx context.Context, options *ListPayeesOptions) (*PayeeListResponse, error) {
+func (c *PayoutClient) ListPayees(ctx context.Context, options *ListPayeesOptions, callOpts ...CallOption) (*PayeeListResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	queryParams := url.Values{}
 	
 	if options != nil {
@@ -257,7 +261,9 @@ type CreatePayoutRequest struct {
 
 // CreatePayout creates a new payout transaction
 // POST /open-api/v3/payment
-func (c *PayoutClient) CreatePayout(ctx context.Context, req *CreatePayoutRequest) (*Payout, error) {
+func (c *PayoutClient) CreatePayout(ctx context.Context, req *CreatePayoutRequest, callOpts ...CallOption) (*Payout, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if req.AccountID == "" {
 		return nil, fmt.Errorf("accountId is required")
 	}
@@ -292,7 +298,9 @@ func (c *PayoutClient) CreatePayout(ctx context.Context, req *CreatePayoutReques
 
 // GetPayout retrieves details of a specific payout
 // GET /open-api/v3/payment/{id}/detail
-func (c *PayoutClient) GetPayout(ctx context.Context, payoutID string) (*Payout, error) {
+func (c *PayoutClient) GetPayout(ctx context.Context, payoutID string, callOpts ...CallOption) (*Payout, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if payoutID == "" {
 		return nil, fmt.Errorf("payout ID cannot be empty")
 	}
@@ -335,7 +343,9 @@ type PayoutListResponse struct {
 
 // ListPayouts retrieves a list of payouts
 // GET /open-api/v3/payments
-func (c *PayoutClient) ListPayouts(ctx context.Context, options *ListPayoutsOptions) (*PayoutListResponse, error) {
+func (c *PayoutClient) ListPayouts(ctx context.Context, options *ListPayoutsOptions, callOpts ...CallOption) (*PayoutListResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	queryParams := url.Values{}
 	
 	if options != nil {
@@ -413,7 +423,9 @@ type CreateQuotationRequest struct {
 
 // CreateQuotation creates a payout quotation
 // POST /open-api/v3/payment/quotation
-func (c *PayoutClient) CreateQuotation(ctx context.Context, req *CreateQuotationRequest) (*Quotation, error) {
+func (c *PayoutClient) CreateQuotation(ctx context.Context, req *CreateQuotationRequest, callOpts ...CallOption) (*Quotation, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if req.AccountID == "" {
 		return nil, fmt.Errorf("accountId is required")
 	}
@@ -445,7 +457,9 @@ func (c *PayoutClient) CreateQuotation(ctx context.Context, req *CreateQuotation
 
 // GetQuotation retrieves details of a specific quotation
 // GET /open-api/v3/payment/quotation/{id}
-func (c *PayoutClient) GetQuotation(ctx context.Context, quotationID string) (*Quotation, error) {
+func (c *PayoutClient) GetQuotation(ctx context.Context, quotationID string, callOpts ...CallOption) (*Quotation, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if quotationID == "" {
 		return nil, fmt.Errorf("quotation ID cannot be empty")
 	}
@@ -474,7 +488,9 @@ type AcceptQuotationRequest struct {
 
 // AcceptQuotation accepts a quotation and creates a payout
 // POST /open-api/v3/payment/quotation/{id}/accept
-func (c *PayoutClient) AcceptQuotation(ctx context.Context, quotationID string, req *AcceptQuotationRequest) (*Payout, error) {
+func (c *PayoutClient) AcceptQuotation(ctx context.Context, quotationID string, req *AcceptQuotationRequest, callOpts ...CallOption) (*Payout, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if quotationID == "" {
 		return nil, fmt.Errorf("quotation ID cannot be empty")
 	}
@@ -508,7 +524,9 @@ type CancelPayoutResponse struct {
 
 // CancelPayout cancels a pending payout
 // POST /open-api/v3/payment/{id}/cancel
-func (c *PayoutClient) CancelPayout(ctx context.Context, payoutID string) (*CancelPayoutResponse, error) {
+func (c *PayoutClient) CancelPayout(ctx context.Context, payoutID string, callOpts ...CallOption) (*CancelPayoutResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if payoutID == "" {
 		return nil, fmt.Errorf("payout ID cannot be empty")
 	}
@@ -527,3 +545,31 @@ func (c *PayoutClient) CancelPayout(ctx context.Context, payoutID string) (*Canc
 
 	return &response, nil
 }
+
+// CreatePayoutOperation creates a payout and returns an operation that tracks it until it
+// completes or fails
+func (c *PayoutClient) CreatePayoutOperation(ctx context.Context, req *CreatePayoutRequest, callOpts ...CallOption) (*Operation[Payout], error) {
+	payout, err := c.CreatePayout(ctx, req, callOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return newOperation(payout.ID, payout, c.payoutOperationSpec(payout.ID)), nil
+}
+
+// PayoutOperation returns an operation that tracks an existing payout
+func (c *PayoutClient) PayoutOperation(payoutID string) *Operation[Payout] {
+	return newOperation(payoutID, nil, c.payoutOperationSpec(payoutID))
+}
+
+func (c *PayoutClient) payoutOperationSpec(payoutID string) operationSpec[Payout] {
+	return operationSpec[Payout]{
+		poll: func(ctx context.Context) (*Payout, error) {
+			return c.GetPayout(ctx, payoutID)
+		},
+		state: func(p *Payout) (OperationState, string, string) {
+			return statusState(p.Status), p.Status, p.FailureReason
+		},
+		eventKeys: []string{"payoutId", "id"},
+	}
+}
@@ -0,0 +1,181 @@
+package interlace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrQuoteOutOfTolerance is returned, wrapped in a *QuoteToleranceError, when a quotation exceeds
+// the tolerances of a quoted payout
+var ErrQuoteOutOfTolerance = errors.New("interlace: quotation outside of tolerance")
+
+// ErrQuoteExpired is returned when every quotation of a quoted payout expired before it could be
+// accepted
+var ErrQuoteExpired = errors.New("interlace: quotation expired")
+
+// QuoteToleranceError reports the quotation that was rejected and why
+type QuoteToleranceError struct {
+	Quotation *Quotation
+	Reason    string
+}
+
+func (e *QuoteToleranceError) Error() string {
+	return fmt.Sprintf("%v: quotation %s %s", ErrQuoteOutOfTolerance, e.Quotation.ID, e.Reason)
+}
+
+// Is makes errors.Is(err, ErrQuoteOutOfTolerance) match
+func (e *QuoteToleranceError) Is(target error) bool {
+	return target == ErrQuoteOutOfTolerance
+}
+
+// QuotedPayoutRequest describes a payout at a quoted rate and the tolerances the quote must meet.
+// Zero tolerances are not checked.
+type QuotedPayoutRequest struct {
+	Quotation CreateQuotationRequest
+	// PayeeID defaults to Quotation.PayeeID
+	PayeeID   string
+	Reference string
+	// MerchantTradeNo identifies the payout and prefixes the trade numbers of the acceptances. A
+	// random value is used when empty.
+	MerchantTradeNo string
+
+	// MinRate and MaxRate bound the exchange rate, in target currency per source currency
+	MinRate float64
+	MaxRate float64
+	// MaxFee bounds the fee of the quotation
+	MaxFee float64
+	// MaxTotalAmount bounds the total amount debited, fee included
+	MaxTotalAmount float64
+
+	// MaxRequotes is how many times an expired quotation is replaced. Defaults to 3.
+	MaxRequotes int
+	// ExpiryMargin treats quotations expiring within it as expired, leaving time to accept them.
+	// Defaults to 2s.
+	ExpiryMargin time.Duration
+
+	// OnQuote is called with every quotation obtained, before it is checked
+	OnQuote func(quotation *Quotation)
+}
+
+// QuoteAndExecute obtains a quotation, checks it against the tolerances of the request and
+// accepts it before it expires. An expired quotation is replaced, up to MaxRequotes times, and
+// every replacement is checked again. The resulting payout is returned as an operation.
+//
+// No new quotation is requested when the outcome of an acceptance is unknown, e.g. after a
+// network failure, so a payout is never made twice; the *RequestError is returned instead.
+func (c *PayoutClient) QuoteAndExecute(ctx context.Context, req *QuotedPayoutRequest, callOpts ...CallOption) (*Operation[Payout], error) {
+	if req == nil {
+		return nil, errors.New("quoted payout request is required")
+	}
+	payeeID := req.PayeeID
+	if payeeID == "" {
+		payeeID = req.Quotation.PayeeID
+	}
+	if payeeID == "" {
+		return nil, fmt.Errorf("payeeId is required")
+	}
+
+	maxRequotes := req.MaxRequotes
+	if maxRequotes <= 0 {
+		maxRequotes = 3
+	}
+	margin := req.ExpiryMargin
+	if margin <= 0 {
+		margin = 2 * time.Second
+	}
+	tradeNo := req.MerchantTradeNo
+	if tradeNo == "" {
+		tradeNo = NewIdempotencyKey()
+	}
+
+	for attempt := 0; attempt <= maxRequotes; attempt++ {
+		// Every quotation gets its own idempotency key, or a key set by the caller would replay
+		// the first, expired, quotation
+		quotationReq := req.Quotation
+		quoteOpts := append(callOpts[:len(callOpts):len(callOpts)], WithIdempotencyKey(fmt.Sprintf("%s-quote-%d", tradeNo, attempt+1)))
+		quotation, err := c.CreateQuotation(ctx, &quotationReq, quoteOpts...)
+		if err != nil {
+			return nil, err
+		}
+		if req.OnQuote != nil {
+			req.OnQuote(quotation)
+		}
+		if err := req.checkTolerance(quotation); err != nil {
+			return nil, err
+		}
+		if quoteExpired(quotation, margin) {
+			continue
+		}
+
+		// Each quotation is accepted under its own trade number, since an acceptance rejected
+		// for expiry must not be replayed for the next quotation
+		accept := &AcceptQuotationRequest{
+			PayeeID:         payeeID,
+			MerchantTradeNo: fmt.Sprintf("%s-%d", tradeNo, attempt+1),
+			Reference:       req.Reference,
+		}
+		payout, err := c.AcceptQuotation(ctx, quotation.ID, accept, callOpts...)
+		if err != nil {
+			var apiErr *Error
+			if !errors.As(err, &apiErr) {
+				return nil, err
+			}
+			// The API rejected the acceptance; try again only when the quotation lapsed meanwhile
+			current, getErr := c.GetQuotation(ctx, quotation.ID, callOpts...)
+			if getErr != nil || !quoteExpired(current, 0) {
+				return nil, err
+			}
+			continue
+		}
+
+		return newOperation(payout.ID, payout, c.payoutOperationSpec(payout.ID)), nil
+	}
+
+	return nil, fmt.Errorf("%w after %d quotations", ErrQuoteExpired, maxRequotes+1)
+}
+
+// checkTolerance checks a quotation against the tolerances of the request
+func (r *QuotedPayoutRequest) checkTolerance(q *Quotation) error {
+	switch {
+	case r.MinRate > 0 && q.ExchangeRate < r.MinRate:
+		return &QuoteToleranceError{Quotation: q, Reason: fmt.Sprintf("rate %g is below the minimum %g", q.ExchangeRate, r.MinRate)}
+	case r.MaxRate > 0 && q.ExchangeRate > r.MaxRate:
+		return &QuoteToleranceError{Quotation: q, Reason: fmt.Sprintf("rate %g is above the maximum %g", q.ExchangeRate, r.MaxRate)}
+	case r.MaxFee > 0 && q.Fee > r.MaxFee:
+		return &QuoteToleranceError{Quotation: q, Reason: fmt.Sprintf("fee %s exceeds the maximum %s", formatAmount(q.Fee), formatAmount(r.MaxFee))}
+	case r.MaxTotalAmount > 0 && q.TotalAmount > r.MaxTotalAmount:
+		return &QuoteToleranceError{Quotation: q, Reason: fmt.Sprintf("total %s exceeds the maximum %s", formatAmount(q.TotalAmount), formatAmount(r.MaxTotalAmount))}
+	}
+	return nil
+}
+
+// quoteExpired reports whether a quotation has expired or expires within margin. Quotations
+// without a readable expiry are assumed to be valid.
+func quoteExpired(q *Quotation, margin time.Duration) bool {
+	if strings.EqualFold(q.Status, "EXPIRED") {
+		return true
+	}
+	validUntil, ok := parseAPITime(q.ValidUntil)
+	if !ok {
+		return false
+	}
+	return time.Until(validUntil) <= margin
+}
+
+// parseAPITime parses a timestamp of the API, either RFC 3339 or Unix milliseconds
+func parseAPITime(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, true
+	}
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.UnixMilli(ms), true
+	}
+	return time.Time{}, false
+}
@@ -99,11 +99,15 @@ type ActivatePhysicalCardResponse struct {
 }
 
 // ListPhysicalCardFees lists all physical card fees
-func (c *PhysicalCardClient) ListPhysicalCardFees(ctx context.Context) ([]PhysicalCardFee, error) {
+// The response is cached when Config.Cache is set
+func (c *PhysicalCardClient) ListPhysicalCardFees(ctx context.Context, callOpts ...CallOption) ([]PhysicalCardFee, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	opts := &RequestOptions{
 		Method:      "GET",
 		Endpoint:    "/open-api/v3/physical-card/fees",
 		RequireAuth: true,
+		Cacheable:   true,
 	}
 
 	var fees []PhysicalCardFee
@@ -115,7 +119,9 @@ func (c *PhysicalCardClient) ListPhysicalCardFees(ctx context.Context) ([]Physic
 }
 
 // BulkShipPhysicalCards ships multiple physical cards in bulk
-func (c *PhysicalCardClient) BulkShipPhysicalCards(ctx context.Context, req *BulkShipRequest) (*BulkShipResponse, error) {
+func (c *PhysicalCardClient) BulkShipPhysicalCards(ctx context.Context, req *BulkShipRequest, callOpts ...CallOption) (*BulkShipResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if req == nil {
 		return nil, fmt.Errorf("bulk ship request is required")
 	}
@@ -157,7 +163,9 @@ func (c *PhysicalCardClient) BulkShipPhysicalCards(ctx context.Context, req *Bul
 }
 
 // ConfirmCardholderIdentity confirms cardholder identity for physical card
-func (c *PhysicalCardClient) ConfirmCardholderIdentity(ctx context.Context, req *ConfirmCardholderIdentityRequest) (*ConfirmCardholderIdentityResponse, error) {
+func (c *PhysicalCardClient) ConfirmCardholderIdentity(ctx context.Context, req *ConfirmCardholderIdentityRequest, callOpts ...CallOption) (*ConfirmCardholderIdentityResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if req == nil {
 		return nil, fmt.Errorf("confirm identity request is required")
 	}
@@ -184,7 +192,9 @@ func (c *PhysicalCardClient) ConfirmCardholderIdentity(ctx context.Context, req
 }
 
 // GenerateCardholderIdentityURL generates a URL for cardholder identity verification
-func (c *PhysicalCardClient) GenerateCardholderIdentityURL(ctx context.Context, cardholderID string) (*CardholderIdentityURLResponse, error) {
+func (c *PhysicalCardClient) GenerateCardholderIdentityURL(ctx context.Context, cardholderID string, callOpts ...CallOption) (*CardholderIdentityURLResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if cardholderID == "" {
 		return nil, fmt.Errorf("cardholder ID is required")
 	}
@@ -204,7 +214,9 @@ func (c *PhysicalCardClient) GenerateCardholderIdentityURL(ctx context.Context,
 }
 
 // ActivatePhysicalCard activates a physical card
-func (c *PhysicalCardClient) ActivatePhysicalCard(ctx context.Context, req *ActivatePhysicalCardRequest) (*ActivatePhysicalCardResponse, error) {
+func (c *PhysicalCardClient) ActivatePhysicalCard(ctx context.Context, req *ActivatePhysicalCardRequest, callOpts ...CallOption) (*ActivatePhysicalCardResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if req == nil {
 		return nil, fmt.Errorf("activate card request is required")
 	}
@@ -0,0 +1,84 @@
+package interlace
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ResponseMeta captures transport-level details of an API call, such as the server request ID
+// and the raw response body, for support tickets and audits. It is written by a single call and
+// must not be shared by calls running concurrently; helpers fanning out many requests do not
+// record into it.
+type ResponseMeta struct {
+	StatusCode     int
+	RequestID      string
+	TraceID        string
+	IdempotencyKey string
+	RateLimit      RateLimit
+	Header         http.Header
+	RawBody        []byte
+	Latency        time.Duration // Total time spent, including retries
+	Attempts       int
+	Cached         bool // Set when the response was served from the cache, see CacheConfig
+}
+
+// RateLimit holds the rate-limit headers returned by the API
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// ContextWithResponseMeta returns a context that makes requests record their response metadata
+// into meta. When several requests share the context (for example a paginating helper), meta
+// describes the last one. The WithResponseMeta call option does the same for a single call.
+//
+//	var meta interlace.ResponseMeta
+//	card, err := client.Card.FreezeCard(ctx, cardID, interlace.WithResponseMeta(&meta))
+//	log.Printf("request %s took %s", meta.RequestID, meta.Latency)
+func ContextWithResponseMeta(ctx context.Context, meta *ResponseMeta) context.Context {
+	return ContextWithCallOptions(ctx, WithResponseMeta(meta))
+}
+
+// Request and trace ID headers in order of preference
+var (
+	requestIDHeaders = []string{"X-Request-Id", "X-Amzn-Requestid", "Request-Id"}
+	traceIDHeaders   = []string{"X-Trace-Id", "X-B3-Traceid", "Traceparent"}
+)
+
+// record fills the metadata from an HTTP response
+func (m *ResponseMeta) record(resp *http.Response, body []byte) {
+	m.StatusCode = resp.StatusCode
+	m.Header = resp.Header.Clone()
+	m.RawBody = body
+	m.RequestID = firstHeader(resp.Header, requestIDHeaders)
+	m.TraceID = firstHeader(resp.Header, traceIDHeaders)
+	m.RateLimit = parseRateLimit(resp.Header)
+}
+
+func firstHeader(header http.Header, names []string) string {
+	for _, name := range names {
+		if value := header.Get(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// parseRateLimit reads the X-RateLimit-* headers. The reset header may hold either a Unix
+// timestamp or the number of seconds until the window resets.
+func parseRateLimit(header http.Header) RateLimit {
+	var rl RateLimit
+	rl.Limit, _ = strconv.Atoi(header.Get("X-RateLimit-Limit"))
+	rl.Remaining, _ = strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+	if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		if reset > 1e9 {
+			rl.Reset = time.Unix(reset, 0)
+		} else {
+			rl.Reset = time.Now().Add(time.Duration(reset) * time.Second)
+		}
+	}
+	return rl
+}
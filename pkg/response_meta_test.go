@@ -0,0 +1,36 @@
+package interlace
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseMeta(t *testing.T) {
+	client := newTestHTTPClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-1")
+		w.Header().Set("Traceparent", "00-trace-01")
+		w.Header().Set("X-RateLimit-Limit", "100")
+		w.Header().Set("X-RateLimit-Remaining", "99")
+		w.Header().Set("X-RateLimit-Reset", "1700000000")
+		w.Write([]byte(`{"id":"card-1"}`))
+	}), nil)
+
+	var meta ResponseMeta
+	ctx := ContextWithResponseMeta(context.Background(), &meta)
+	require.NoError(t, client.DoRequest(ctx, &RequestOptions{Method: http.MethodPost, Endpoint: "/cards",
+		IdempotencyKey: "key-1"}, nil))
+
+	assert.Equal(t, http.StatusOK, meta.StatusCode)
+	assert.Equal(t, "req-1", meta.RequestID)
+	assert.Equal(t, "00-trace-01", meta.TraceID)
+	assert.Equal(t, "key-1", meta.IdempotencyKey)
+	assert.Equal(t, RateLimit{Limit: 100, Remaining: 99, Reset: time.Unix(1700000000, 0)}, meta.RateLimit)
+	assert.Equal(t, `{"id":"card-1"}`, string(meta.RawBody))
+	assert.Equal(t, 1, meta.Attempts)
+	assert.False(t, meta.Cached)
+}
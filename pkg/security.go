@@ -31,7 +31,9 @@ type UpdatePINResponse struct {
 }
 
 // UpdateCardPIN updates the PIN for a card
-func (c *SecurityClient) UpdateCardPIN(ctx context.Context, req *UpdatePINRequest) (*UpdatePINResponse, error) {
+func (c *SecurityClient) UpdateCardPIN(ctx context.Context, req *UpdatePINRequest, callOpts ...CallOption) (*UpdatePINResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if req == nil {
 		return nil, fmt.Errorf("update PIN request is required")
 	}
@@ -0,0 +1,610 @@
+package interlace
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec declares the budgets and budget cards of an account. Budgets are identified by Name and
+// cards by their ID. Cards without an ID are created, and Apply records the ID of the created
+// cards in the spec: save the spec back so that they are not created again. Resources that exist
+// but are not in the spec are left untouched.
+//
+//	accountId: acc_123
+//	budgets:
+//	  - name: Marketing
+//	    currency: USD
+//	    initBalance: 5000
+//	    cards:
+//	      - label: ads-google
+//	        id: card_123
+//	        binId: bin_1
+//	        cardholderId: ch_1
+//	        velocityControl:
+//	          dailySpendingLimit: 500
+//	        scenarios: [ADVERTISING]
+type Spec struct {
+	AccountID string       `json:"accountId" yaml:"accountId"`
+	Budgets   []BudgetSpec `json:"budgets" yaml:"budgets"`
+}
+
+// BudgetSpec declares a budget and its cards
+type BudgetSpec struct {
+	Name     string `json:"name" yaml:"name"`
+	Currency string `json:"currency" yaml:"currency"`
+	// Description and Status are only enforced when set
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Status      string `json:"status,omitempty" yaml:"status,omitempty"`
+	// InitBalance is only used when the budget is created
+	InitBalance float64    `json:"initBalance,omitempty" yaml:"initBalance,omitempty"`
+	Cards       []CardSpec `json:"cards,omitempty" yaml:"cards,omitempty"`
+}
+
+// CardSpec declares a budget card. Unset limits and scenarios are not enforced.
+type CardSpec struct {
+	// Label names the card in the spec and plans, and is set on created cards
+	Label string `json:"label" yaml:"label"`
+	// ID is the ID of the live card, empty for a card to create
+	ID                       string               `json:"id,omitempty" yaml:"id,omitempty"`
+	BinID                    string               `json:"binId" yaml:"binId"`
+	CardholderID             string               `json:"cardholderId" yaml:"cardholderId"`
+	ShippingAddressID        string               `json:"shippingAddressId,omitempty" yaml:"shippingAddressId,omitempty"`
+	VelocityControl          *VelocityControlSpec `json:"velocityControl,omitempty" yaml:"velocityControl,omitempty"`
+	MonthlySpendingLimit     *float64             `json:"monthlySpendingLimit,omitempty" yaml:"monthlySpendingLimit,omitempty"`
+	ThreeDSecureAuthRequired *bool                `json:"threeDSecureAuthRequired,omitempty" yaml:"threeDSecureAuthRequired,omitempty"`
+	// Scenarios are consumption scenario codes, see CommonClient.ListConsumptionScenarios. The card
+	// API does not report the scenarios of a card, so they are set again on every apply.
+	Scenarios []string `json:"scenarios,omitempty" yaml:"scenarios,omitempty"`
+}
+
+// VelocityControlSpec declares the velocity control of a card
+type VelocityControlSpec struct {
+	DailySpendingLimit *float64 `json:"dailySpendingLimit,omitempty" yaml:"dailySpendingLimit,omitempty"`
+	SingleTransLimit   *float64 `json:"singleTransLimit,omitempty" yaml:"singleTransLimit,omitempty"`
+}
+
+// ParseSpec parses a YAML or JSON spec and validates it
+func ParseSpec(data []byte) (*Spec, error) {
+	var spec Spec
+	if err := yaml.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse spec: %w", err)
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return &spec, nil
+}
+
+// LoadSpec reads and parses a YAML or JSON spec file
+func LoadSpec(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spec: %w", err)
+	}
+	return ParseSpec(data)
+}
+
+// Validate checks that the spec is complete and that names and card IDs are unique
+func (s *Spec) Validate() error {
+	if s.AccountID == "" {
+		return fmt.Errorf("spec: accountId is required")
+	}
+
+	budgets := make(map[string]bool)
+	cardIDs := make(map[string]bool)
+	for i, budget := range s.Budgets {
+		if budget.Name == "" {
+			return fmt.Errorf("spec: budgets[%d]: name is required", i)
+		}
+		if budget.Currency == "" {
+			return fmt.Errorf("spec: budget %q: currency is required", budget.Name)
+		}
+		if budgets[budget.Name] {
+			return fmt.Errorf("spec: budget %q is declared twice", budget.Name)
+		}
+		budgets[budget.Name] = true
+
+		cards := make(map[string]bool)
+		for j, card := range budget.Cards {
+			if card.Label == "" {
+				return fmt.Errorf("spec: budget %q: cards[%d]: label is required", budget.Name, j)
+			}
+			if card.BinID == "" || card.CardholderID == "" {
+				return fmt.Errorf("spec: card %q: binId and cardholderId are required", budget.Name+"/"+card.Label)
+			}
+			if cards[card.Label] {
+				return fmt.Errorf("spec: card %q is declared twice", budget.Name+"/"+card.Label)
+			}
+			cards[card.Label] = true
+			if card.ID != "" {
+				if cardIDs[card.ID] {
+					return fmt.Errorf("spec: card %s is declared twice", card.ID)
+				}
+				cardIDs[card.ID] = true
+			}
+		}
+	}
+	return nil
+}
+
+// ChangeAction is the kind of a planned change
+type ChangeAction string
+
+// Change actions
+const (
+	ChangeCreate ChangeAction = "create"
+	ChangeUpdate ChangeAction = "update"
+)
+
+// FieldDiff is a field whose live value differs from the spec
+type FieldDiff struct {
+	Field string
+	From  string // Empty when the live value is unknown
+	To    string
+}
+
+// Change is one planned change
+type Change struct {
+	Action   ChangeAction
+	Resource string // "budget" or "card"
+	// Name is the budget name, or "budget/label" for cards
+	Name string
+	// ID is the ID of the live resource. It is empty for resources to create.
+	ID    string
+	Diffs []FieldDiff
+	// Unreported lists the fields the API does not report, such as the scenarios of a card. They
+	// cannot be compared, so they are set again whenever the change is applied, but a change
+	// with only unreported fields does not make the plan non-empty.
+	Unreported []FieldDiff
+
+	// Applied reports whether Apply carried out the change
+	Applied bool
+
+	apply func(ctx context.Context) error
+}
+
+// Plan is the list of changes that bring the live state in line with a spec
+type Plan struct {
+	Changes []*Change
+}
+
+// Empty reports whether the live state already matches the spec, as far as it is reported
+func (p *Plan) Empty() bool {
+	for _, change := range p.Changes {
+		if change.Action == ChangeCreate || len(change.Diffs) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteTo prints the plan in a human readable form
+func (p *Plan) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, p.String())
+	return int64(n), err
+}
+
+func (p *Plan) String() string {
+	var b strings.Builder
+	creates, updates, unreported := 0, 0, 0
+	for _, change := range p.Changes {
+		switch {
+		case change.Action == ChangeCreate:
+			creates++
+			fmt.Fprintf(&b, "+ %s %q\n", change.Resource, change.Name)
+		case len(change.Diffs) > 0:
+			updates++
+			fmt.Fprintf(&b, "~ %s %q [%s]\n", change.Resource, change.Name, change.ID)
+		default:
+			unreported++
+			fmt.Fprintf(&b, "  %s %q [%s]\n", change.Resource, change.Name, change.ID)
+		}
+		for _, diff := range change.Diffs {
+			if change.Action == ChangeCreate {
+				fmt.Fprintf(&b, "    %s: %s\n", diff.Field, diff.To)
+				continue
+			}
+			from := diff.From
+			if from == "" {
+				from = "(unknown)"
+			}
+			fmt.Fprintf(&b, "    %s: %s -> %s\n", diff.Field, from, diff.To)
+		}
+		for _, diff := range change.Unreported {
+			fmt.Fprintf(&b, "    %s: %s (not reported, set again)\n", diff.Field, diff.To)
+		}
+	}
+	if p.Empty() {
+		b.WriteString("No changes. The live state matches the spec.\n")
+	} else {
+		fmt.Fprintf(&b, "Plan: %d to create, %d to update.\n", creates, updates)
+	}
+	if unreported > 0 {
+		fmt.Fprintf(&b, "Unreported fields of %d unchanged resources are set again on apply.\n", unreported)
+	}
+	return b.String()
+}
+
+// SpecManager plans and applies specs against the live state of an account
+//
+//	spec, err := interlace.LoadSpec("budgets.yaml")
+//	manager := interlace.NewSpecManager(client)
+//	plan, err := manager.Plan(ctx, spec)
+//	fmt.Print(plan)
+//	err = manager.Apply(ctx, plan)
+type SpecManager struct {
+	client *Client
+}
+
+// NewSpecManager creates a spec manager
+func NewSpecManager(client *Client) *SpecManager {
+	return &SpecManager{client: client}
+}
+
+// Plan diffs the spec against the live budgets, cards and consumption scenarios of its account
+func (m *SpecManager) Plan(ctx context.Context, spec *Spec) (*Plan, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+
+	budgets, err := m.listBudgets(ctx, spec.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	scenarios, err := m.scenarioIDs(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{}
+	for i := range spec.Budgets {
+		budgetSpec := &spec.Budgets[i]
+		cardScenarios, err := resolveScenarios(budgetSpec, scenarios)
+		if err != nil {
+			return nil, err
+		}
+
+		live, ok := budgets[budgetSpec.Name]
+		if !ok {
+			// The cards of a new budget are created once the budget exists
+			budgetID := new(string)
+			plan.Changes = append(plan.Changes, m.createBudget(spec.AccountID, budgetSpec, budgetID))
+			for j := range budgetSpec.Cards {
+				cardSpec := &budgetSpec.Cards[j]
+				if cardSpec.ID != "" {
+					return nil, fmt.Errorf("spec: card %q: card %s cannot be in budget %q, which does not exist", budgetSpec.Name+"/"+cardSpec.Label, cardSpec.ID, budgetSpec.Name)
+				}
+				plan.Changes = append(plan.Changes, m.createCard(budgetSpec, cardSpec, budgetID, cardScenarios[j]))
+			}
+			continue
+		}
+
+		if !strings.EqualFold(live.Currency, budgetSpec.Currency) {
+			return nil, fmt.Errorf("spec: budget %q: currency is %s and cannot be changed to %s", budgetSpec.Name, live.Currency, budgetSpec.Currency)
+		}
+		if change := m.updateBudget(budgetSpec, live); change != nil {
+			plan.Changes = append(plan.Changes, change)
+		}
+
+		cards, err := m.listCards(ctx, spec.AccountID, live.ID)
+		if err != nil {
+			return nil, err
+		}
+		for j := range budgetSpec.Cards {
+			cardSpec := &budgetSpec.Cards[j]
+			name := budgetSpec.Name + "/" + cardSpec.Label
+			if cardSpec.ID == "" {
+				// A card created by an earlier apply whose spec was not saved must not be
+				// created again
+				for k := range cards {
+					if cardSpec.Label != "" && cards[k].Label == cardSpec.Label {
+						return nil, fmt.Errorf("spec: card %q: budget already has card %s labelled %q, set its id in the spec", name, cards[k].ID, cardSpec.Label)
+					}
+				}
+				plan.Changes = append(plan.Changes, m.createCard(budgetSpec, cardSpec, &live.ID, cardScenarios[j]))
+				continue
+			}
+
+			var liveCard *Card
+			for k := range cards {
+				if cards[k].ID == cardSpec.ID {
+					liveCard = &cards[k]
+				}
+			}
+			if liveCard == nil {
+				return nil, fmt.Errorf("spec: card %q: card %s is not in budget %q", name, cardSpec.ID, budgetSpec.Name)
+			}
+			if change := m.updateCard(budgetSpec, cardSpec, liveCard, cardScenarios[j]); change != nil {
+				plan.Changes = append(plan.Changes, change)
+			}
+		}
+	}
+
+	return plan, nil
+}
+
+// Apply carries out the changes of a plan in order. It stops at the first failure; the changes
+// applied so far are marked as Applied and are not repeated when the plan is applied again.
+func (m *SpecManager) Apply(ctx context.Context, plan *Plan) error {
+	for _, change := range plan.Changes {
+		if change.Applied {
+			continue
+		}
+		if err := change.apply(ctx); err != nil {
+			return fmt.Errorf("failed to %s %s %q: %w", change.Action, change.Resource, change.Name, err)
+		}
+		change.Applied = true
+	}
+	return nil
+}
+
+// listBudgets returns the live budgets of the account by name
+func (m *SpecManager) listBudgets(ctx context.Context, accountID string) (map[string]*Budget, error) {
+	budgets := make(map[string]*Budget)
+	err := walkPages(func(page int) (int, int, error) {
+		list, err := m.client.Budget.ListBudgets(ctx, &ListBudgetsOptions{AccountID: accountID, Limit: selectPageSize, Page: page})
+		if err != nil {
+			return 0, 0, err
+		}
+		for i := range list.List {
+			budgets[list.List[i].Name] = &list.List[i]
+		}
+		return len(list.List), list.Total, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return budgets, nil
+}
+
+// listCards returns the live cards of a budget
+func (m *SpecManager) listCards(ctx context.Context, accountID, budgetID string) ([]Card, error) {
+	return m.client.Card.SelectCards(ctx, CardListOptions{AccountID: accountID, BudgetID: budgetID}, nil)
+}
+
+// scenarioIDs returns the IDs of the known consumption scenarios by code, or nil when the spec
+// uses none
+func (m *SpecManager) scenarioIDs(ctx context.Context, spec *Spec) (map[string]string, error) {
+	used := false
+	for _, budget := range spec.Budgets {
+		for _, card := range budget.Cards {
+			used = used || len(card.Scenarios) > 0
+		}
+	}
+	if !used {
+		return nil, nil
+	}
+
+	list, err := m.client.Common.ListConsumptionScenarios(ctx, spec.AccountID)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]string)
+	for _, scenario := range list.List {
+		ids[scenario.Code] = scenario.ID
+	}
+	return ids, nil
+}
+
+// resolveScenarios returns the scenario IDs of each card of a budget, in spec order
+func resolveScenarios(budget *BudgetSpec, known map[string]string) ([][]string, error) {
+	resolved := make([][]string, len(budget.Cards))
+	for i, card := range budget.Cards {
+		for _, code := range card.Scenarios {
+			id, ok := known[code]
+			if !ok {
+				return nil, fmt.Errorf("spec: card %q: unknown consumption scenario %q", budget.Name+"/"+card.Label, code)
+			}
+			if id == "" {
+				return nil, fmt.Errorf("spec: card %q: consumption scenario %q has no ID", budget.Name+"/"+card.Label, code)
+			}
+			resolved[i] = append(resolved[i], id)
+		}
+	}
+	return resolved, nil
+}
+
+func (m *SpecManager) createBudget(accountID string, spec *BudgetSpec, budgetID *string) *Change {
+	req := &CreateBudgetRequest{
+		AccountID:   accountID,
+		Name:        spec.Name,
+		Currency:    spec.Currency,
+		Description: spec.Description,
+		InitBalance: spec.InitBalance,
+	}
+
+	change := &Change{Action: ChangeCreate, Resource: "budget", Name: spec.Name}
+	change.Diffs = append(change.Diffs, FieldDiff{Field: "currency", To: spec.Currency})
+	if spec.Description != "" {
+		change.Diffs = append(change.Diffs, FieldDiff{Field: "description", To: strconv.Quote(spec.Description)})
+	}
+	if spec.InitBalance != 0 {
+		change.Diffs = append(change.Diffs, FieldDiff{Field: "initBalance", To: formatAmount(spec.InitBalance)})
+	}
+	change.apply = func(ctx context.Context) error {
+		budget, err := m.client.Budget.CreateBudget(ctx, req)
+		if err != nil {
+			return err
+		}
+		*budgetID = budget.ID
+		change.ID = budget.ID
+
+		if spec.Status != "" && !strings.EqualFold(budget.Status, spec.Status) {
+			_, err = m.client.Budget.UpdateBudget(ctx, budget.ID, &UpdateBudgetRequest{Status: spec.Status})
+		}
+		return err
+	}
+	return change
+}
+
+func (m *SpecManager) updateBudget(spec *BudgetSpec, live *Budget) *Change {
+	var diffs []FieldDiff
+	req := &UpdateBudgetRequest{}
+	if spec.Description != "" && spec.Description != live.Description {
+		diffs = append(diffs, FieldDiff{Field: "description", From: strconv.Quote(live.Description), To: strconv.Quote(spec.Description)})
+		req.Description = spec.Description
+	}
+	if spec.Status != "" && !strings.EqualFold(spec.Status, live.Status) {
+		diffs = append(diffs, FieldDiff{Field: "status", From: live.Status, To: spec.Status})
+		req.Status = spec.Status
+	}
+	if len(diffs) == 0 {
+		return nil
+	}
+
+	return &Change{
+		Action:   ChangeUpdate,
+		Resource: "budget",
+		Name:     spec.Name,
+		ID:       live.ID,
+		Diffs:    diffs,
+		apply: func(ctx context.Context) error {
+			_, err := m.client.Budget.UpdateBudget(ctx, live.ID, req)
+			return err
+		},
+	}
+}
+
+func (m *SpecManager) createCard(budget *BudgetSpec, spec *CardSpec, budgetID *string, scenarios []string) *Change {
+	change := &Change{Action: ChangeCreate, Resource: "card", Name: budget.Name + "/" + spec.Label}
+	change.Diffs = append(change.Diffs,
+		FieldDiff{Field: "binId", To: spec.BinID},
+		FieldDiff{Field: "cardholderId", To: spec.CardholderID},
+	)
+	change.Diffs = append(change.Diffs, cardDiffs(spec, &Card{})...)
+	change.Diffs = append(change.Diffs, cardUnreported(spec)...)
+
+	change.apply = func(ctx context.Context) error {
+		req := &CreateBudgetCardRequest{
+			BinID:             spec.BinID,
+			CardholderID:      spec.CardholderID,
+			BudgetID:          *budgetID,
+			ShippingAddressID: spec.ShippingAddressID,
+			Label:             spec.Label,
+		}
+		if spec.VelocityControl != nil {
+			req.DailySpendingLimit = derefFloat(spec.VelocityControl.DailySpendingLimit)
+			req.SingleTransLimit = derefFloat(spec.VelocityControl.SingleTransLimit)
+		}
+		req.MonthlySpendingLimit = derefFloat(spec.MonthlySpendingLimit)
+		if spec.ThreeDSecureAuthRequired != nil {
+			req.ThreeDSecureAuthRequired = *spec.ThreeDSecureAuthRequired
+		}
+
+		card, err := m.client.Card.CreateBudgetCard(ctx, req)
+		if err != nil {
+			return err
+		}
+		change.ID = card.ID
+		spec.ID = card.ID
+
+		if len(scenarios) > 0 {
+			_, err = m.client.Common.SetConsumptionScenario(ctx, &SetConsumptionScenarioRequest{CardID: card.ID, ScenarioIDs: scenarios})
+		}
+		return err
+	}
+	return change
+}
+
+func (m *SpecManager) updateCard(budget *BudgetSpec, spec *CardSpec, live *Card, scenarios []string) *Change {
+	diffs, unreported := cardDiffs(spec, live), cardUnreported(spec)
+	if len(diffs) == 0 && len(unreported) == 0 {
+		return nil
+	}
+
+	changed := make(map[string]bool)
+	for _, diff := range diffs {
+		changed[diff.Field] = true
+	}
+
+	return &Change{
+		Action:     ChangeUpdate,
+		Resource:   "card",
+		Name:       budget.Name + "/" + spec.Label,
+		ID:         live.ID,
+		Diffs:      diffs,
+		Unreported: unreported,
+		apply: func(ctx context.Context) error {
+			if changed["dailySpendingLimit"] || changed["singleTransLimit"] {
+				req := &VelocityControlRequest{
+					DailySpendingLimit: spec.VelocityControl.DailySpendingLimit,
+					SingleTransLimit:   spec.VelocityControl.SingleTransLimit,
+				}
+				if _, err := m.client.Card.SetCardVelocityControl(ctx, live.ID, req); err != nil {
+					return err
+				}
+			}
+			if changed["monthlySpendingLimit"] || changed["threeDSecureAuthRequired"] {
+				req := &UpdateCardRequest{
+					CardID:                   live.ID,
+					MonthlySpendingLimit:     spec.MonthlySpendingLimit,
+					ThreeDSecureAuthRequired: spec.ThreeDSecureAuthRequired,
+				}
+				if _, err := m.client.Card.UpdateCard(ctx, req); err != nil {
+					return err
+				}
+			}
+			if len(scenarios) > 0 {
+				req := &SetConsumptionScenarioRequest{CardID: live.ID, ScenarioIDs: scenarios}
+				if _, err := m.client.Common.SetConsumptionScenario(ctx, req); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// cardDiffs lists the fields of a card that differ from its spec
+func cardDiffs(spec *CardSpec, live *Card) []FieldDiff {
+	var diffs []FieldDiff
+	diffFloat := func(field string, want, have *float64) {
+		if want != nil && (have == nil || *have != *want) {
+			diff := FieldDiff{Field: field, To: formatAmount(*want)}
+			if have != nil {
+				diff.From = formatAmount(*have)
+			}
+			diffs = append(diffs, diff)
+		}
+	}
+
+	if spec.VelocityControl != nil {
+		diffFloat("dailySpendingLimit", spec.VelocityControl.DailySpendingLimit, live.DailySpendingLimit)
+		diffFloat("singleTransLimit", spec.VelocityControl.SingleTransLimit, live.SingleTransLimit)
+	}
+	diffFloat("monthlySpendingLimit", spec.MonthlySpendingLimit, live.MonthlySpendingLimit)
+
+	if want := spec.ThreeDSecureAuthRequired; want != nil {
+		if have := live.ThreeDSecureAuthRequired; have == nil || *have != *want {
+			diff := FieldDiff{Field: "threeDSecureAuthRequired", To: strconv.FormatBool(*want)}
+			if have != nil {
+				diff.From = strconv.FormatBool(*have)
+			}
+			diffs = append(diffs, diff)
+		}
+	}
+
+	return diffs
+}
+
+// cardUnreported lists the fields of a card spec the API does not report, which cardDiffs cannot
+// compare
+func cardUnreported(spec *CardSpec) []FieldDiff {
+	if len(spec.Scenarios) == 0 {
+		return nil
+	}
+	return []FieldDiff{{Field: "scenarios", To: "[" + strings.Join(spec.Scenarios, ", ") + "]"}}
+}
+
+func derefFloat(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
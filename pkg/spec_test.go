@@ -0,0 +1,73 @@
+package interlace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCardDiffs(t *testing.T) {
+	limit := func(v float64) *float64 { return &v }
+	yes := true
+	spec := &CardSpec{
+		Label:                    "ads",
+		VelocityControl:          &VelocityControlSpec{DailySpendingLimit: limit(500)},
+		MonthlySpendingLimit:     limit(3000),
+		ThreeDSecureAuthRequired: &yes,
+		Scenarios:                []string{"ADVERTISING", "SAAS"},
+	}
+
+	live := &Card{DailySpendingLimit: limit(500), MonthlySpendingLimit: limit(3000), ThreeDSecureAuthRequired: &yes}
+	assert.Empty(t, cardDiffs(spec, live), "scenarios are not compared")
+	assert.Equal(t, []FieldDiff{{Field: "scenarios", To: "[ADVERTISING, SAAS]"}}, cardUnreported(spec))
+
+	live = &Card{DailySpendingLimit: limit(200)}
+	assert.Equal(t, []FieldDiff{
+		{Field: "dailySpendingLimit", From: "200", To: "500"},
+		{Field: "monthlySpendingLimit", To: "3000"},
+		{Field: "threeDSecureAuthRequired", To: "true"},
+	}, cardDiffs(spec, live))
+}
+
+func TestPlanEmpty(t *testing.T) {
+	scenarios := []FieldDiff{{Field: "scenarios", To: "[ADVERTISING]"}}
+	tests := []struct {
+		name    string
+		changes []*Change
+		empty   bool
+		output  string
+	}{
+		{name: "no changes", empty: true, output: "No changes. The live state matches the spec.\n"},
+		{
+			name:    "unreported fields only",
+			changes: []*Change{{Action: ChangeUpdate, Resource: "card", Name: "Marketing/ads", ID: "card_1", Unreported: scenarios}},
+			empty:   true,
+			output: "  card \"Marketing/ads\" [card_1]\n" +
+				"    scenarios: [ADVERTISING] (not reported, set again)\n" +
+				"No changes. The live state matches the spec.\n" +
+				"Unreported fields of 1 unchanged resources are set again on apply.\n",
+		},
+		{
+			name: "update",
+			changes: []*Change{{Action: ChangeUpdate, Resource: "card", Name: "Marketing/ads", ID: "card_1",
+				Diffs: []FieldDiff{{Field: "monthlySpendingLimit", To: "3000.00"}}, Unreported: scenarios}},
+			output: "~ card \"Marketing/ads\" [card_1]\n" +
+				"    monthlySpendingLimit: (unknown) -> 3000.00\n" +
+				"    scenarios: [ADVERTISING] (not reported, set again)\n" +
+				"Plan: 0 to create, 1 to update.\n",
+		},
+		{
+			name:    "create",
+			changes: []*Change{{Action: ChangeCreate, Resource: "budget", Name: "Marketing"}},
+			output:  "+ budget \"Marketing\"\nPlan: 1 to create, 0 to update.\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := &Plan{Changes: tt.changes}
+			assert.Equal(t, tt.empty, plan.Empty())
+			assert.Equal(t, tt.output, plan.String())
+		})
+	}
+}
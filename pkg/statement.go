@@ -0,0 +1,558 @@
+package interlace
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StatementRequest selects the transactions of a bank statement
+type StatementRequest struct {
+	AccountID string
+	// From and To bound the period, From included and To excluded
+	From time.Time
+	To   time.Time
+	// Currency restricts the statements to one currency. By default one statement is generated per
+	// currency with transactions in the period.
+	Currency string
+
+	// AccountIdentifier identifies the account in the documents, e.g. its IBAN. Defaults to
+	// AccountID.
+	AccountIdentifier string
+	// ServicerBIC is the BIC of the account servicer written into CAMT.053 documents, if known
+	ServicerBIC string
+	// SequenceNumber numbers the statement (MT940 :28C:, CAMT.053 ElctrncSeqNb). Defaults to 1.
+	SequenceNumber int
+}
+
+// Statement is the booked transactions of an account in one currency over a period, with the
+// opening and closing balances
+type Statement struct {
+	AccountID         string
+	AccountIdentifier string
+	ServicerBIC       string
+	Currency          string
+	From              time.Time
+	To                time.Time
+	SequenceNumber    int
+	CreatedAt         time.Time
+
+	// OpeningBalance is the balance before the first entry. ClosingBalance is the opening balance
+	// plus the entries, so the statement always balances.
+	OpeningBalance float64
+	ClosingBalance float64
+	// Entries are the completed transactions of the period, oldest first
+	Entries      []BusinessAccountTransaction
+	TotalCredits float64
+	TotalDebits  float64
+	CreditCount  int
+	DebitCount   int
+}
+
+// ID identifies the statement, e.g. 20240101USD
+func (s *Statement) ID() string {
+	return s.From.Format("20060102") + s.Currency
+}
+
+// Statements pages through the transactions of the period and returns one statement per
+// currency, or for req.Currency only. Only completed transactions are booked. A currency without
+// transactions in the period gets a statement only when req.Currency is set; its balances are then
+// derived from the current balance and the later transactions.
+func (c *BusinessAccountClient) Statements(ctx context.Context, req *StatementRequest, callOpts ...CallOption) ([]*Statement, error) {
+	if req == nil || req.AccountID == "" {
+		return nil, fmt.Errorf("account ID is required")
+	}
+	if req.From.IsZero() || !req.To.After(req.From) {
+		return nil, errors.New("statement period is invalid: To must be after From")
+	}
+
+	transactions, err := c.listTransactions(ctx, req.AccountID, req.From, req.To, callOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	byCurrency := make(map[string][]BusinessAccountTransaction)
+	for _, tx := range transactions {
+		currency := strings.ToUpper(tx.Currency)
+		if req.Currency != "" && currency != strings.ToUpper(req.Currency) {
+			continue
+		}
+		if tx.Status != "" && statusState(tx.Status) != OperationSucceeded {
+			continue
+		}
+		byCurrency[currency] = append(byCurrency[currency], tx)
+	}
+	if req.Currency != "" && len(byCurrency) == 0 {
+		statement, err := c.emptyStatement(ctx, req, callOpts)
+		if err != nil {
+			return nil, err
+		}
+		return []*Statement{statement}, nil
+	}
+
+	currencies := make([]string, 0, len(byCurrency))
+	for currency := range byCurrency {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	statements := make([]*Statement, 0, len(currencies))
+	for _, currency := range currencies {
+		entries := byCurrency[currency]
+		sort.SliceStable(entries, func(i, j int) bool {
+			ti, _ := parseAPITime(entries[i].CreatedAt)
+			tj, _ := parseAPITime(entries[j].CreatedAt)
+			return ti.Before(tj)
+		})
+
+		statement := newStatement(req, currency)
+		statement.OpeningBalance = entries[0].BalanceBefore
+		statement.Entries = entries
+		statement.ClosingBalance = statement.OpeningBalance
+		for i := range entries {
+			amount, credit := entryAmount(&entries[i])
+			if credit {
+				statement.TotalCredits += amount
+				statement.CreditCount++
+				statement.ClosingBalance += amount
+			} else {
+				statement.TotalDebits += amount
+				statement.DebitCount++
+				statement.ClosingBalance -= amount
+			}
+		}
+		statement.TotalCredits = roundCents(statement.TotalCredits)
+		statement.TotalDebits = roundCents(statement.TotalDebits)
+		statement.ClosingBalance = roundCents(statement.ClosingBalance)
+		statements = append(statements, statement)
+	}
+
+	return statements, nil
+}
+
+func newStatement(req *StatementRequest, currency string) *Statement {
+	statement := &Statement{
+		AccountID:         req.AccountID,
+		AccountIdentifier: req.AccountIdentifier,
+		ServicerBIC:       req.ServicerBIC,
+		Currency:          currency,
+		From:              req.From,
+		To:                req.To,
+		SequenceNumber:    req.SequenceNumber,
+		CreatedAt:         time.Now(),
+	}
+	if statement.AccountIdentifier == "" {
+		statement.AccountIdentifier = req.AccountID
+	}
+	if statement.SequenceNumber <= 0 {
+		statement.SequenceNumber = 1
+	}
+	return statement
+}
+
+// emptyStatement builds the statement of a period without transactions. Its balance is the
+// current balance minus the completed transactions booked since the end of the period.
+func (c *BusinessAccountClient) emptyStatement(ctx context.Context, req *StatementRequest, callOpts []CallOption) (*Statement, error) {
+	currency := strings.ToUpper(req.Currency)
+	balance, err := c.GetAccountBalance(ctx, req.AccountID, callOpts...)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(balance.Currency, currency) {
+		return nil, fmt.Errorf("no %s transactions in the period and the account balance is in %s", currency, balance.Currency)
+	}
+
+	later, err := c.listTransactions(ctx, req.AccountID, req.To, time.Now(), callOpts)
+	if err != nil {
+		return nil, err
+	}
+	amount := balance.Balance
+	for i := range later {
+		tx := &later[i]
+		if !strings.EqualFold(tx.Currency, currency) || (tx.Status != "" && statusState(tx.Status) != OperationSucceeded) {
+			continue
+		}
+		if value, credit := entryAmount(tx); credit {
+			amount -= value
+		} else {
+			amount += value
+		}
+	}
+
+	statement := newStatement(req, currency)
+	statement.OpeningBalance = roundCents(amount)
+	statement.ClosingBalance = statement.OpeningBalance
+	return statement, nil
+}
+
+// listTransactions returns every transaction of the account created in [from, to)
+func (c *BusinessAccountClient) listTransactions(ctx context.Context, accountID string, from, to time.Time, callOpts []CallOption) ([]BusinessAccountTransaction, error) {
+	options := &ListBusinessAccountTransactionsOptions{
+		AccountID: accountID,
+		StartTime: from.Format(time.RFC3339),
+		EndTime:   to.Format(time.RFC3339),
+		Limit:     selectPageSize,
+	}
+
+	var transactions []BusinessAccountTransaction
+	err := walkPages(func(page int) (int, int, error) {
+		options.Page = page
+		list, err := c.GetAccountTransactions(ctx, options, callOpts...)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to list transactions (page %d): %w", page, err)
+		}
+		for _, tx := range list.Transactions {
+			// The period is re-applied in case the API treats its bounds differently
+			if created, ok := parseAPITime(tx.CreatedAt); ok && (created.Before(from) || !created.Before(to)) {
+				continue
+			}
+			transactions = append(transactions, tx)
+		}
+		return len(list.Transactions), list.TotalCount, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
+
+// entryAmount returns the absolute amount of a transaction and whether it credits the account.
+// Transactions without a DEBIT or CREDIT type are classified by their balance change.
+func entryAmount(tx *BusinessAccountTransaction) (float64, bool) {
+	amount := math.Abs(tx.Amount)
+	switch strings.ToUpper(tx.Type) {
+	case "CREDIT":
+		return amount, true
+	case "DEBIT":
+		return amount, false
+	}
+	if tx.BalanceAfter != tx.BalanceBefore {
+		return amount, tx.BalanceAfter > tx.BalanceBefore
+	}
+	return amount, tx.Amount >= 0
+}
+
+// mt940Charset replaces the characters outside of the SWIFT X character set
+var mt940Charset = strings.NewReplacer("&", "+", "_", "-", "@", "(AT)", "\"", "'", ";", ",", "!", ".", "#", "", "*", "", "\r", " ", "\n", " ")
+
+// mt940Text sanitizes free text for MT940 and truncates it to max characters
+func mt940Text(value string, max int) string {
+	value = mt940Charset.Replace(value)
+	var b strings.Builder
+	for _, r := range value {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', strings.ContainsRune("/-?:().,'+ ", r):
+			b.WriteRune(r)
+		default:
+			b.WriteRune('.')
+		}
+	}
+	value = strings.TrimSpace(b.String())
+	if len(value) > max {
+		value = value[:max]
+	}
+	return value
+}
+
+// mt940Amount formats an amount with a decimal comma, e.g. 1234,56
+func mt940Amount(amount float64) string {
+	return strings.Replace(strconv.FormatFloat(math.Abs(amount), 'f', 2, 64), ".", ",", 1)
+}
+
+// mt940Balance formats a balance field: mark, date, currency and amount
+func mt940Balance(amount float64, date time.Time, currency string) string {
+	mark := "C"
+	if amount < 0 {
+		mark = "D"
+	}
+	return mark + date.Format("060102") + currency + mt940Amount(amount)
+}
+
+// mt940TransactionType maps a transaction category to a SWIFT transaction type code
+func mt940TransactionType(category string) string {
+	switch strings.ToUpper(category) {
+	case "FEE":
+		return "CHG"
+	case "TRANSFER_IN", "TRANSFER_OUT", "PAYOUT", "REFUND":
+		return "TRF"
+	}
+	return "MSC"
+}
+
+// WriteMT940 writes the statement as a SWIFT MT940 message body (block 4), lines separated by
+// CRLF, as imported by most ERP systems
+func (s *Statement) WriteMT940(w io.Writer) error {
+	last := s.To.Add(-time.Nanosecond)
+	lines := []string{
+		":20:" + mt940Text(s.ID(), 16),
+		":25:" + mt940Text(s.AccountIdentifier, 35),
+		fmt.Sprintf(":28C:%d/1", s.SequenceNumber%100000),
+		":60F:" + mt940Balance(s.OpeningBalance, s.From, s.Currency),
+	}
+
+	for i := range s.Entries {
+		tx := &s.Entries[i]
+		amount, credit := entryAmount(tx)
+		mark := "D"
+		if credit {
+			mark = "C"
+		}
+		booked, ok := parseAPITime(tx.CreatedAt)
+		if !ok {
+			booked = s.From
+		}
+		booked = booked.In(s.From.Location())
+
+		reference := mt940Text(tx.Reference, 16)
+		if reference == "" {
+			reference = "NONREF"
+		}
+		line := ":61:" + booked.Format("060102") + booked.Format("0102") + mark + mt940Amount(amount) +
+			"N" + mt940TransactionType(tx.Category) + reference
+		if bankRef := mt940Text(tx.TransactionID, 16); bankRef != "" {
+			line += "//" + bankRef
+		}
+		lines = append(lines, line)
+
+		narrative := mt940Text(strings.Join(nonEmpty(tx.Description, tx.CounterpartyName, tx.CounterpartyAccount), " "), 6*65)
+		if narrative != "" {
+			// :86: holds up to 6 lines of 65 characters
+			for i := 0; i < len(narrative); i += 65 {
+				chunk := narrative[i:min(i+65, len(narrative))]
+				if i == 0 {
+					chunk = ":86:" + chunk
+				}
+				lines = append(lines, chunk)
+			}
+		}
+	}
+
+	lines = append(lines, ":62F:"+mt940Balance(s.ClosingBalance, last, s.Currency))
+	_, err := io.WriteString(w, strings.Join(lines, "\r\n")+"\r\n")
+	return err
+}
+
+func nonEmpty(values ...string) []string {
+	var result []string
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// camt053Namespace is the version of the CAMT.053 documents written
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.08"
+
+type camtAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+func newCAMTAmount(amount float64, currency string) camtAmount {
+	return camtAmount{Currency: currency, Value: strconv.FormatFloat(math.Abs(amount), 'f', 2, 64)}
+}
+
+type camtBalance struct {
+	Code      string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amount    camtAmount `xml:"Amt"`
+	Indicator string     `xml:"CdtDbtInd"`
+	Date      string     `xml:"Dt>Dt"`
+}
+
+type camtEntry struct {
+	Reference     string          `xml:"NtryRef,omitempty"`
+	Amount        camtAmount      `xml:"Amt"`
+	Indicator     string          `xml:"CdtDbtInd"`
+	Status        string          `xml:"Sts>Cd"`
+	BookingDate   string          `xml:"BookgDt>DtTm"`
+	ValueDate     string          `xml:"ValDt>Dt"`
+	ServicerRef   string          `xml:"AcctSvcrRef,omitempty"`
+	BankTxCode    string          `xml:"BkTxCd>Prtry>Cd"`
+	EndToEndID    string          `xml:"NtryDtls>TxDtls>Refs>EndToEndId"`
+	Parties       *camtParties    `xml:"NtryDtls>TxDtls>RltdPties,omitempty"`
+	RemittanceInf *camtRemittance `xml:"NtryDtls>TxDtls>RmtInf,omitempty"`
+}
+
+// Optional elements are pointers: encoding/xml writes the parents of an omitted a>b field
+
+type camtParties struct {
+	Debtor          *camtParty     `xml:"Dbtr,omitempty"`
+	DebtorAccount   *camtAccountID `xml:"DbtrAcct,omitempty"`
+	Creditor        *camtParty     `xml:"Cdtr,omitempty"`
+	CreditorAccount *camtAccountID `xml:"CdtrAcct,omitempty"`
+}
+
+type camtParty struct {
+	Name string `xml:"Pty>Nm"`
+}
+
+type camtAccountID struct {
+	IBAN  string       `xml:"Id>IBAN,omitempty"`
+	Other *camtOtherID `xml:"Id>Othr,omitempty"`
+}
+
+type camtOtherID struct {
+	ID string `xml:"Id"`
+}
+
+func newCAMTAccountID(identifier string) *camtAccountID {
+	if ValidateIBAN(identifier) == nil {
+		return &camtAccountID{IBAN: normalizeBankValue(identifier)}
+	}
+	return &camtAccountID{Other: &camtOtherID{ID: identifier}}
+}
+
+type camtRemittance struct {
+	Unstructured string `xml:"Ustrd"`
+}
+
+type camtAccount struct {
+	*camtAccountID
+	Currency string        `xml:"Ccy"`
+	Servicer *camtServicer `xml:"Svcr,omitempty"`
+}
+
+type camtServicer struct {
+	BIC string `xml:"FinInstnId>BICFI"`
+}
+
+type camtTotals struct {
+	Count int    `xml:"NbOfNtries"`
+	Sum   string `xml:"Sum"`
+}
+
+type camt053Document struct {
+	XMLName   xml.Name `xml:"Document"`
+	Xmlns     string   `xml:"xmlns,attr"`
+	MessageID string   `xml:"BkToCstmrStmt>GrpHdr>MsgId"`
+	Created   string   `xml:"BkToCstmrStmt>GrpHdr>CreDtTm"`
+	Statement struct {
+		ID             string        `xml:"Id"`
+		SequenceNumber int           `xml:"ElctrncSeqNb"`
+		Created        string        `xml:"CreDtTm"`
+		From           string        `xml:"FrToDt>FrDtTm"`
+		To             string        `xml:"FrToDt>ToDtTm"`
+		Account        camtAccount   `xml:"Acct"`
+		Balances       []camtBalance `xml:"Bal"`
+		Total          struct {
+			Count     int    `xml:"NbOfNtries"`
+			Sum       string `xml:"Sum"`
+			Net       string `xml:"TtlNetNtry>Amt"`
+			Indicator string `xml:"TtlNetNtry>CdtDbtInd"`
+		} `xml:"TxsSummry>TtlNtries"`
+		Credits camtTotals  `xml:"TxsSummry>TtlCdtNtries"`
+		Debits  camtTotals  `xml:"TxsSummry>TtlDbtNtries"`
+		Entries []camtEntry `xml:"Ntry"`
+	} `xml:"BkToCstmrStmt>Stmt"`
+}
+
+func camtIndicator(amount float64, credit bool) string {
+	if credit && amount >= 0 || !credit && amount < 0 {
+		return "CRDT"
+	}
+	return "DBIT"
+}
+
+// WriteCAMT053 writes the statement as an ISO 20022 CAMT.053 (camt.053.001.08) bank-to-customer
+// statement with opening (OPBD) and closing (CLBD) booked balances
+func (s *Statement) WriteCAMT053(w io.Writer) error {
+	const dateTime = "2006-01-02T15:04:05"
+	last := s.To.Add(-time.Nanosecond)
+
+	var doc camt053Document
+	doc.Xmlns = camt053Namespace
+	doc.MessageID = fmt.Sprintf("%s-%d", s.ID(), s.SequenceNumber)
+	doc.Created = s.CreatedAt.Format(dateTime)
+
+	stmt := &doc.Statement
+	stmt.ID = s.ID()
+	stmt.SequenceNumber = s.SequenceNumber
+	stmt.Created = doc.Created
+	stmt.From = s.From.Format(dateTime)
+	stmt.To = last.Format(dateTime)
+	stmt.Account.camtAccountID = newCAMTAccountID(s.AccountIdentifier)
+	stmt.Account.Currency = s.Currency
+	if s.ServicerBIC != "" {
+		stmt.Account.Servicer = &camtServicer{BIC: s.ServicerBIC}
+	}
+	stmt.Balances = []camtBalance{
+		{Code: "OPBD", Amount: newCAMTAmount(s.OpeningBalance, s.Currency), Indicator: camtIndicator(s.OpeningBalance, true), Date: s.From.Format("2006-01-02")},
+		{Code: "CLBD", Amount: newCAMTAmount(s.ClosingBalance, s.Currency), Indicator: camtIndicator(s.ClosingBalance, true), Date: last.Format("2006-01-02")},
+	}
+
+	net := s.TotalCredits - s.TotalDebits
+	stmt.Total.Count = len(s.Entries)
+	stmt.Total.Sum = strconv.FormatFloat(roundCents(s.TotalCredits+s.TotalDebits), 'f', 2, 64)
+	stmt.Total.Net = newCAMTAmount(net, s.Currency).Value
+	stmt.Total.Indicator = camtIndicator(net, true)
+	stmt.Credits = camtTotals{Count: s.CreditCount, Sum: strconv.FormatFloat(s.TotalCredits, 'f', 2, 64)}
+	stmt.Debits = camtTotals{Count: s.DebitCount, Sum: strconv.FormatFloat(s.TotalDebits, 'f', 2, 64)}
+
+	for i := range s.Entries {
+		tx := &s.Entries[i]
+		amount, credit := entryAmount(tx)
+		booked, ok := parseAPITime(tx.CreatedAt)
+		if !ok {
+			booked = s.From
+		}
+		booked = booked.In(s.From.Location())
+
+		entry := camtEntry{
+			Reference:   tx.TransactionID,
+			Amount:      newCAMTAmount(amount, s.Currency),
+			Indicator:   camtIndicator(amount, credit),
+			Status:      "BOOK",
+			BookingDate: booked.Format(dateTime),
+			ValueDate:   booked.Format("2006-01-02"),
+			ServicerRef: tx.TransactionID,
+			BankTxCode:  tx.Category,
+			EndToEndID:  tx.Reference,
+		}
+		if tx.Description != "" {
+			entry.RemittanceInf = &camtRemittance{Unstructured: tx.Description}
+		}
+		if entry.BankTxCode == "" {
+			entry.BankTxCode = strings.ToUpper(tx.Type)
+		}
+		if entry.EndToEndID == "" {
+			entry.EndToEndID = "NOTPROVIDED"
+		}
+		if tx.CounterpartyName != "" || tx.CounterpartyAccount != "" {
+			var party *camtParty
+			var account *camtAccountID
+			if tx.CounterpartyName != "" {
+				party = &camtParty{Name: tx.CounterpartyName}
+			}
+			if tx.CounterpartyAccount != "" {
+				account = newCAMTAccountID(tx.CounterpartyAccount)
+			}
+			// The counterparty is the debtor of incoming funds and the creditor of outgoing ones
+			if credit {
+				entry.Parties = &camtParties{Debtor: party, DebtorAccount: account}
+			} else {
+				entry.Parties = &camtParties{Creditor: party, CreditorAccount: account}
+			}
+		}
+		stmt.Entries = append(stmt.Entries, entry)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write CAMT.053: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
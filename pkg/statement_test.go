@@ -0,0 +1,183 @@
+package interlace
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testStatement() *Statement {
+	return &Statement{
+		AccountID:         "acc",
+		AccountIdentifier: "DE89370400440532013000",
+		ServicerBIC:       "DEUTDEFF",
+		Currency:          "EUR",
+		From:              time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		To:                time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+		SequenceNumber:    3,
+		CreatedAt:         time.Date(2024, 4, 1, 8, 0, 0, 0, time.UTC),
+		OpeningBalance:    1000,
+		ClosingBalance:    1225.5,
+		Entries: []BusinessAccountTransaction{
+			{
+				TransactionID:       "tx-1",
+				Type:                "CREDIT",
+				Category:            "TRANSFER_IN",
+				Amount:              250.5,
+				Description:         "Invoice #42 & more",
+				CounterpartyName:    "Acme Ltd",
+				CounterpartyAccount: "GB82WEST12345698765432",
+				Reference:           "INV-42",
+				CreatedAt:           "2024-03-05T10:00:00Z",
+			},
+			{
+				TransactionID: "tx-2",
+				Category:      "FEE",
+				Amount:        25,
+				BalanceBefore: 1250.5,
+				BalanceAfter:  1225.5,
+				CreatedAt:     "2024-03-31T23:00:00Z",
+			},
+		},
+		TotalCredits: 250.5,
+		TotalDebits:  25,
+		CreditCount:  1,
+		DebitCount:   1,
+	}
+}
+
+func TestMT940Text(t *testing.T) {
+	tests := []struct {
+		value string
+		max   int
+		want  string
+	}{
+		{value: "Invoice 42", max: 16, want: "Invoice 42"},
+		{value: "A&B_C@d", max: 16, want: "A+B-C(AT)d"},
+		{value: "Müller; \"quoted\"", max: 35, want: "M.ller, 'quoted'"},
+		{value: "  line\nbreak  ", max: 35, want: "line break"},
+		{value: "abcdefghijklmnopqrstuvwxyz", max: 16, want: "abcdefghijklmnop"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			assert.Equal(t, tt.want, mt940Text(tt.value, tt.max))
+		})
+	}
+}
+
+func TestMT940Balance(t *testing.T) {
+	date := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		amount float64
+		want   string
+	}{
+		{amount: 1234.56, want: "C240301EUR1234,56"},
+		{amount: 0, want: "C240301EUR0,00"},
+		{amount: -10.5, want: "D240301EUR10,50"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, mt940Balance(tt.amount, date, "EUR"))
+	}
+}
+
+func TestStatementWriteMT940(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, testStatement().WriteMT940(&buf))
+
+	want := strings.Join([]string{
+		":20:20240301EUR",
+		":25:DE89370400440532013000",
+		":28C:3/1",
+		":60F:C240301EUR1000,00",
+		":61:2403050305C250,50NTRFINV-42//tx-1",
+		":86:Invoice 42 + more Acme Ltd GB82WEST12345698765432",
+		":61:2403310331D25,00NCHGNONREF//tx-2",
+		":62F:C240331EUR1225,50",
+	}, "\r\n") + "\r\n"
+	assert.Equal(t, want, buf.String())
+}
+
+func TestStatementWriteCAMT053(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, testStatement().WriteCAMT053(&buf))
+
+	var doc struct {
+		XMLName   xml.Name
+		MessageID string `xml:"BkToCstmrStmt>GrpHdr>MsgId"`
+		Statement struct {
+			ID       string `xml:"Id"`
+			IBAN     string `xml:"Acct>Id>IBAN"`
+			BIC      string `xml:"Acct>Svcr>FinInstnId>BICFI"`
+			Balances []struct {
+				Code      string     `xml:"Tp>CdOrPrtry>Cd"`
+				Amount    camtAmount `xml:"Amt"`
+				Indicator string     `xml:"CdtDbtInd"`
+				Date      string     `xml:"Dt>Dt"`
+			} `xml:"Bal"`
+			Count     int        `xml:"TxsSummry>TtlNtries>NbOfNtries"`
+			Sum       string     `xml:"TxsSummry>TtlNtries>Sum"`
+			Net       camtAmount `xml:"TxsSummry>TtlNtries>TtlNetNtry>Amt"`
+			NetInd    string     `xml:"TxsSummry>TtlNtries>TtlNetNtry>CdtDbtInd"`
+			CreditSum string     `xml:"TxsSummry>TtlCdtNtries>Sum"`
+			DebitSum  string     `xml:"TxsSummry>TtlDbtNtries>Sum"`
+			Entries   []struct {
+				Amount      string `xml:"Amt"`
+				Indicator   string `xml:"CdtDbtInd"`
+				BankTxCode  string `xml:"BkTxCd>Prtry>Cd"`
+				EndToEndID  string `xml:"NtryDtls>TxDtls>Refs>EndToEndId"`
+				Debtor      string `xml:"NtryDtls>TxDtls>RltdPties>Dbtr>Pty>Nm"`
+				DebtorIBAN  string `xml:"NtryDtls>TxDtls>RltdPties>DbtrAcct>Id>IBAN"`
+				Creditor    string `xml:"NtryDtls>TxDtls>RltdPties>Cdtr>Pty>Nm"`
+				Remittance  string `xml:"NtryDtls>TxDtls>RmtInf>Ustrd"`
+				BookingDate string `xml:"BookgDt>DtTm"`
+			} `xml:"Ntry"`
+		} `xml:"BkToCstmrStmt>Stmt"`
+	}
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+
+	assert.Equal(t, camt053Namespace, doc.XMLName.Space)
+	assert.Equal(t, "20240301EUR-3", doc.MessageID)
+	stmt := doc.Statement
+	assert.Equal(t, "20240301EUR", stmt.ID)
+	assert.Equal(t, "DE89370400440532013000", stmt.IBAN)
+	assert.Equal(t, "DEUTDEFF", stmt.BIC)
+
+	require.Len(t, stmt.Balances, 2)
+	assert.Equal(t, "OPBD", stmt.Balances[0].Code)
+	assert.Equal(t, camtAmount{Currency: "EUR", Value: "1000.00"}, stmt.Balances[0].Amount)
+	assert.Equal(t, "CRDT", stmt.Balances[0].Indicator)
+	assert.Equal(t, "2024-03-01", stmt.Balances[0].Date)
+	assert.Equal(t, "CLBD", stmt.Balances[1].Code)
+	assert.Equal(t, camtAmount{Currency: "EUR", Value: "1225.50"}, stmt.Balances[1].Amount)
+	assert.Equal(t, "2024-03-31", stmt.Balances[1].Date)
+
+	assert.Equal(t, 2, stmt.Count)
+	assert.Equal(t, "275.50", stmt.Sum)
+	assert.Equal(t, camtAmount{Value: "225.50"}, stmt.Net, "the net entry total has no currency")
+	assert.Equal(t, "CRDT", stmt.NetInd)
+	assert.Equal(t, "250.50", stmt.CreditSum)
+	assert.Equal(t, "25.00", stmt.DebitSum)
+
+	require.Len(t, stmt.Entries, 2)
+	credit, debit := stmt.Entries[0], stmt.Entries[1]
+	assert.Equal(t, "250.50", credit.Amount)
+	assert.Equal(t, "CRDT", credit.Indicator)
+	assert.Equal(t, "TRANSFER_IN", credit.BankTxCode)
+	assert.Equal(t, "INV-42", credit.EndToEndID)
+	assert.Equal(t, "Acme Ltd", credit.Debtor)
+	assert.Equal(t, "GB82WEST12345698765432", credit.DebtorIBAN)
+	assert.Equal(t, "Invoice #42 & more", credit.Remittance)
+	assert.Equal(t, "2024-03-05T10:00:00", credit.BookingDate)
+	assert.Equal(t, "25.00", debit.Amount)
+	assert.Equal(t, "DBIT", debit.Indicator)
+	assert.Equal(t, "FEE", debit.BankTxCode)
+	assert.Equal(t, "NOTPROVIDED", debit.EndToEndID)
+	assert.Empty(t, debit.Creditor)
+}
@@ -53,7 +53,9 @@ type SweepTransaction struct {
 
 // Sweeping performs cryptocurrency sweeping from multiple addresses to a single address
 // POST /open-api/v3/crypto/sweeping
-func (c *SweepingClient) Sweeping(ctx context.Context, req *SweepingRequest) (*SweepingResponse, error) {
+func (c *SweepingClient) Sweeping(ctx context.Context, req *SweepingRequest, callOpts ...CallOption) (*SweepingResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if req == nil {
 		return nil, fmt.Errorf("sweeping request is required")
 	}
@@ -95,3 +97,29 @@ func (c *SweepingClient) Sweeping(ctx context.Context, req *SweepingRequest) (*S
 
 	return &response, nil
 }
+
+// CreateSweepingOperation performs a sweeping and returns an operation that tracks it. The API
+// has no endpoint to query a sweeping, so the operation can only be completed from webhook
+// events (see WaitOptions.Webhooks).
+func (c *SweepingClient) CreateSweepingOperation(ctx context.Context, req *SweepingRequest, callOpts ...CallOption) (*Operation[SweepingResponse], error) {
+	sweeping, err := c.Sweeping(ctx, req, callOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return newOperation(sweeping.SweepingID, sweeping, sweepingOperationSpec()), nil
+}
+
+// SweepingOperation returns an operation that tracks an existing sweeping from webhook events
+func (c *SweepingClient) SweepingOperation(sweepingID string) *Operation[SweepingResponse] {
+	return newOperation(sweepingID, nil, sweepingOperationSpec())
+}
+
+func sweepingOperationSpec() operationSpec[SweepingResponse] {
+	return operationSpec[SweepingResponse]{
+		state: func(s *SweepingResponse) (OperationState, string, string) {
+			return statusState(s.Status), s.Status, ""
+		},
+		eventKeys: []string{"sweepingId", "id"},
+	}
+}
@@ -48,7 +48,9 @@ type SimulateAuthorizationResponse struct {
 
 // SimulateCardAuthorization simulates a card authorization request for testing
 // POST /open-api/v3/testing/simulate-authorization
-func (c *TestingClient) SimulateCardAuthorization(ctx context.Context, req *SimulateAuthorizationRequest) (*SimulateAuthorizationResponse, error) {
+func (c *TestingClient) SimulateCardAuthorization(ctx context.Context, req *SimulateAuthorizationRequest, callOpts ...CallOption) (*SimulateAuthorizationResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if req == nil {
 		return nil, fmt.Errorf("simulation request is required")
 	}
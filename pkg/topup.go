@@ -0,0 +1,546 @@
+package interlace
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TopUpRule keeps prepaid cards funded. A card whose available balance drops below MinBalance is
+// topped up to RefillTo, without exceeding DailyCap per card and UTC day.
+type TopUpRule struct {
+	Name string
+
+	// CardID selects a single card. Otherwise Label selects every card with that label, optionally
+	// restricted to AccountID. Rules on a CardID take precedence over label rules.
+	CardID    string
+	Label     string
+	AccountID string
+
+	MinBalance float64
+	RefillTo   float64
+	// DailyCap limits the amount topped up per card and UTC day. Zero means no limit.
+	DailyCap float64
+	Currency string
+
+	// InfinityAccountID and InfinityAccountType select the funding source. The default account
+	// funds the card when they are empty.
+	InfinityAccountID   string
+	InfinityAccountType string
+}
+
+func (r *TopUpRule) validate() error {
+	switch {
+	case r.CardID == "" && r.Label == "":
+		return fmt.Errorf("top-up rule %q: cardId or label is required", r.Name)
+	case r.Currency == "":
+		return fmt.Errorf("top-up rule %q: currency is required", r.Name)
+	case r.RefillTo <= r.MinBalance:
+		return fmt.Errorf("top-up rule %q: refill-to amount must be greater than the minimum balance", r.Name)
+	}
+	return nil
+}
+
+// TopUpStatus is the outcome of a top-up attempt
+type TopUpStatus string
+
+// Top-up statuses
+const (
+	TopUpSucceeded TopUpStatus = "succeeded"
+	TopUpFailed    TopUpStatus = "failed"
+	// TopUpCapped means the card needed funds but its daily cap was reached
+	TopUpCapped TopUpStatus = "capped"
+)
+
+// TopUpEntry is an audit trail entry, recorded for every card found below its minimum balance
+type TopUpEntry struct {
+	Time    time.Time   `json:"time"`
+	Rule    string      `json:"rule"`
+	CardID  string      `json:"cardId"`
+	Status  TopUpStatus `json:"status"`
+	Balance float64     `json:"balance"`
+	Amount  float64     `json:"amount"`
+	// Currency is the currency of Amount
+	Currency string `json:"currency"`
+	// MerchantTradeNo identifies the transfer and doubles as its idempotency key
+	MerchantTradeNo string `json:"merchantTradeNo,omitempty"`
+	TransferID      string `json:"transferId,omitempty"`
+	Error           string `json:"error,omitempty"`
+	// Ambiguous marks a failed transfer that may still have gone through. It is sent again with
+	// the same trade number and amount before the card is topped up again, and the outcome is
+	// recorded as a new entry with that trade number.
+	Ambiguous bool `json:"ambiguous,omitempty"`
+}
+
+// TopUpAuditLog stores the audit trail of the top-up engine, which also uses it to enforce daily
+// caps. Implementations must be safe for concurrent use.
+type TopUpAuditLog interface {
+	Append(ctx context.Context, entry *TopUpEntry) error
+	// Entries returns the entries of a card recorded at or after since, oldest first
+	Entries(ctx context.Context, cardID string, since time.Time) ([]TopUpEntry, error)
+}
+
+// MemoryTopUpLog is an in-memory TopUpAuditLog, useful for tests. Entries are lost when the
+// process exits.
+type MemoryTopUpLog struct {
+	mu      sync.Mutex
+	entries []TopUpEntry
+}
+
+// NewMemoryTopUpLog creates an empty in-memory audit log
+func NewMemoryTopUpLog() *MemoryTopUpLog {
+	return &MemoryTopUpLog{}
+}
+
+// Append implements TopUpAuditLog
+func (l *MemoryTopUpLog) Append(ctx context.Context, entry *TopUpEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, *entry)
+	return nil
+}
+
+// Entries implements TopUpAuditLog
+func (l *MemoryTopUpLog) Entries(ctx context.Context, cardID string, since time.Time) ([]TopUpEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return filterTopUpEntries(l.entries, cardID, since), nil
+}
+
+// FileTopUpLog appends the audit trail to a JSON Lines file
+type FileTopUpLog struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileTopUpLog creates an audit log that appends to the file at path, creating it if needed
+func NewFileTopUpLog(path string) (*FileTopUpLog, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open top-up audit log: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("failed to open top-up audit log: %w", err)
+	}
+	return &FileTopUpLog{path: path}, nil
+}
+
+// Append implements TopUpAuditLog
+func (l *FileTopUpLog) Append(ctx context.Context, entry *TopUpEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode top-up entry: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to append top-up entry: %w", err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to append top-up entry: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to append top-up entry: %w", err)
+	}
+	return f.Close()
+}
+
+// Entries implements TopUpAuditLog
+func (l *FileTopUpLog) Entries(ctx context.Context, cardID string, since time.Time) ([]TopUpEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.Open(l.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read top-up audit log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []TopUpEntry
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var entry TopUpEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to decode top-up audit log line %d: %w", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read top-up audit log: %w", err)
+	}
+	return filterTopUpEntries(entries, cardID, since), nil
+}
+
+func filterTopUpEntries(entries []TopUpEntry, cardID string, since time.Time) []TopUpEntry {
+	var matched []TopUpEntry
+	for _, entry := range entries {
+		if entry.CardID == cardID && !entry.Time.Before(since) {
+			matched = append(matched, entry)
+		}
+	}
+	return matched
+}
+
+// TopUpEngine applies top-up rules, on a schedule with Run or on transaction webhooks with Watch
+//
+//	log, err := interlace.NewFileTopUpLog("topups.jsonl")
+//	engine, err := interlace.NewTopUpEngine(client, log, interlace.TopUpRule{
+//		Name:       "ads",
+//		Label:      "ads",
+//		MinBalance: 100,
+//		RefillTo:   500,
+//		DailyCap:   2000,
+//		Currency:   "USD",
+//	})
+//	go engine.Watch(ctx, webhookServer)
+//	err = engine.Run(ctx, 15*time.Minute)
+type TopUpEngine struct {
+	client *Client
+	rules  []TopUpRule
+	log    TopUpAuditLog
+
+	// OnEntry is called after every audit entry is recorded
+	OnEntry func(entry *TopUpEntry)
+	// OnError is called with the errors of Run and Watch evaluations
+	OnError func(err error)
+
+	now func() time.Time
+
+	mu        sync.Mutex
+	cardLocks map[string]*sync.Mutex
+	labels    map[string]string // Card ID to label, refreshed by Evaluate
+}
+
+// NewTopUpEngine creates a top-up engine. Entries are recorded in log, which must not be nil.
+func NewTopUpEngine(client *Client, log TopUpAuditLog, rules ...TopUpRule) (*TopUpEngine, error) {
+	if log == nil {
+		return nil, errors.New("top-up audit log is required")
+	}
+	for i := range rules {
+		if err := rules[i].validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &TopUpEngine{
+		client:    client,
+		rules:     rules,
+		log:       log,
+		now:       time.Now,
+		cardLocks: make(map[string]*sync.Mutex),
+		labels:    make(map[string]string),
+	}, nil
+}
+
+// Run evaluates every rule right away and then every interval until ctx is done. Evaluation
+// errors are passed to OnError; Run only returns ctx.Err().
+func (e *TopUpEngine) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := e.Evaluate(ctx); err != nil {
+			e.reportError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Watch re-evaluates a card whenever a transaction webhook for it arrives, until ctx is done
+func (e *TopUpEngine) Watch(ctx context.Context, webhooks *WebhookServer) error {
+	events, unsubscribe := webhooks.Subscribe()
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event := <-events:
+			if _, err := e.HandleEvent(ctx, event); err != nil {
+				e.reportError(err)
+			}
+		}
+	}
+}
+
+func (e *TopUpEngine) reportError(err error) {
+	if e.OnError != nil && !errors.Is(err, context.Canceled) {
+		e.OnError(err)
+	}
+}
+
+// HandleEvent re-evaluates the card of a transaction webhook event. Other events are ignored.
+func (e *TopUpEngine) HandleEvent(ctx context.Context, event *WebhookEvent) (*TopUpEntry, error) {
+	if !strings.HasPrefix(event.EventType, "transaction.") {
+		return nil, nil
+	}
+	cardID, _ := event.Data["cardId"].(string)
+	if cardID == "" {
+		return nil, nil
+	}
+	return e.EvaluateCard(ctx, cardID)
+}
+
+// Evaluate applies the rules to every card they select and returns the recorded entries. Cards
+// that could not be evaluated are reported in the returned error, after the others were processed.
+func (e *TopUpEngine) Evaluate(ctx context.Context) ([]TopUpEntry, error) {
+	cards, err := e.selectCards(ctx)
+
+	var entries []TopUpEntry
+	var errs []error
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for cardID, rule := range cards {
+		entry, err := e.evaluate(ctx, cardID, rule)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if entry != nil {
+			entries = append(entries, *entry)
+		}
+	}
+	return entries, errors.Join(errs...)
+}
+
+// EvaluateCard applies the matching rule to one card. It returns a nil entry when no rule
+// applies or the card has enough funds.
+func (e *TopUpEngine) EvaluateCard(ctx context.Context, cardID string) (*TopUpEntry, error) {
+	rule := e.ruleFor(cardID)
+	if rule == nil && e.hasLabelRules() {
+		// The card may be new; refresh the labels once
+		if _, err := e.selectCards(ctx); err != nil {
+			return nil, err
+		}
+		rule = e.ruleFor(cardID)
+	}
+	if rule == nil {
+		return nil, nil
+	}
+	return e.evaluate(ctx, cardID, rule)
+}
+
+// selectCards resolves the cards of every rule and refreshes the label index. The cards of the
+// rules that could be resolved are returned along with any error.
+func (e *TopUpEngine) selectCards(ctx context.Context) (map[string]*TopUpRule, error) {
+	cards := make(map[string]*TopUpRule)
+	labels := make(map[string]string)
+	var errs []error
+	for i := range e.rules {
+		rule := &e.rules[i]
+		if rule.CardID != "" {
+			continue
+		}
+		selected, err := e.client.Card.SelectCards(ctx, CardListOptions{AccountID: rule.AccountID}, func(card *Card) bool {
+			return card.Label == rule.Label
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to select cards of top-up rule %q: %w", rule.Name, err))
+			continue
+		}
+		for _, card := range selected {
+			labels[card.ID] = card.Label
+			if _, ok := cards[card.ID]; !ok {
+				cards[card.ID] = rule
+			}
+		}
+	}
+
+	e.mu.Lock()
+	e.labels = labels
+	e.mu.Unlock()
+
+	// Card rules take precedence
+	for i := range e.rules {
+		if e.rules[i].CardID != "" {
+			cards[e.rules[i].CardID] = &e.rules[i]
+		}
+	}
+	return cards, errors.Join(errs...)
+}
+
+func (e *TopUpEngine) ruleFor(cardID string) *TopUpRule {
+	for i := range e.rules {
+		if e.rules[i].CardID == cardID {
+			return &e.rules[i]
+		}
+	}
+
+	e.mu.Lock()
+	label, ok := e.labels[cardID]
+	e.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	for i := range e.rules {
+		if e.rules[i].CardID == "" && e.rules[i].Label == label {
+			return &e.rules[i]
+		}
+	}
+	return nil
+}
+
+func (e *TopUpEngine) hasLabelRules() bool {
+	for i := range e.rules {
+		if e.rules[i].CardID == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// lockCard serializes the evaluations of a card, so a scheduled run and a webhook never top up
+// the same card twice
+func (e *TopUpEngine) lockCard(cardID string) func() {
+	e.mu.Lock()
+	lock, ok := e.cardLocks[cardID]
+	if !ok {
+		lock = &sync.Mutex{}
+		e.cardLocks[cardID] = lock
+	}
+	e.mu.Unlock()
+
+	lock.Lock()
+	return lock.Unlock
+}
+
+func (e *TopUpEngine) evaluate(ctx context.Context, cardID string, rule *TopUpRule) (*TopUpEntry, error) {
+	defer e.lockCard(cardID)()
+
+	now := e.now().UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	history, err := e.log.Entries(ctx, cardID, day)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every attempt uses up a trade number; the last entry of a trade number is its outcome
+	var attempts []string
+	outcomes := make(map[string]*TopUpEntry)
+	for i := range history {
+		if tradeNo := history[i].MerchantTradeNo; tradeNo != "" {
+			if _, ok := outcomes[tradeNo]; !ok {
+				attempts = append(attempts, tradeNo)
+			}
+			outcomes[tradeNo] = &history[i]
+		}
+	}
+	for _, tradeNo := range attempts {
+		if outcome := outcomes[tradeNo]; outcome.Status == TopUpFailed && outcome.Ambiguous {
+			// No new transfer is made while an earlier one may have gone through
+			resolved, err := e.resolve(ctx, outcome, rule)
+			if resolved.Ambiguous || (err != nil && resolved.Status != TopUpFailed) {
+				return resolved, err
+			}
+			outcomes[tradeNo] = resolved
+		}
+	}
+
+	summary, err := e.client.Card.GetCardSummary(ctx, cardID)
+	if err != nil {
+		return nil, err
+	}
+	if summary.AvailableBalance >= rule.MinBalance {
+		return nil, nil
+	}
+
+	toppedUp := 0.0
+	for _, tradeNo := range attempts {
+		if outcomes[tradeNo].Status == TopUpSucceeded {
+			toppedUp += outcomes[tradeNo].Amount
+		}
+	}
+
+	entry := &TopUpEntry{
+		Time:            now,
+		Rule:            rule.Name,
+		CardID:          cardID,
+		Balance:         summary.AvailableBalance,
+		Amount:          rule.RefillTo - summary.AvailableBalance,
+		Currency:        rule.Currency,
+		MerchantTradeNo: fmt.Sprintf("topup-%s-%s-%d", cardID, day.Format("20060102"), len(attempts)+1),
+	}
+	if rule.DailyCap > 0 && toppedUp+entry.Amount > rule.DailyCap {
+		entry.Amount = rule.DailyCap - toppedUp
+	}
+
+	if entry.Amount <= 0 {
+		entry.Amount = 0
+		entry.Status = TopUpCapped
+		entry.MerchantTradeNo = ""
+	} else {
+		e.transferIn(ctx, entry, rule)
+	}
+	return entry, e.record(ctx, entry)
+}
+
+// resolve sends an ambiguous transfer again with its trade number and amount, so that the server
+// returns the original outcome if it went through, and records the result. The returned entry is
+// still ambiguous when the outcome remains unknown.
+func (e *TopUpEngine) resolve(ctx context.Context, attempt *TopUpEntry, rule *TopUpRule) (*TopUpEntry, error) {
+	entry := &TopUpEntry{
+		Time:            e.now().UTC(),
+		Rule:            attempt.Rule,
+		CardID:          attempt.CardID,
+		Balance:         attempt.Balance,
+		Amount:          attempt.Amount,
+		Currency:        attempt.Currency,
+		MerchantTradeNo: attempt.MerchantTradeNo,
+	}
+	e.transferIn(ctx, entry, rule)
+	return entry, e.record(ctx, entry)
+}
+
+// transferIn runs the transfer of an entry and sets its outcome
+func (e *TopUpEngine) transferIn(ctx context.Context, entry *TopUpEntry, rule *TopUpRule) {
+	var meta ResponseMeta
+	resp, err := e.client.CardTransaction.CardTransferIn(ctx, &CardTransferInRequest{
+		CardID:              entry.CardID,
+		Amount:              entry.Amount,
+		Currency:            entry.Currency,
+		MerchantTradeNo:     entry.MerchantTradeNo,
+		InfinityAccountID:   rule.InfinityAccountID,
+		InfinityAccountType: rule.InfinityAccountType,
+	}, WithIdempotencyKey(entry.MerchantTradeNo), WithResponseMeta(&meta))
+	if err != nil {
+		var reqErr *RequestError
+		entry.Status = TopUpFailed
+		entry.Error = err.Error()
+		entry.Ambiguous = errors.As(err, &reqErr) || meta.StatusCode >= 500
+		return
+	}
+	entry.Status = TopUpSucceeded
+	entry.TransferID = resp.ID
+}
+
+// record appends an entry to the audit log and reports failed top-ups as errors
+func (e *TopUpEngine) record(ctx context.Context, entry *TopUpEntry) error {
+	if err := e.log.Append(ctx, entry); err != nil {
+		return fmt.Errorf("failed to record top-up of card %s: %w", entry.CardID, err)
+	}
+	if e.OnEntry != nil {
+		e.OnEntry(entry)
+	}
+	if entry.Status == TopUpFailed {
+		return fmt.Errorf("failed to top up card %s: %s", entry.CardID, entry.Error)
+	}
+	return nil
+}
@@ -0,0 +1,210 @@
+package interlace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// topUpServer fakes the card summary and transfer-in endpoints of one card
+type topUpServer struct {
+	mu        sync.Mutex
+	balance   float64
+	statuses  []int // Transfer-in statuses returned in turn, then 200
+	transfers []CardTransferInRequest
+	keys      []string
+	summaries int
+}
+
+func (s *topUpServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/open-api/v3/cards/card-1/card-summary":
+		s.summaries++
+		json.NewEncoder(w).Encode(CardSummary{AvailableBalance: s.balance})
+	case "/open-api/v3/cards/transfer-in":
+		var req CardTransferInRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		s.transfers = append(s.transfers, req)
+		s.keys = append(s.keys, r.Header.Get(IdempotencyKeyHeader))
+		status := http.StatusOK
+		if len(s.statuses) > 0 {
+			status, s.statuses = s.statuses[0], s.statuses[1:]
+		}
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			fmt.Fprint(w, `{"code":"ERR","message":"transfer failed"}`)
+			return
+		}
+		s.balance += req.Amount
+		json.NewEncoder(w).Encode(CardTransferInResponse{ID: fmt.Sprintf("tr-%d", len(s.transfers)), Amount: req.Amount})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func newTestTopUpEngine(t *testing.T, server *topUpServer, log TopUpAuditLog, rule TopUpRule) *TopUpEngine {
+	srv := httptest.NewServer(server)
+	t.Cleanup(srv.Close)
+
+	cfg := DefaultConfig()
+	cfg.BaseURL = srv.URL
+	cfg.MaxRetries = 0
+	engine, err := NewTopUpEngine(NewClientWithToken(cfg, "token"), log, rule)
+	require.NoError(t, err)
+	engine.now = func() time.Time { return time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC) }
+	return engine
+}
+
+func testTopUpRule() TopUpRule {
+	return TopUpRule{Name: "ads", CardID: "card-1", MinBalance: 100, RefillTo: 500, DailyCap: 600, Currency: "USD"}
+}
+
+func TestTopUpEngineTopsUpBelowMinimum(t *testing.T) {
+	server := &topUpServer{balance: 50}
+	engine := newTestTopUpEngine(t, server, NewMemoryTopUpLog(), testTopUpRule())
+
+	entry, err := engine.EvaluateCard(context.Background(), "card-1")
+	require.NoError(t, err)
+	assert.Equal(t, TopUpSucceeded, entry.Status)
+	assert.Equal(t, 450.0, entry.Amount)
+	assert.Equal(t, "topup-card-1-20240301-1", entry.MerchantTradeNo)
+	assert.Equal(t, []string{"topup-card-1-20240301-1"}, server.keys)
+
+	// Enough funds: nothing to do
+	entry, err = engine.EvaluateCard(context.Background(), "card-1")
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+}
+
+func TestTopUpEngineDailyCap(t *testing.T) {
+	server := &topUpServer{balance: 50}
+	log := NewMemoryTopUpLog()
+	engine := newTestTopUpEngine(t, server, log, testTopUpRule())
+
+	_, err := engine.EvaluateCard(context.Background(), "card-1")
+	require.NoError(t, err)
+
+	server.balance = 0
+	entry, err := engine.EvaluateCard(context.Background(), "card-1")
+	require.NoError(t, err)
+	assert.Equal(t, 150.0, entry.Amount, "the cap leaves 600 - 450")
+	assert.Equal(t, "topup-card-1-20240301-2", entry.MerchantTradeNo)
+
+	server.balance = 0
+	entry, err = engine.EvaluateCard(context.Background(), "card-1")
+	require.NoError(t, err)
+	assert.Equal(t, TopUpCapped, entry.Status)
+	assert.Empty(t, entry.MerchantTradeNo)
+	assert.Len(t, server.transfers, 2)
+}
+
+func TestTopUpEngineFailureRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		// balance is the card balance before the second evaluation, when the first transfer did
+		// not go through
+		balance float64
+		// keys and amounts are the transfers sent over both evaluations
+		keys      []string
+		amounts   []float64
+		statusLog []TopUpStatus
+		ambiguous []bool
+		err       bool
+	}{
+		{
+			name:      "definite failure uses a new trade number",
+			statuses:  []int{http.StatusBadRequest},
+			balance:   80,
+			keys:      []string{"topup-card-1-20240301-1", "topup-card-1-20240301-2"},
+			amounts:   []float64{450, 420},
+			statusLog: []TopUpStatus{TopUpFailed, TopUpSucceeded},
+			ambiguous: []bool{false, false},
+		},
+		{
+			name:      "ambiguous failure is resent with its trade number and amount",
+			statuses:  []int{http.StatusBadGateway},
+			balance:   80,
+			keys:      []string{"topup-card-1-20240301-1", "topup-card-1-20240301-1"},
+			amounts:   []float64{450, 450},
+			statusLog: []TopUpStatus{TopUpFailed, TopUpSucceeded},
+			ambiguous: []bool{true, false},
+		},
+		{
+			name:      "unresolved ambiguous failure blocks new transfers",
+			statuses:  []int{http.StatusBadGateway, http.StatusBadGateway},
+			balance:   80,
+			keys:      []string{"topup-card-1-20240301-1", "topup-card-1-20240301-1"},
+			amounts:   []float64{450, 450},
+			statusLog: []TopUpStatus{TopUpFailed, TopUpFailed},
+			ambiguous: []bool{true, true},
+			err:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &topUpServer{balance: 50, statuses: tt.statuses}
+			log := NewMemoryTopUpLog()
+			engine := newTestTopUpEngine(t, server, log, testTopUpRule())
+
+			_, err := engine.EvaluateCard(context.Background(), "card-1")
+			require.Error(t, err)
+
+			server.balance = tt.balance
+			_, err = engine.EvaluateCard(context.Background(), "card-1")
+			if tt.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.keys, server.keys)
+			var amounts []float64
+			for _, transfer := range server.transfers {
+				amounts = append(amounts, transfer.Amount)
+			}
+			assert.Equal(t, tt.amounts, amounts)
+
+			entries, err := log.Entries(context.Background(), "card-1", time.Time{})
+			require.NoError(t, err)
+			require.Len(t, entries, len(tt.statusLog))
+			for i, entry := range entries {
+				assert.Equal(t, tt.statusLog[i], entry.Status, "entry %d", i)
+				assert.Equal(t, tt.ambiguous[i], entry.Ambiguous, "entry %d", i)
+			}
+		})
+	}
+}
+
+func TestTopUpEngineResolvedTransferCountsTowardsCap(t *testing.T) {
+	server := &topUpServer{balance: 50, statuses: []int{http.StatusBadGateway}}
+	log := NewMemoryTopUpLog()
+	engine := newTestTopUpEngine(t, server, log, testTopUpRule())
+
+	_, err := engine.EvaluateCard(context.Background(), "card-1")
+	require.Error(t, err)
+
+	// The resent transfer goes through, then the card is drained again
+	server.balance = 500
+	entry, err := engine.EvaluateCard(context.Background(), "card-1")
+	require.NoError(t, err)
+	assert.Nil(t, entry, "the card is funded once the transfer is resolved")
+
+	server.balance = 0
+	entry, err = engine.EvaluateCard(context.Background(), "card-1")
+	require.NoError(t, err)
+	assert.Equal(t, 150.0, entry.Amount)
+	assert.Equal(t, "topup-card-1-20240301-2", entry.MerchantTradeNo)
+}
@@ -24,7 +24,7 @@ type CreateTransferRequest struct {
 	Amount         string `json:"amount"`
 	ToAddress      string `json:"toAddress"`
 	Tag            string `json:"tag,omitempty"`
-	IdempotencyKey string `json:"idempotencyKey"`
+	IdempotencyKey string `json:"idempotencyKey"` // Generated by the SDK when empty
 }
 
 // FeeAndQuotaRequest represents the request to get fee and quota
@@ -50,12 +50,14 @@ func NewTransferClient(httpClient *HTTPClient) *TransferClient {
 
 // CreateTransfer creates a new blockchain transfer
 // POST /open-api/v3/cryptoconnect/transfers
-func (c *TransferClient) CreateTransfer(ctx context.Context, req *CreateTransferRequest) (*BlockchainTransfer, error) {
+func (c *TransferClient) CreateTransfer(ctx context.Context, req *CreateTransferRequest, callOpts ...CallOption) (*BlockchainTransfer, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
-	if req.WalletID == "" || req.Currency == "" || req.Chain == "" || req.Amount == "" || req.ToAddress == "" || req.IdempotencyKey == "" {
-		return nil, fmt.Errorf("walletId, currency, chain, amount, toAddress and idempotencyKey are required")
+	if req.WalletID == "" || req.Currency == "" || req.Chain == "" || req.Amount == "" || req.ToAddress == "" {
+		return nil, fmt.Errorf("walletId, currency, chain, amount and toAddress are required")
 	}
 
 	opts := &RequestOptions{
@@ -76,7 +78,9 @@ func (c *TransferClient) CreateTransfer(ctx context.Context, req *CreateTransfer
 
 // ListTransfers retrieves a list of blockchain transfers
 // GET /open-api/v3/cryptoconnect/transfers
-func (c *TransferClient) ListTransfers(ctx context.Context, options *TransferListOptions) (*TransferListResponse, error) {
+func (c *TransferClient) ListTransfers(ctx context.Context, options *TransferListOptions, callOpts ...CallOption) (*TransferListResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	queryParams := url.Values{}
 	
 	if options != nil {
@@ -118,7 +122,9 @@ func (c *TransferClient) ListTransfers(ctx context.Context, options *TransferLis
 
 // GetTransfer retrieves a specific blockchain transfer by ID
 // GET /open-api/v3/cryptoconnect/transfers/{id}
-func (c *TransferClient) GetTransfer(ctx context.Context, transferID string) (*BlockchainTransfer, error) {
+func (c *TransferClient) GetTransfer(ctx context.Context, transferID string, callOpts ...CallOption) (*BlockchainTransfer, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if transferID == "" {
 		return nil, fmt.Errorf("transfer ID cannot be empty")
 	}
@@ -140,7 +146,9 @@ func (c *TransferClient) GetTransfer(ctx context.Context, transferID string) (*B
 
 // GetTransferKYT retrieves KYT (Know Your Transaction) information for a transfer
 // GET /open-api/v3/cryptoconnect/transfers/{id}/kyt
-func (c *TransferClient) GetTransferKYT(ctx context.Context, transferID string) (*TransferKYT, error) {
+func (c *TransferClient) GetTransferKYT(ctx context.Context, transferID string, callOpts ...CallOption) (*TransferKYT, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if transferID == "" {
 		return nil, fmt.Errorf("transfer ID cannot be empty")
 	}
@@ -162,7 +170,9 @@ func (c *TransferClient) GetTransferKYT(ctx context.Context, transferID string)
 
 // GetFeeAndQuota retrieves transfer fee and cross-chain quota information
 // POST /open-api/v3/cryptoconnect/transfers/fee-and-quota
-func (c *TransferClient) GetFeeAndQuota(ctx context.Context, req *FeeAndQuotaRequest) (*FeeAndQuota, error) {
+func (c *TransferClient) GetFeeAndQuota(ctx context.Context, req *FeeAndQuotaRequest, callOpts ...CallOption) (*FeeAndQuota, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -185,3 +195,36 @@ func (c *TransferClient) GetFeeAndQuota(ctx context.Context, req *FeeAndQuotaReq
 
 	return &response, nil
 }
+
+// CreateTransferOperation creates a blockchain transfer and returns an operation that tracks it
+// until it completes or fails
+func (c *TransferClient) CreateTransferOperation(ctx context.Context, req *CreateTransferRequest, callOpts ...CallOption) (*Operation[BlockchainTransfer], error) {
+	transfer, err := c.CreateTransfer(ctx, req, callOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return newOperation(transfer.ID, transfer, c.transferOperationSpec(transfer.ID)), nil
+}
+
+// TransferOperation returns an operation that tracks an existing blockchain transfer
+func (c *TransferClient) TransferOperation(transferID string) *Operation[BlockchainTransfer] {
+	return newOperation(transferID, nil, c.transferOperationSpec(transferID))
+}
+
+func (c *TransferClient) transferOperationSpec(transferID string) operationSpec[BlockchainTransfer] {
+	return operationSpec[BlockchainTransfer]{
+		poll: func(ctx context.Context) (*BlockchainTransfer, error) {
+			return c.GetTransfer(ctx, transferID)
+		},
+		state: func(t *BlockchainTransfer) (OperationState, string, string) {
+			state := statusState(t.Status)
+			// A transfer is final once it has the required number of confirmations
+			if state == OperationPending && t.RequiredConfirms > 0 && t.Confirmations >= t.RequiredConfirms {
+				state = OperationSucceeded
+			}
+			return state, t.Status, ""
+		},
+		eventKeys: []string{"transferId", "id"},
+	}
+}
@@ -365,14 +365,38 @@ type Config struct {
 	ClientID  string
 	UserAgent string
 	Timeout   time.Duration
+
+	// DisableIdempotencyKeys stops the SDK from attaching an Idempotency-Key header and filling
+	// empty merchant reference fields on mutating requests. Mutating requests are then only
+	// retried when rate limited, since they could otherwise execute twice.
+	DisableIdempotencyKeys bool
+
+	// MaxRetries is the number of times a failed request is retried after network errors,
+	// 429 and 5xx responses. Mutating requests are retried with the same idempotency key.
+	MaxRetries int
+	// RetryWaitMin and RetryWaitMax bound the exponential backoff between retries. RetryWaitMax
+	// also caps the delay a Retry-After header asks for.
+	RetryWaitMin time.Duration
+	RetryWaitMax time.Duration
+
+	// CircuitBreaker enables a circuit breaker per endpoint group when set
+	CircuitBreaker *CircuitBreakerConfig
+
+	// Cache enables caching of slow-changing reference data when set
+	Cache *CacheConfig
+
+	// Approval holds large money movements for maker-checker approval when set
+	Approval *ApprovalConfig
 }
 
 // DefaultConfig returns the default configuration for sandbox environment
 func DefaultConfig() *Config {
 	return &Config{
-		BaseURL:   "https://api-sandbox.interlace.money",
-		UserAgent: "interlace-go-sdk/1.0.0",
-		Timeout:   30 * time.Second,
+		BaseURL:      "https://api-sandbox.interlace.money",
+		UserAgent:    "interlace-go-sdk/1.0.0",
+		Timeout:      30 * time.Second,
+		RetryWaitMin: 500 * time.Millisecond,
+		RetryWaitMax: 5 * time.Second,
 	}
 }
 
@@ -380,6 +404,9 @@ func DefaultConfig() *Config {
 type Error struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
+
+	// IdempotencyKey is the idempotency key of the failed request, set for mutating requests
+	IdempotencyKey string `json:"-"`
 }
 
 func (e *Error) Error() string {
@@ -424,6 +451,13 @@ type Card struct {
 	CreatedAt         string  `json:"createdAt"`
 	UpdatedAt         string  `json:"updatedAt"`
 	CardholderName    string  `json:"cardholderName,omitempty"`
+	CardholderID      string  `json:"cardholderId,omitempty"`
+	BudgetID          string  `json:"budgetId,omitempty"`
+	Label             string  `json:"label,omitempty"`
+	DailySpendingLimit       *float64 `json:"dailySpendingLimit,omitempty"`
+	SingleTransLimit         *float64 `json:"singleTransLimit,omitempty"`
+	MonthlySpendingLimit     *float64 `json:"monthlySpendingLimit,omitempty"`
+	ThreeDSecureAuthRequired *bool    `json:"threeDSecureAuthRequired,omitempty"`
 }
 
 // CardListResponse represents the response from list cards API
@@ -627,6 +661,7 @@ type CardBinListResponse struct {
 
 // ConsumptionScenario represents a card transaction scenario
 type ConsumptionScenario struct {
+	ID          string `json:"id,omitempty"`
 	Code        string `json:"code"`
 	Name        string `json:"name"`
 	Description string `json:"description,omitempty"`
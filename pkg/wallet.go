@@ -17,7 +17,7 @@ type WalletListOptions struct {
 type CreateWalletRequest struct {
 	AccountID      string `json:"accountId"`
 	Nickname       string `json:"nickname,omitempty"`
-	IdempotencyKey string `json:"idempotencyKey"`
+	IdempotencyKey string `json:"idempotencyKey"` // Generated by the SDK when empty
 }
 
 // UpdateWalletRequest represents the request to update a wallet nickname
@@ -45,7 +45,9 @@ func NewWalletClient(httpClient *HTTPClient) *WalletClient {
 
 // CreateWallet creates a new crypto wallet
 // POST /open-api/v3/cryptoconnect/wallets
-func (c *WalletClient) CreateWallet(ctx context.Context, req *CreateWalletRequest) (*Wallet, error) {
+func (c *WalletClient) CreateWallet(ctx context.Context, req *CreateWalletRequest, callOpts ...CallOption) (*Wallet, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if req == nil || req.AccountID == "" {
 		return nil, fmt.Errorf("account ID is required")
 	}
@@ -68,7 +70,9 @@ func (c *WalletClient) CreateWallet(ctx context.Context, req *CreateWalletReques
 
 // ListWallets retrieves a list of wallets
 // GET /open-api/v3/cryptoconnect/wallets
-func (c *WalletClient) ListWallets(ctx context.Context, options *WalletListOptions) (*WalletListResponse, error) {
+func (c *WalletClient) ListWallets(ctx context.Context, options *WalletListOptions, callOpts ...CallOption) (*WalletListResponse, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	queryParams := url.Values{}
 	
 	if options != nil {
@@ -101,7 +105,9 @@ func (c *WalletClient) ListWallets(ctx context.Context, options *WalletListOptio
 
 // GetWallet retrieves a specific wallet by ID
 // GET /open-api/v3/cryptoconnect/wallets/{id}
-func (c *WalletClient) GetWallet(ctx context.Context, walletID string) (*Wallet, error) {
+func (c *WalletClient) GetWallet(ctx context.Context, walletID string, callOpts ...CallOption) (*Wallet, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if walletID == "" {
 		return nil, fmt.Errorf("wallet ID cannot be empty")
 	}
@@ -123,7 +129,9 @@ func (c *WalletClient) GetWallet(ctx context.Context, walletID string) (*Wallet,
 
 // UpdateWallet updates a wallet's nickname
 // PATCH /open-api/v3/cryptoconnect/wallets/{id}
-func (c *WalletClient) UpdateWallet(ctx context.Context, walletID string, req *UpdateWalletRequest) (*Wallet, error) {
+func (c *WalletClient) UpdateWallet(ctx context.Context, walletID string, req *UpdateWalletRequest, callOpts ...CallOption) (*Wallet, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if walletID == "" {
 		return nil, fmt.Errorf("wallet ID cannot be empty")
 	}
@@ -149,7 +157,9 @@ func (c *WalletClient) UpdateWallet(ctx context.Context, walletID string, req *U
 
 // CreateWalletAddress creates a new blockchain address for a wallet
 // POST /open-api/v3/cryptoconnect/wallets/{id}/addresses
-func (c *WalletClient) CreateWalletAddress(ctx context.Context, walletID string, req *CreateAddressRequest) (*WalletAddress, error) {
+func (c *WalletClient) CreateWalletAddress(ctx context.Context, walletID string, req *CreateAddressRequest, callOpts ...CallOption) (*WalletAddress, error) {
+	ctx = ContextWithCallOptions(ctx, callOpts...)
+
 	if walletID == "" {
 		return nil, fmt.Errorf("wallet ID cannot be empty")
 	}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
 )
 
 // WebhookClient handles webhook event processing
@@ -131,13 +132,17 @@ type WebhookHandler func(event *WebhookEvent) error
 type WebhookServer struct {
 	client   *WebhookClient
 	handlers map[string]WebhookHandler
+
+	mu          sync.Mutex
+	subscribers map[chan *WebhookEvent]bool
 }
 
 // NewWebhookServer creates a new webhook server
 func NewWebhookServer(webhookSecret string) *WebhookServer {
 	return &WebhookServer{
 		client:   NewWebhookClient(webhookSecret),
-		handlers: make(map[string]WebhookHandler),
+		handlers:    make(map[string]WebhookHandler),
+		subscribers: make(map[chan *WebhookEvent]bool),
 	}
 }
 
@@ -146,6 +151,36 @@ func (s *WebhookServer) RegisterHandler(eventType string, handler WebhookHandler
 	s.handlers[eventType] = handler
 }
 
+// Subscribe returns a channel that receives every verified webhook event, independently of the
+// registered handlers, and a function that cancels the subscription. Events are dropped when the
+// subscriber falls behind.
+func (s *WebhookServer) Subscribe() (<-chan *WebhookEvent, func()) {
+	ch := make(chan *WebhookEvent, 16)
+
+	s.mu.Lock()
+	s.subscribers[ch] = true
+	s.mu.Unlock()
+
+	return ch, func() {
+		s.mu.Lock()
+		delete(s.subscribers, ch)
+		s.mu.Unlock()
+	}
+}
+
+// publish delivers an event to the subscribers without blocking
+func (s *WebhookServer) publish(event *WebhookEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ch := range s.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
 // HandleWebhook handles incoming webhook HTTP requests
 func (s *WebhookServer) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
@@ -159,6 +194,8 @@ func (s *WebhookServer) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	s.publish(event)
+
 	// Find and execute handler
 	handler, exists := s.handlers[event.EventType]
 	if !exists {